@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// forecastRecordInterval is how often record tasks store a location's
+// forecast; providers update theirs every few hours.
+const forecastRecordInterval = 3 * time.Hour
+
+// forecastMatchWindow is how far an observation may be from a forecast's
+// valid time and still be compared with it.
+const forecastMatchWindow = 30 * time.Minute
+
+// ForecastRecord is one forecast slot as issued by a provider, stored so
+// it can later be compared with what was observed.
+type ForecastRecord struct {
+	Location  string    `json:"location"`
+	Provider  string    `json:"provider"`
+	Issued    time.Time `json:"issued"`
+	Valid     time.Time `json:"valid"`
+	Temp      float64   `json:"temp"`
+	WindSpeed float64   `json:"wind_speed"`
+	Pop       float64   `json:"pop"`
+}
+
+func forecastsPath() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "forecasts.jsonl"), nil
+}
+
+// recordForecast appends every slot of a provider's forecast for location
+// to the forecast log.
+func recordForecast(location, provider string, issued time.Time, data *ForecastResponse) error {
+	path, err := forecastsPath()
+	if err != nil {
+		return err
+	}
+	points := forecastPointsFrom(data)
+	records := make([]ForecastRecord, 0, len(points))
+	for _, p := range points {
+		records = append(records, ForecastRecord{
+			Location:  location,
+			Provider:  provider,
+			Issued:    issued.UTC(),
+			Valid:     p.At.UTC(),
+			Temp:      p.Temp,
+			WindSpeed: p.WindSpeed,
+			Pop:       p.Pop,
+		})
+	}
+	return appendJSONLines(path, records)
+}
+
+// ForecastAccuracy is how well one provider's forecasts verified at one
+// lead time: the mean absolute errors of temperature and wind speed, and
+// the Brier score of the chance of precipitation (0 is perfect).
+type ForecastAccuracy struct {
+	Provider string  `json:"provider"`
+	LeadDays int     `json:"lead_days"` // forecasts made up to this many days ahead
+	Count    int     `json:"count"`
+	TempMAE  float64 `json:"temp_mae"`
+	WindMAE  float64 `json:"wind_mae"`
+	PopBrier float64 `json:"pop_brier"`
+}
+
+// precipitating reports whether an observation saw rain or snow.
+func precipitating(obs Observation) bool {
+	switch obs.Condition {
+	case "Rain", "Drizzle", "Thunderstorm", "Snow":
+		return true
+	}
+	return obs.Rain > 0
+}
+
+// nearestObservation returns the observation in observations, sorted by
+// time, closest to at and within forecastMatchWindow of it.
+func nearestObservation(observations []Observation, at time.Time) (Observation, bool) {
+	i := sort.Search(len(observations), func(i int) bool { return !observations[i].At.Before(at) })
+	var best Observation
+	found := false
+	for _, j := range []int{i - 1, i} {
+		if j < 0 || j >= len(observations) {
+			continue
+		}
+		d := observations[j].At.Sub(at).Abs()
+		if d <= forecastMatchWindow && (!found || d < best.At.Sub(at).Abs()) {
+			best, found = observations[j], true
+		}
+	}
+	return best, found
+}
+
+// verifyForecasts compares location's recorded forecasts with the
+// observations recorded for it, by provider and lead time in whole days.
+func verifyForecasts(forecasts []ForecastRecord, observations []Observation, location string) []ForecastAccuracy {
+	var observed []Observation
+	for _, obs := range observations {
+		if obs.Location == location {
+			observed = append(observed, obs)
+		}
+	}
+	sort.Slice(observed, func(i, j int) bool { return observed[i].At.Before(observed[j].At) })
+
+	type key struct {
+		provider string
+		lead     int
+	}
+	sums := make(map[key]*ForecastAccuracy)
+	for _, f := range forecasts {
+		if f.Location != location {
+			continue
+		}
+		obs, ok := nearestObservation(observed, f.Valid)
+		if !ok {
+			continue
+		}
+		k := key{f.Provider, max(int(math.Ceil(f.Valid.Sub(f.Issued).Hours()/24)), 1)}
+		acc := sums[k]
+		if acc == nil {
+			acc = &ForecastAccuracy{Provider: k.provider, LeadDays: k.lead}
+			sums[k] = acc
+		}
+		outcome := 0.0
+		if precipitating(obs) {
+			outcome = 1
+		}
+		acc.Count++
+		acc.TempMAE += math.Abs(f.Temp - obs.Temp)
+		acc.WindMAE += math.Abs(f.WindSpeed - obs.WindSpeed)
+		acc.PopBrier += (f.Pop - outcome) * (f.Pop - outcome)
+	}
+
+	results := make([]ForecastAccuracy, 0, len(sums))
+	for _, acc := range sums {
+		n := float64(acc.Count)
+		acc.TempMAE /= n
+		acc.WindMAE /= n
+		acc.PopBrier /= n
+		results = append(results, *acc)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Provider != results[j].Provider {
+			return results[i].Provider < results[j].Provider
+		}
+		return results[i].LeadDays < results[j].LeadDays
+	})
+	return results
+}
+
+// runAccuracy implements `weather accuracy`, reporting how well each
+// provider's recorded forecasts matched the recorded observations.
+func runAccuracy(args []string) error {
+	fs := flag.NewFlagSet("accuracy", flag.ExitOnError)
+	city := fs.String("city", "", "City or @favorite to report on, as recorded by record tasks")
+	asJSON := fs.Bool("json", false, "Print the figures as JSON")
+	fs.Parse(args)
+
+	name := *city
+	if name == "" {
+		name = strings.Join(fs.Args(), " ")
+	}
+	if name == "" {
+		return fmt.Errorf("usage: weather accuracy [--json] --city <city|@favorite>")
+	}
+	loc, err := resolveLocation(name)
+	if err != nil {
+		return err
+	}
+	usePreferences(loc)
+	path, err := forecastsPath()
+	if err != nil {
+		return err
+	}
+	forecasts, err := readJSONLines[ForecastRecord](path)
+	if err != nil {
+		return err
+	}
+	observations, err := readObservations()
+	if err != nil {
+		return err
+	}
+	results := verifyForecasts(forecasts, observations, loc.String())
+	if len(results) == 0 {
+		return fmt.Errorf("no forecasts of %s to verify yet; give a record task for it \"forecasts\" and wait for the forecast times to pass", loc)
+	}
+
+	if *asJSON {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode accuracy report: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Printf("Forecast accuracy for %s (mean absolute error; Brier score, 0 is perfect)\n\n", loc)
+	fmt.Fprintf(tw, "PROVIDER\tLEAD\tFORECASTS\tTEMP %s\tWIND %s\tPOP\t\n", displayUnits.temp.symbol, displayUnits.speed.symbol)
+	for _, r := range results {
+		fmt.Fprint(tw, lsprintf("%s\t%dd\t%d\t%.2f\t%.2f\t%.3f\t\n", r.Provider, r.LeadDays, r.Count, showTempChange(r.TempMAE), showSpeed(r.WindMAE), r.PopBrier))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// trackedLocation counts the API requests for one location.
+type trackedLocation struct {
+	Location string    `json:"location"`
+	Requests int       `json:"requests"`
+	Last     time.Time `json:"last"`
+}
+
+// providerHealth summarizes the upstream fetches made through one weather
+// provider.
+type providerHealth struct {
+	Provider    string    `json:"provider"`
+	Requests    int       `json:"requests"`
+	Failures    int       `json:"failures"`
+	LastSuccess time.Time `json:"last_success,omitzero"`
+	LastFailure time.Time `json:"last_failure,omitzero"`
+	LastError   string    `json:"last_error,omitempty"`
+	AvgLatency  string    `json:"avg_latency"`
+
+	totalLatency time.Duration
+}
+
+// serverStats collects the runtime state shown by the admin endpoints.
+type serverStats struct {
+	mu        sync.Mutex
+	locations map[string]*trackedLocation
+	providers map[string]*providerHealth
+}
+
+func newServerStats() *serverStats {
+	return &serverStats{
+		locations: make(map[string]*trackedLocation),
+		providers: make(map[string]*providerHealth),
+	}
+}
+
+// requested records an API request for loc.
+func (s *serverStats) requested(loc Location) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name := loc.String()
+	t, ok := s.locations[name]
+	if !ok {
+		t = &trackedLocation{Location: name}
+		s.locations[name] = t
+	}
+	t.Requests++
+	t.Last = time.Now()
+}
+
+// fetched records an upstream fetch through provider that took elapsed.
+// Upstream errors are summarized the way clients see them, since they can
+// include the API key.
+func (s *serverStats) fetched(provider string, elapsed time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, ok := s.providers[provider]
+	if !ok {
+		h = &providerHealth{Provider: provider}
+		s.providers[provider] = h
+	}
+	h.Requests++
+	h.totalLatency += elapsed
+	h.AvgLatency = (h.totalLatency / time.Duration(h.Requests)).Round(time.Millisecond).String()
+	if err != nil {
+		h.Failures++
+		h.LastFailure = time.Now()
+		h.LastError = upstreamErrorSummary(err)
+		return
+	}
+	h.LastSuccess = time.Now()
+}
+
+// upstreamErrorSummary describes err without the request URL.
+func upstreamErrorSummary(err error) string {
+	if apiErr, ok := err.(*APIError); ok {
+		return fmt.Sprintf("HTTP %d", apiErr.StatusCode)
+	}
+	if urlErr, ok := err.(*url.Error); ok {
+		return urlErr.Err.Error()
+	}
+	return err.Error()
+}
+
+// trackedLocations returns the requested locations, most requested first.
+func (s *serverStats) trackedLocations() []trackedLocation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	locations := make([]trackedLocation, 0, len(s.locations))
+	for _, t := range s.locations {
+		locations = append(locations, *t)
+	}
+	sort.Slice(locations, func(i, j int) bool {
+		if locations[i].Requests != locations[j].Requests {
+			return locations[i].Requests > locations[j].Requests
+		}
+		return locations[i].Location < locations[j].Location
+	})
+	return locations
+}
+
+// health returns the per-provider fetch statistics.
+func (s *serverStats) health() []providerHealth {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	health := make([]providerHealth, 0, len(s.providers))
+	for _, h := range s.providers {
+		health = append(health, *h)
+	}
+	sort.Slice(health, func(i, j int) bool { return health[i].Provider < health[j].Provider })
+	return health
+}
+
+// handleAdmin registers the /admin endpoints on mux. They need an API key
+// with "admin": true.
+func (s *apiServer) handleAdmin(mux *http.ServeMux) {
+	mux.HandleFunc("GET /admin/cache", requireAdmin(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.cache.list())
+	}))
+	mux.HandleFunc("DELETE /admin/cache", requireAdmin(func(w http.ResponseWriter, r *http.Request) {
+		var loc *Location
+		if len(r.URL.Query()) > 0 {
+			l, err := locationFromQuery(r.URL.Query())
+			if err != nil {
+				writeJSONError(w, http.StatusBadRequest, err)
+				return
+			}
+			loc = &l
+		}
+		writeJSON(w, http.StatusOK, map[string]int{"purged": s.cache.purge(loc)})
+	}))
+	mux.HandleFunc("GET /admin/locations", requireAdmin(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.stats.trackedLocations())
+	}))
+	mux.HandleFunc("GET /admin/alerts", requireAdmin(func(w http.ResponseWriter, r *http.Request) {
+		alertStoreMu.Lock()
+		store, err := loadAlertStore()
+		alertStoreMu.Unlock()
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, store)
+	}))
+	mux.HandleFunc("GET /admin/health", requireAdmin(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.stats.health())
+	}))
+}
+
+// runAdmin implements `weather admin`, a client for a running server's
+// /admin endpoints.
+func runAdmin(args []string) error {
+	usage := fmt.Errorf("usage: weather admin cache | purge [--city CITY | --zip ZIP | --id ID | --lat LAT --lon LON] | locations | alerts | health [--server URL] [--token KEY]")
+	if len(args) == 0 {
+		return usage
+	}
+	fs := flag.NewFlagSet("admin "+args[0], flag.ExitOnError)
+	server := fs.String("server", "http://localhost:8080", "Base URL of the running weather serve")
+	token := fs.String("token", "", "Admin API key (default $WEATHER_TOOL_ADMIN_TOKEN)")
+	var query url.Values
+	if args[0] == "purge" {
+		query = url.Values{}
+		for _, name := range []string{"city", "zip", "id", "lat", "lon"} {
+			fs.Func(name, "Purge only the responses cached for this "+name, func(v string) error {
+				query.Set(name, v)
+				return nil
+			})
+		}
+	}
+	fs.Parse(args[1:])
+	if fs.NArg() > 0 {
+		return usage
+	}
+	if *token == "" {
+		*token = os.Getenv("WEATHER_TOOL_ADMIN_TOKEN")
+	}
+	client := adminClient{base: strings.TrimSuffix(*server, "/"), token: *token}
+
+	switch args[0] {
+	case "cache":
+		var entries []cachedResponse
+		if err := client.call(http.MethodGet, "/admin/cache", nil, &entries); err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			fmt.Println("The cache is empty.")
+			return nil
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "KIND\tLOCATION\tFETCHED\tSTATE")
+		for _, e := range entries {
+			state := "fresh"
+			if e.Stale {
+				state = "stale"
+			}
+			if e.Tenant {
+				state += ", caller's key"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", e.Kind, e.Location, e.Fetched.Local().Format("2006-01-02 15:04:05"), state)
+		}
+		return tw.Flush()
+
+	case "purge":
+		var result struct {
+			Purged int `json:"purged"`
+		}
+		if err := client.call(http.MethodDelete, "/admin/cache", query, &result); err != nil {
+			return err
+		}
+		fmt.Printf("Purged %d cached responses\n", result.Purged)
+		return nil
+
+	case "locations":
+		var locations []trackedLocation
+		if err := client.call(http.MethodGet, "/admin/locations", nil, &locations); err != nil {
+			return err
+		}
+		if len(locations) == 0 {
+			fmt.Println("No locations requested yet.")
+			return nil
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "LOCATION\tREQUESTS\tLAST")
+		for _, l := range locations {
+			fmt.Fprintf(tw, "%s\t%d\t%s\n", l.Location, l.Requests, l.Last.Local().Format("2006-01-02 15:04:05"))
+		}
+		return tw.Flush()
+
+	case "alerts":
+		var store AlertStore
+		if err := client.call(http.MethodGet, "/admin/alerts", nil, &store); err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(store, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode alert states: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+
+	case "health":
+		var health []providerHealth
+		if err := client.call(http.MethodGet, "/admin/health", nil, &health); err != nil {
+			return err
+		}
+		if len(health) == 0 {
+			fmt.Println("No upstream requests made yet.")
+			return nil
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "PROVIDER\tREQUESTS\tFAILURES\tAVG LATENCY\tLAST ERROR")
+		for _, h := range health {
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\n", h.Provider, h.Requests, h.Failures, h.AvgLatency, h.LastError)
+		}
+		return tw.Flush()
+	}
+	return usage
+}
+
+// adminClient calls a running server's /admin endpoints.
+type adminClient struct {
+	base  string
+	token string
+}
+
+// call sends a method request for path and decodes the JSON response
+// into out.
+func (c adminClient) call(method, path string, query url.Values, out any) error {
+	target := c.base + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, target, nil)
+	if err != nil {
+		return fmt.Errorf("invalid server URL %q: %w", c.base, err)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach server: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var apiErr apiError
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("server returned %s: %s", resp.Status, apiErr.Error)
+		}
+		return fmt.Errorf("server returned %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode server response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const airPollutionURL = "https://api.openweathermap.org/data/2.5/air_pollution"
+
+// AirPollutionResponse is the air pollution API response. Main.AQI is the
+// OpenWeatherMap air quality index from 1 (good) to 5 (very poor).
+type AirPollutionResponse struct {
+	Coord Coord `json:"coord"`
+	List  []struct {
+		Dt   int64 `json:"dt"`
+		Main struct {
+			AQI int `json:"aqi"`
+		} `json:"main"`
+		Components map[string]float64 `json:"components"`
+	} `json:"list"`
+}
+
+// aqiLevels names the air quality index values 1 to 5.
+var aqiLevels = [...]string{"", "Good", "Fair", "Moderate", "Poor", "Very Poor"}
+
+// aqiLabel names an air quality index value.
+func aqiLabel(aqi int) string {
+	if aqi < 1 || aqi >= len(aqiLevels) {
+		return "Unknown"
+	}
+	return aqiLevels[aqi]
+}
+
+// aqiAdvice is the health advice for each air quality index value.
+var aqiAdvice = [...]string{
+	"",
+	"Air quality is good; enjoy the outdoors.",
+	"Air quality is acceptable; unusually sensitive people may notice it.",
+	"Sensitive groups (asthma, heart or lung disease, children, older adults) should cut down long or heavy exertion outdoors.",
+	"Sensitive groups should avoid exertion outdoors, and everyone else should cut it down.",
+	"Everyone should avoid exertion outdoors; keep windows closed if you can.",
+}
+
+// pollutants are the components `weather air` shows, with the upper bounds
+// in µg/m³ of OpenWeatherMap's index bands Good to Poor for each; anything
+// higher is Very Poor.
+var pollutants = []struct {
+	key, name string
+	bands     [4]float64
+}{
+	{"pm2_5", "PM2.5", [4]float64{10, 25, 50, 75}},
+	{"pm10", "PM10", [4]float64{20, 50, 100, 200}},
+	{"o3", "O3", [4]float64{60, 100, 140, 180}},
+	{"no2", "NO2", [4]float64{40, 70, 150, 200}},
+	{"so2", "SO2", [4]float64{20, 80, 250, 350}},
+	{"co", "CO", [4]float64{4400, 9400, 12400, 15400}},
+}
+
+// pollutantIndex returns the 1–5 index band of concentration against
+// bands.
+func pollutantIndex(concentration float64, bands [4]float64) int {
+	for i, limit := range bands {
+		if concentration < limit {
+			return i + 1
+		}
+	}
+	return 5
+}
+
+// runAir implements `weather air`, the current air quality index and
+// pollutant concentrations at a location.
+func runAir(args []string) error {
+	fs := flag.NewFlagSet("air", flag.ExitOnError)
+	locFlags := addLocationFlags(fs)
+	asJSON := fs.Bool("json", false, "Print the raw air pollution data as JSON")
+	fs.Parse(args)
+
+	loc, hasFlagLoc, err := locFlags.location()
+	switch {
+	case err != nil:
+	case hasFlagLoc:
+	case fs.NArg() > 0:
+		loc, err = locFlags.resolveCity(strings.Join(fs.Args(), " "))
+	case config.DefaultCity != "":
+		loc, err = resolveLocation(config.DefaultCity)
+	default:
+		return fmt.Errorf("usage: weather air [--json] <city|@favorite>")
+	}
+	if err != nil {
+		return err
+	}
+	usePreferences(loc)
+
+	apiKey := apiKeyFromEnv()
+	lat, lon, err := loc.coordinates(apiKey)
+	if err != nil {
+		return err
+	}
+	data, err := GetAirPollution(lat, lon, apiKey)
+	if err != nil {
+		return fmt.Errorf("fetching air quality for %s: %w", loc, err)
+	}
+	if *asJSON {
+		out, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode air pollution data: %w", err)
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	air := data.List[0]
+	lprintf("Air quality for %s at %s:\n", loc, formatDate(time.Unix(air.Dt, 0).Local(), "Mon Jan 2 15:04"))
+	lprintf("  AQI: %d (%s)\n", air.Main.AQI, aqiLabel(air.Main.AQI))
+	if air.Main.AQI >= 1 && air.Main.AQI < len(aqiAdvice) {
+		fmt.Printf("  %s\n", aqiAdvice[air.Main.AQI])
+	}
+	fmt.Println()
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "POLLUTANT\tµg/m³\tRATING")
+	for _, p := range pollutants {
+		concentration, ok := air.Components[p.key]
+		if !ok {
+			continue
+		}
+		fmt.Fprintln(tw, lsprintf("%s\t%.1f\t%s", p.name, concentration, aqiLabel(pollutantIndex(concentration, p.bands))))
+	}
+	tw.Flush()
+	fmt.Println("The AQI is the worst of the pollutants' ratings.")
+	return nil
+}
+
+// GetAirPollution fetches current air pollution data for coordinates.
+func GetAirPollution(lat, lon float64, apiKey string) (*AirPollutionResponse, error) {
+	params := url.Values{
+		"lat":   {strconv.FormatFloat(lat, 'f', -1, 64)},
+		"lon":   {strconv.FormatFloat(lon, 'f', -1, 64)},
+		"appid": {apiKey},
+	}
+	var data AirPollutionResponse
+	if err := fetchWeatherData(airPollutionURL+"?"+params.Encode(), &data); err != nil {
+		return nil, err
+	}
+	if len(data.List) == 0 {
+		return nil, fmt.Errorf("no air pollution data returned")
+	}
+	return &data, nil
+}
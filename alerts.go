@@ -0,0 +1,343 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultAlertCooldown is how long a still-firing alert waits before it is
+// sent again at the same severity.
+const defaultAlertCooldown = 6 * time.Hour
+
+// Alert severities, from least to most serious. Built-in severe-weather
+// alerts are severe or extreme; rules with a condition default to warning.
+const (
+	severityNone    = ""
+	severityWarning = "warning"
+	severitySevere  = "severe"
+	severityExtreme = "extreme"
+)
+
+// alertSeverity grades the current conditions at loc: extreme for
+// tornadoes, squalls, heavy thunderstorms and storm-force winds, severe for
+// anything else isSevere flags. The wind speeds come from loc's
+// alertThresholds.
+func alertSeverity(loc Location, data *CurrentWeatherResponse) string {
+	for _, w := range data.Weather {
+		switch w.ID {
+		case 202, 212, 221, 771, 781: // Heavy or ragged thunderstorm, squalls, tornado
+			return severityExtreme
+		}
+	}
+	thresholds := alertThresholds(loc)
+	if data.Wind.Speed >= thresholds.ExtremeWind {
+		return severityExtreme
+	}
+	if severeCondition(data) || data.Wind.Speed >= thresholds.SevereWind {
+		return severitySevere
+	}
+	return severityNone
+}
+
+// AlertState is what was last seen and sent for one rule at one location.
+type AlertState struct {
+	Severity   string    `json:"severity"`
+	Since      time.Time `json:"since"`
+	NotifiedAt time.Time `json:"notified_at,omitempty"`
+}
+
+// AlertStore is the persisted alert state shared by the daemon and the
+// `weather alerts` command.
+type AlertStore struct {
+	// Firing holds the rules currently firing, keyed by alertKey.
+	Firing map[string]AlertState `json:"firing"`
+	// Snoozed maps rule names to when their snooze ends.
+	Snoozed map[string]time.Time `json:"snoozed"`
+}
+
+// alertStoreMu serializes read-modify-write cycles of the alert store
+// between alert tasks running in the same daemon.
+var alertStoreMu sync.Mutex
+
+func alertKey(rule string, loc Location) string {
+	return rule + " @ " + loc.String()
+}
+
+func alertStorePath() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "alerts.json"), nil
+}
+
+func loadAlertStore() (*AlertStore, error) {
+	path, err := alertStorePath()
+	if err != nil {
+		return nil, err
+	}
+	store := &AlertStore{}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read alert state: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, store); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	}
+	if store.Firing == nil {
+		store.Firing = make(map[string]AlertState)
+	}
+	if store.Snoozed == nil {
+		store.Snoozed = make(map[string]time.Time)
+	}
+	return store, nil
+}
+
+func saveAlertStore(store *AlertStore) error {
+	path, err := alertStorePath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(store, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode alert state: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write alert state: %w", err)
+	}
+	return nil
+}
+
+// snoozed reports whether rule is silenced at now.
+func (s *AlertStore) snoozed(rule string, now time.Time) bool {
+	return now.Before(s.Snoozed[rule])
+}
+
+// Alert log events.
+const (
+	eventFired   = "fired"   // started firing or changed severity
+	eventSnoozed = "snoozed" // fired while the rule was snoozed
+	eventCleared = "cleared" // stopped firing
+	eventSent    = "sent"    // delivered through a channel
+	eventHeld    = "held"    // held back for the channel's quiet hours
+	eventFailed  = "failed"  // delivery through a channel failed
+)
+
+// update records severity for rule at loc and reports whether a
+// notification should go out: when the alert starts firing, when its
+// severity changes, or when it is still firing after cooldown. Nothing is
+// sent while the rule is snoozed; it is sent once the snooze ends if the
+// alert is still firing and hasn't been sent within the cooldown. The
+// returned event, if any, is the state change to log.
+func (s *AlertStore) update(rule string, loc Location, severity string, cooldown time.Duration, now time.Time) (bool, string) {
+	key := alertKey(rule, loc)
+	prev, firing := s.Firing[key]
+	if severity == severityNone {
+		if !firing {
+			return false, ""
+		}
+		delete(s.Firing, key)
+		return false, eventCleared
+	}
+
+	state := prev
+	var event string
+	if !firing || prev.Severity != severity {
+		state = AlertState{Severity: severity, Since: now}
+		event = eventFired
+	}
+	snoozed := s.snoozed(rule, now)
+	if event != "" && snoozed {
+		event = eventSnoozed
+	}
+	notify := !snoozed && (state.NotifiedAt.IsZero() || now.Sub(state.NotifiedAt) >= cooldown)
+	if notify {
+		state.NotifiedAt = now
+	}
+	s.Firing[key] = state
+	return notify, event
+}
+
+// AlertLogEntry is one line of the alert audit log.
+type AlertLogEntry struct {
+	At       time.Time `json:"at"`
+	Rule     string    `json:"rule"`
+	Event    string    `json:"event"`
+	Location string    `json:"location,omitempty"`
+	Severity string    `json:"severity,omitempty"`
+	Channel  string    `json:"channel,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+func alertLogPath() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "alerts-log.jsonl"), nil
+}
+
+// logAlertEvents appends entries to the alert audit log.
+func logAlertEvents(entries ...AlertLogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	path, err := alertLogPath()
+	if err != nil {
+		return err
+	}
+	return appendJSONLines(path, entries)
+}
+
+// alertRuleNames returns the names of the alerts tasks in the config, which
+// are the rules that can be snoozed, sorted.
+func alertRuleNames() []string {
+	var names []string
+	for _, task := range config.Daemon.Tasks {
+		if task.Type == "alerts" {
+			names = append(names, cmp.Or(task.Name, task.Type))
+		}
+	}
+	sort.Strings(names)
+	return slices.Compact(names)
+}
+
+// runAlerts implements `weather alerts status|snooze|unsnooze`.
+func runAlerts(args []string) error {
+	usage := fmt.Errorf("usage: weather alerts status | log [--rule RULE] [--since DURATION] | snooze <rule> <duration> | unsnooze <rule>")
+	if len(args) == 0 {
+		return usage
+	}
+	if args[0] == "log" {
+		return runAlertsLog(args[1:])
+	}
+	alertStoreMu.Lock()
+	defer alertStoreMu.Unlock()
+	store, err := loadAlertStore()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+
+	switch args[0] {
+	case "status":
+		if len(args) != 1 {
+			return usage
+		}
+		if len(store.Firing) == 0 {
+			fmt.Println("No alerts firing.")
+		}
+		keys := make([]string, 0, len(store.Firing))
+		for key := range store.Firing {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			state := store.Firing[key]
+			fmt.Printf("%-40s %-8s since %s", key, state.Severity, state.Since.Local().Format("2006-01-02 15:04"))
+			if !state.NotifiedAt.IsZero() {
+				fmt.Printf(", last sent %s", state.NotifiedAt.Local().Format("2006-01-02 15:04"))
+			}
+			fmt.Println()
+		}
+		for rule, until := range store.Snoozed {
+			if now.Before(until) {
+				fmt.Printf("%s is snoozed until %s\n", rule, until.Local().Format("2006-01-02 15:04"))
+			}
+		}
+		return nil
+
+	case "snooze":
+		if len(args) != 3 {
+			return usage
+		}
+		d, err := time.ParseDuration(args[2])
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid snooze duration %q, use e.g. 30m or 6h", args[2])
+		}
+		rules := alertRuleNames()
+		if !slices.Contains(rules, args[1]) {
+			if len(rules) == 0 {
+				return fmt.Errorf("unknown rule %q: no alerts tasks are configured in the \"daemon\" section of the config file", args[1])
+			}
+			return fmt.Errorf("unknown rule %q, use one of %s", args[1], strings.Join(rules, ", "))
+		}
+		until := now.Add(d)
+		store.Snoozed[args[1]] = until
+		fmt.Printf("Snoozed %s until %s\n", args[1], until.Local().Format("2006-01-02 15:04"))
+
+	case "unsnooze":
+		if len(args) != 2 {
+			return usage
+		}
+		if _, ok := store.Snoozed[args[1]]; !ok {
+			return fmt.Errorf("%s is not snoozed", args[1])
+		}
+		delete(store.Snoozed, args[1])
+		fmt.Printf("Unsnoozed %s\n", args[1])
+
+	default:
+		return usage
+	}
+
+	for rule, until := range store.Snoozed {
+		if !now.Before(until) {
+			delete(store.Snoozed, rule)
+		}
+	}
+	return saveAlertStore(store)
+}
+
+// runAlertsLog implements `weather alerts log`, printing what fired and
+// where it was delivered, oldest first.
+func runAlertsLog(args []string) error {
+	fs := flag.NewFlagSet("alerts log", flag.ExitOnError)
+	rule := fs.String("rule", "", "Only show entries for this rule")
+	since := fs.Duration("since", 7*24*time.Hour, "How far back to show")
+	fs.Parse(args)
+
+	path, err := alertLogPath()
+	if err != nil {
+		return err
+	}
+	entries, err := readJSONLines[AlertLogEntry](path)
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-*since)
+	shown := 0
+	for _, e := range entries {
+		if e.At.Before(cutoff) || (*rule != "" && e.Rule != *rule) {
+			continue
+		}
+		fmt.Printf("%s  %-12s %-8s %s", e.At.Local().Format("2006-01-02 15:04"), e.Rule, e.Event, e.Location)
+		if e.Severity != "" {
+			fmt.Printf(" (%s)", e.Severity)
+		}
+		if e.Channel != "" {
+			fmt.Printf(" via %s", e.Channel)
+		}
+		if e.Error != "" {
+			fmt.Printf(": %s", e.Error)
+		}
+		fmt.Println()
+		shown++
+	}
+	if shown == 0 {
+		fmt.Println("No alert activity in that period.")
+	}
+	return nil
+}
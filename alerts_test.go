@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAlertsSnoozeRuleNames(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	tasks := []DaemonTask{
+		{Type: "alerts"},
+		{Name: "storm", Type: "alerts"},
+		{Name: "morning", Type: "digest"},
+	}
+	tests := []struct {
+		name    string
+		tasks   []DaemonTask
+		rule    string
+		wantErr string
+	}{
+		{"named rule", tasks, "storm", ""},
+		{"default name", tasks, "alerts", ""},
+		{"typo", tasks, "strom", `unknown rule "strom", use one of alerts, storm`},
+		{"not an alerts task", tasks, "morning", "use one of alerts, storm"},
+		{"no rules", nil, "storm", "no alerts tasks are configured"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withConfig(t, Config{Daemon: DaemonConfig{Tasks: tt.tasks}}, func() {
+				err := runAlerts([]string{"snooze", tt.rule, "1h"})
+				if tt.wantErr == "" {
+					if err != nil {
+						t.Fatal(err)
+					}
+					store, err := loadAlertStore()
+					if err != nil {
+						t.Fatal(err)
+					}
+					if _, ok := store.Snoozed[tt.rule]; !ok {
+						t.Errorf("%s not snoozed", tt.rule)
+					}
+					return
+				}
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want %q", err, tt.wantErr)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// alfredItem is a single result in Alfred/Raycast script-filter JSON.
+// See https://www.alfredapp.com/help/workflows/inputs/script-filter/json/.
+type alfredItem struct {
+	UID      string      `json:"uid,omitempty"`
+	Title    string      `json:"title"`
+	Subtitle string      `json:"subtitle"`
+	Arg      string      `json:"arg,omitempty"`
+	Icon     *alfredIcon `json:"icon,omitempty"`
+}
+
+// alfredIcon points at an icon file relative to the workflow directory.
+// Icons are looked up as icons/<OpenWeatherMap icon code>.png, e.g.
+// icons/10d.png, so workflows can bundle the OWM icon set.
+type alfredIcon struct {
+	Path string `json:"path"`
+}
+
+func alfredIconFor(w []Weather) *alfredIcon {
+	if len(w) == 0 {
+		return nil
+	}
+	return &alfredIcon{Path: fmt.Sprintf("icons/%s.png", w[0].Icon)}
+}
+
+func printAlfredItems(items []alfredItem) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(map[string][]alfredItem{"items": items})
+}
+
+// displayCurrentWeatherAlfred prints current weather as a script-filter item.
+func displayCurrentWeatherAlfred(data *CurrentWeatherResponse) {
+	condition := "N/A"
+	if len(data.Weather) > 0 {
+		condition = data.Weather[0].Description
+	}
+	temp, speed := displayUnits.temp.symbol, displayUnits.speed.symbol
+	printAlfredItems([]alfredItem{{
+		UID:   fmt.Sprintf("current-%d", data.ID),
+		Title: lsprintf("%.*f%s %s — %s, %s", decimals("temp", 1), showTemp(data.Main.Temp), temp, condition, data.Name, data.Sys.Country),
+		Subtitle: lsprintf("Feels like %.*f%s · Humidity %d%% · Wind %.*f %s · Sunrise %s · Sunset %s",
+			decimals("temp", 1), showTemp(data.Main.FeelsLike), temp, data.Main.Humidity, decimals("wind", 1), showSpeed(data.Wind.Speed), speed,
+			time.Unix(data.Sys.Sunrise, 0).Local().Format("15:04"),
+			time.Unix(data.Sys.Sunset, 0).Local().Format("15:04")),
+		Arg:  fmt.Sprintf("https://openweathermap.org/city/%d", data.ID),
+		Icon: alfredIconFor(data.Weather),
+	}})
+}
+
+// displayForecastAlfred prints one script-filter item per forecast entry.
+func displayForecastAlfred(data *ForecastResponse) {
+	items := make([]alfredItem, 0, len(data.List))
+	temp, speed := displayUnits.temp.symbol, displayUnits.speed.symbol
+	for _, entry := range data.List {
+		condition := "N/A"
+		if len(entry.Weather) > 0 {
+			condition = entry.Weather[0].Description
+		}
+		items = append(items, alfredItem{
+			UID:   fmt.Sprintf("forecast-%d-%d", data.City.ID, entry.Dt),
+			Title: lsprintf("%s: %.*f%s %s", formatDate(time.Unix(entry.Dt, 0).Local(), "Mon 15:04"), decimals("temp", 1), showTemp(entry.Main.Temp), temp, condition),
+			Subtitle: lsprintf("%s, %s · Feels like %.*f%s · Wind %.*f %s · Rain %.0f%%",
+				data.City.Name, data.City.Country, decimals("temp", 1), showTemp(entry.Main.FeelsLike), temp, decimals("wind", 1), showSpeed(entry.Wind.Speed), speed, entry.Pop*100),
+			Arg:  fmt.Sprintf("https://openweathermap.org/city/%d", data.City.ID),
+			Icon: alfredIconFor(entry.Weather),
+		})
+	}
+	printAlfredItems(items)
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Mugambi645/weather-tool/pkg/weather"
+)
+
+// The API response types live in pkg/weather so other programs can use
+// them; these aliases keep their short names here.
+type (
+	Weather                = weather.Weather
+	Main                   = weather.Main
+	Wind                   = weather.Wind
+	Clouds                 = weather.Clouds
+	Rain                   = weather.Rain
+	Rain3h                 = weather.Rain3h
+	Sys                    = weather.Sys
+	Coord                  = weather.Coord
+	CurrentWeatherResponse = weather.CurrentWeatherResponse
+	City                   = weather.City
+	ForecastListEntry      = weather.ForecastListEntry
+	ForecastResponse       = weather.ForecastResponse
+	APIError               = weather.APIError
+)
+
+// owmClient makes the OpenWeatherMap requests. The API key travels in each
+// request's parameters, so the client itself doesn't hold one.
+var owmClient = weather.NewClient("")
+
+// fetchWeatherData requests requestURL, any OpenWeatherMap URL with its
+// parameters, and decodes the JSON answer into target.
+func fetchWeatherData(requestURL string, target interface{}) error {
+	return owmClient.GetJSON(context.Background(), requestURL, target)
+}
+
+// sendJSON sends body, encoded as JSON, to requestURL with method and
+// decodes the response into target unless it is nil. Like fetchWeatherData
+// it keeps the query string, and so the API key, out of errors.
+func sendJSON(method, requestURL string, body, target interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, requestURL, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL, _, _ = strings.Cut(urlErr.URL, "?")
+		}
+		return fmt.Errorf("failed to make HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return &APIError{StatusCode: resp.StatusCode, Body: string(bodyBytes)}
+	}
+	if target == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to unmarshal JSON response: %w", err)
+	}
+	return nil
+}
+
+// GetCurrentWeather fetches current weather data for a given city.
+func GetCurrentWeather(city string, apiKey string) (*CurrentWeatherResponse, error) {
+	return GetCurrentWeatherAt(Location{Name: city}, apiKey)
+}
+
+// GetCurrentWeatherAt fetches current weather data for a location.
+func GetCurrentWeatherAt(loc Location, apiKey string) (*CurrentWeatherResponse, error) {
+	var weatherData CurrentWeatherResponse
+	if err := fetchWithHooks("current", loc, apiKey, &weatherData); err != nil {
+		return nil, err
+	}
+	statsd.gauges(loc, &weatherData)
+	return &weatherData, nil
+}
+
+// GetCurrentWeatherByCoords fetches current weather data for exact
+// coordinates.
+func GetCurrentWeatherByCoords(lat, lon float64, apiKey string) (*CurrentWeatherResponse, error) {
+	if err := weather.ValidateCoordinates(lat, lon); err != nil {
+		return nil, err
+	}
+	return GetCurrentWeatherAt(Location{Lat: lat, Lon: lon, HasCoords: true}, apiKey)
+}
+
+// GetForecast fetches 5-day / 3-hour forecast data for a given city.
+func GetForecast(city string, apiKey string) (*ForecastResponse, error) {
+	return GetForecastAt(Location{Name: city}, apiKey)
+}
+
+// GetForecastAt fetches 5-day / 3-hour forecast data for a location.
+func GetForecastAt(loc Location, apiKey string) (*ForecastResponse, error) {
+	var forecastData ForecastResponse
+	if err := fetchWithHooks("forecast", loc, apiKey, &forecastData); err != nil {
+		return nil, err
+	}
+	return &forecastData, nil
+}
+
+// GetForecastByCoords fetches 5-day / 3-hour forecast data for exact
+// coordinates.
+func GetForecastByCoords(lat, lon float64, apiKey string) (*ForecastResponse, error) {
+	if err := weather.ValidateCoordinates(lat, lon); err != nil {
+		return nil, err
+	}
+	return GetForecastAt(Location{Lat: lat, Lon: lon, HasCoords: true}, apiKey)
+}
+
+// fetchWithHooks fetches kind ("current" or "forecast") data for loc from
+// the first provider in the fallback chain that answers into target,
+// running the pre- and post-fetch hooks.
+func fetchWithHooks(kind string, loc Location, apiKey string, target interface{}) error {
+	request := fetchRequest{Kind: kind, Location: loc}
+	if err := runHooks(hookPreFetch, kind, loc, &request); err != nil {
+		return err
+	}
+	loc = request.Location
+
+	var errs []error
+	for _, p := range providerChain() {
+		err := fetchFrom(p, kind, loc, apiKey, target)
+		if err == nil {
+			return runHooks(hookPostFetch, kind, loc, target)
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
+
+// fetchFrom fetches kind ("current" or "forecast") data for loc from the
+// provider p into target, without running hooks.
+func fetchFrom(p, kind string, loc Location, apiKey string, target interface{}) error {
+	switch {
+	case p != defaultProvider:
+		return fetchFromPlugin(p, kind, loc, target)
+	case kind == "forecast":
+		return owmClient.Fetch(context.Background(), "forecast", loc.query(apiKey), target)
+	}
+	return owmClient.Fetch(context.Background(), "weather", loc.query(apiKey), target)
+}
@@ -0,0 +1,419 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// question is a parsed `weather ask` question.
+type question struct {
+	place string // as written, e.g. "Nairobi" or "@home"; empty for the default city
+	// variable is what's asked about: "rain", "snow", "hot", "cold",
+	// "temp", "wind", "sun" or "humidity", or empty for an overview.
+	variable string
+	from, to time.Time
+	when     string // the window as the answer phrases it, e.g. "tomorrow afternoon"
+}
+
+// questionWords maps the words a question can use to the variable it asks
+// about.
+var questionWords = map[string]string{
+	"rain": "rain", "raining": "rain", "rainy": "rain", "wet": "rain", "umbrella": "rain", "shower": "rain",
+	"showers": "rain", "drizzle": "rain", "precipitation": "rain", "storm": "rain", "stormy": "rain",
+	"snow": "snow", "snowing": "snow", "snowy": "snow",
+	"hot": "hot", "warm": "hot", "cold": "cold", "cool": "cold", "chilly": "cold", "freezing": "cold",
+	"temperature": "temp", "temp": "temp", "degrees": "temp",
+	"wind": "wind", "windy": "wind", "breezy": "wind", "gusty": "wind",
+	"sun": "sun", "sunny": "sun", "clear": "sun", "cloudy": "sun", "clouds": "sun", "overcast": "sun",
+	"humid": "humidity", "humidity": "humidity",
+}
+
+// dayParts are the hours of the day each part covers; night runs into the
+// next morning.
+var dayParts = map[string][2]int{
+	"morning":   {6, 12},
+	"afternoon": {12, 18},
+	"evening":   {18, 24},
+	"night":     {18, 30},
+	"overnight": {18, 30},
+}
+
+// placeStopWords end a place name that follows "in" or "for".
+var placeStopWords = map[string]bool{
+	"today": true, "tomorrow": true, "tonight": true, "this": true, "next": true, "on": true, "at": true,
+	"around": true, "by": true, "during": true, "later": true, "now": true, "in": true, "weekend": true,
+	"and": true, "or": true, "the": true,
+}
+
+// parseQuestion extracts the place, time window and variable from a
+// question such as "will it rain in Nairobi tomorrow afternoon?". Anything
+// it doesn't recognize is ignored, so it defaults to an overview of the
+// next 24 hours.
+func parseQuestion(s string, now time.Time) (question, error) {
+	var q question
+	tokens := strings.Fields(s)
+	words := make([]string, len(tokens))
+	for i, t := range tokens {
+		words[i] = strings.ToLower(strings.Trim(t, "?!.,;:\"'"))
+	}
+
+	var (
+		day              time.Time
+		dayLabel, part   string
+		clock            time.Time
+		span, offset     time.Duration
+		hasClock, isSpan bool
+		rightNow         bool
+	)
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		if d, n := parseAskDuration(words[i+1:]); d > 0 && (w == "in" || w == "next" || w == "for") {
+			if w == "in" {
+				offset = d
+			} else {
+				span, isSpan = d, true
+			}
+			i += n
+			continue
+		}
+		if weekday, ok := parseWeekday(w); ok {
+			day = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+			day = day.AddDate(0, 0, (int(weekday)-int(now.Weekday())+7)%7)
+			dayLabel = "on " + weekday.String()
+			continue
+		}
+		switch {
+		case w == "in" || w == "for":
+			var place []string
+			for i+1 < len(words) && !placeStopWords[words[i+1]] && dayParts[words[i+1]] == [2]int{} {
+				if _, ok := parseWeekday(words[i+1]); ok {
+					break
+				}
+				i++
+				place = append(place, strings.Trim(tokens[i], "?!.;:\"'"))
+			}
+			if len(place) > 0 {
+				q.place = strings.TrimSuffix(strings.Join(place, " "), ",")
+			}
+		case w == "now":
+			rightNow = true
+		case w == "at" || w == "around" || w == "by":
+			if t, n, ok := parseAskClock(words[i+1:]); ok {
+				clock, hasClock = t, true
+				i += n
+			}
+		case w == "today":
+			day, dayLabel = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()), "today"
+		case w == "tomorrow":
+			day, dayLabel = time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location()), "tomorrow"
+		case w == "tonight":
+			day, dayLabel, part = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()), "today", "night"
+		case w == "weekend":
+			dayLabel = "weekend"
+		case dayParts[w] != [2]int{}:
+			part = w
+		case questionWords[w] != "" && q.variable == "":
+			q.variable = questionWords[w]
+		}
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch {
+	case offset > 0:
+		at := now.Add(offset)
+		q.from, q.to = at.Add(-90*time.Minute), at.Add(90*time.Minute)
+		q.when = "around " + spokenClock(at)
+	case isSpan:
+		q.from, q.to = now, now.Add(span)
+		q.when = "in the next " + spokenDuration(span)
+	case dayLabel == "weekend":
+		saturday := today.AddDate(0, 0, (int(time.Saturday)-int(now.Weekday())+7)%7)
+		if now.Weekday() == time.Sunday {
+			saturday = today.AddDate(0, 0, -1)
+		}
+		q.from, q.to, q.when = saturday, saturday.AddDate(0, 0, 2), "this weekend"
+	case hasClock:
+		if day.IsZero() {
+			day, dayLabel = today, "today"
+			if at := day.Add(time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute); at.Before(now) {
+				day, dayLabel = day.AddDate(0, 0, 1), "tomorrow"
+			}
+		}
+		at := day.Add(time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute)
+		q.from, q.to = at.Add(-90*time.Minute), at.Add(90*time.Minute)
+		q.when = strings.TrimSpace(dayLabel + " at " + spokenClock(at))
+	case part != "":
+		if day.IsZero() {
+			day, dayLabel = today, "today"
+			if day.Add(time.Duration(dayParts[part][1]) * time.Hour).Before(now) {
+				day, dayLabel = day.AddDate(0, 0, 1), "tomorrow"
+			}
+		}
+		hours := dayParts[part]
+		q.from, q.to = day.Add(time.Duration(hours[0])*time.Hour), day.Add(time.Duration(hours[1])*time.Hour)
+		switch {
+		case dayLabel == "today" && part == "night":
+			q.when = "tonight"
+		case dayLabel == "today":
+			q.when = "this " + part
+		default:
+			q.when = dayLabel + " " + part
+		}
+	case !day.IsZero():
+		q.from, q.to, q.when = day, day.AddDate(0, 0, 1), dayLabel
+	default:
+		q.from, q.to, q.when = now, now.Add(24*time.Hour), "in the next 24 hours"
+	}
+	if rightNow && offset == 0 && !isSpan && !hasClock {
+		q.from, q.to, q.when = now, now.Add(3*time.Hour), "right now"
+	}
+
+	if !q.to.After(now) {
+		return q, fmt.Errorf("%s is in the past; ask about today or later", q.when)
+	}
+	if q.from.Before(now) {
+		q.from = now
+	}
+	return q, nil
+}
+
+// parseAskDuration parses "3 hours", "an hour", "a few hours" or "90
+// minutes" at the start of words, returning the duration and the number
+// of words used.
+func parseAskDuration(words []string) (time.Duration, int) {
+	if len(words) == 0 {
+		return 0, 0
+	}
+	n, used := 1, 1
+	if len(words) > 1 && (words[0] == "a" || words[0] == "an") && (words[1] == "few" || words[1] == "couple") {
+		used = 2
+	}
+	switch words[used-1] {
+	case "a", "an", "one":
+	case "couple":
+		n = 2
+	case "few":
+		n = 3
+	default:
+		var err error
+		if n, err = strconv.Atoi(words[0]); err != nil {
+			n, used = 1, 0
+		}
+	}
+	if used < len(words) && words[used] == "of" {
+		used++
+	}
+	if used >= len(words) {
+		return 0, 0
+	}
+	switch words[used] {
+	case "hour", "hours", "hr", "hrs", "h":
+		return time.Duration(n) * time.Hour, used + 1
+	case "minute", "minutes", "min", "mins":
+		return time.Duration(n) * time.Minute, used + 1
+	case "day", "days":
+		return time.Duration(n) * 24 * time.Hour, used + 1
+	}
+	return 0, 0
+}
+
+// parseAskClock parses "17:00", "5pm", "5:30pm" or "5 pm" at the start of
+// words, returning the time of day and the number of words used.
+func parseAskClock(words []string) (time.Time, int, bool) {
+	if len(words) == 0 {
+		return time.Time{}, 0, false
+	}
+	candidates := []struct {
+		text string
+		used int
+	}{{words[0], 1}}
+	if len(words) > 1 && (words[1] == "am" || words[1] == "pm") {
+		candidates = append([]struct {
+			text string
+			used int
+		}{{words[0] + words[1], 2}}, candidates...)
+	}
+	for _, c := range candidates {
+		for _, layout := range []string{"15:04", "3pm", "3:04pm"} {
+			if t, err := time.Parse(layout, c.text); err == nil {
+				return t, c.used, true
+			}
+		}
+	}
+	return time.Time{}, 0, false
+}
+
+// parseWeekday recognizes a full weekday name; abbreviations would catch
+// words like "sun" and "wed".
+func parseWeekday(w string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if w == strings.ToLower(d.String()) {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// spokenDuration phrases a duration as "hour", "6 hours" or "90 minutes".
+func spokenDuration(d time.Duration) string {
+	switch {
+	case d == time.Hour:
+		return "hour"
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%d hours", d/time.Hour)
+	}
+	return fmt.Sprintf("%d minutes", d/time.Minute)
+}
+
+// answer replies to q from the forecast points for place.
+func (q question) answer(place string, points []ForecastPoint) (string, error) {
+	var window []ForecastPoint
+	for _, p := range points {
+		// Each point stands for the 3 hours around it.
+		if !p.At.Before(q.from.Add(-90*time.Minute)) && p.At.Before(q.to) {
+			window = append(window, p)
+		}
+	}
+	if len(points) == 0 {
+		return "", fmt.Errorf("no forecast returned")
+	}
+	if len(window) == 0 {
+		if q.from.After(points[len(points)-1].At) {
+			return "", fmt.Errorf("that's beyond the forecast, which only reaches %s", formatDate(points[len(points)-1].At.Local(), "Mon Jan 2 15:04"))
+		}
+		return "", fmt.Errorf("no forecast entries %s", q.when)
+	}
+
+	where := place + " " + q.when
+	temp := displayUnits.temp.symbol
+	low, high := window[0].Temp, window[0].Temp
+	feelsLow, feelsHigh := window[0].FeelsLike, window[0].FeelsLike
+	wettest, windiest := window[0], window[0]
+	humidLow, humidHigh, clouds := window[0].Humidity, window[0].Humidity, 0.0
+	snow := false
+	conditions := make(map[string]int)
+	for _, p := range window {
+		low, high = min(low, p.Temp), max(high, p.Temp)
+		feelsLow, feelsHigh = min(feelsLow, p.FeelsLike), max(feelsHigh, p.FeelsLike)
+		humidLow, humidHigh = min(humidLow, p.Humidity), max(humidHigh, p.Humidity)
+		clouds += p.Clouds / float64(len(window))
+		if p.Pop > wettest.Pop {
+			wettest = p
+		}
+		if max(p.WindSpeed, p.WindGust) > max(windiest.WindSpeed, windiest.WindGust) {
+			windiest = p
+		}
+		snow = snow || p.Condition == "Snow"
+		conditions[p.Description]++
+	}
+
+	switch q.variable {
+	case "rain":
+		switch pop := wettest.Pop * 100; {
+		case wettest.Pop >= rainPopThreshold:
+			return lsprintf("Yes, rain is likely in %s: %.0f%% chance, most likely around %s.", where, pop, spokenClock(wettest.At.Local())), nil
+		case pop >= 20:
+			return lsprintf("Possibly; there's a %.0f%% chance of rain in %s.", pop, where), nil
+		default:
+			return lsprintf("Probably not; the chance of rain in %s is at most %.0f%%.", where, pop), nil
+		}
+	case "snow":
+		if snow {
+			return lsprintf("Yes, snow is forecast in %s, with temperatures from %.0f to %.0f%s.", where, showTemp(low), showTemp(high), temp), nil
+		}
+		return lsprintf("No snow is forecast in %s (%.0f to %.0f%s).", where, showTemp(low), showTemp(high), temp), nil
+	case "hot", "cold", "temp":
+		verdict := ""
+		switch {
+		case q.variable == "hot" && high >= 28:
+			verdict = "Yes, it'll be hot. "
+		case q.variable == "hot":
+			verdict = "Not especially. "
+		case q.variable == "cold" && low <= 10:
+			verdict = "Yes, it'll be cold. "
+		case q.variable == "cold":
+			verdict = "Not especially. "
+		}
+		return verdict + lsprintf("Between %.0f and %.0f%s in %s, feeling like %.0f to %.0f%s.", showTemp(low), showTemp(high), temp, where, showTemp(feelsLow), showTemp(feelsHigh), temp), nil
+	case "wind":
+		speed, gust := windiest.WindSpeed, windiest.WindGust
+		verdict := "Fairly calm"
+		switch {
+		case max(speed, gust) >= 17.2:
+			verdict = "Yes, very windy"
+		case max(speed, gust) >= 10.8:
+			verdict = "Yes, windy"
+		case max(speed, gust) >= 5.5:
+			verdict = "Breezy"
+		}
+		if gust > speed {
+			return lsprintf("%s in %s: wind up to %.0f %s, gusting to %.0f %s.", verdict, where, showSpeed(speed), displayUnits.speed.symbol, showSpeed(gust), displayUnits.speed.symbol), nil
+		}
+		return lsprintf("%s in %s: wind up to %.0f %s.", verdict, where, showSpeed(speed), displayUnits.speed.symbol), nil
+	case "sun":
+		sky := "Mostly cloudy"
+		switch {
+		case clouds < 30:
+			sky = "Mostly sunny"
+		case clouds < 70:
+			sky = "Partly cloudy"
+		}
+		return lsprintf("%s in %s, with %.0f%% cloud cover on average.", sky, where, clouds), nil
+	case "humidity":
+		return lsprintf("Humidity between %.0f%% and %.0f%% in %s.", humidLow, humidHigh, where), nil
+	}
+
+	condition := window[0].Description
+	for _, c := range sortedKeys(conditions) {
+		if conditions[c] > conditions[condition] {
+			condition = c
+		}
+	}
+	return lsprintf("In %s: %.0f to %.0f%s, mostly %s, with up to a %.0f%% chance of rain.", where, showTemp(low), showTemp(high), temp, condition, wettest.Pop*100), nil
+}
+
+// runAsk implements `weather ask`, answering a plain-English question
+// about the forecast.
+func runAsk(args []string) error {
+	fs := flag.NewFlagSet("ask", flag.ExitOnError)
+	locFlags := addLocationFlags(fs)
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		return fmt.Errorf("usage: weather ask \"will it rain in Nairobi tomorrow afternoon?\"")
+	}
+	q, err := parseQuestion(strings.Join(fs.Args(), " "), time.Now())
+	if err != nil {
+		return err
+	}
+
+	loc, hasFlagLoc, err := locFlags.location()
+	switch {
+	case err != nil:
+	case hasFlagLoc:
+	case q.place != "":
+		loc, err = locFlags.resolveCity(q.place)
+	case config.DefaultCity != "":
+		loc, err = locFlags.resolveCity(config.DefaultCity)
+	default:
+		return fmt.Errorf("which place? Ask e.g. \"will it rain in Nairobi tomorrow?\", or set default_city in the config file")
+	}
+	if err != nil {
+		return err
+	}
+	usePreferences(loc)
+
+	forecast, err := GetForecastAt(loc, apiKeyFromEnv())
+	if err != nil {
+		return fmt.Errorf("fetching forecast for %s: %w", loc, err)
+	}
+	reply, err := q.answer(forecast.City.Name, forecastPointsFrom(forecast))
+	if err != nil {
+		return err
+	}
+	fmt.Println(reply)
+	return nil
+}
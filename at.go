@@ -0,0 +1,175 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// parseWhen parses a time for `weather at`: "now", "+90m", "in 3h",
+// "17:45", "tomorrow 17:45", "friday 09:00" or "2024-06-01 17:45". A bare
+// time of day that has already passed today means tomorrow.
+func parseWhen(s string, now time.Time) (time.Time, error) {
+	fields := strings.Fields(strings.ToLower(s))
+	if len(fields) == 0 {
+		return time.Time{}, fmt.Errorf("no time given")
+	}
+	if len(fields) == 1 && fields[0] == "now" {
+		return now, nil
+	}
+	if d, ok := strings.CutPrefix(strings.Join(fields, " "), "in "); ok || strings.HasPrefix(fields[0], "+") {
+		if !ok {
+			d = strings.TrimPrefix(fields[0], "+")
+		}
+		duration, err := time.ParseDuration(strings.ReplaceAll(d, " ", ""))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid time %q, use e.g. +3h or \"in 90m\"", s)
+		}
+		return now.Add(duration), nil
+	}
+
+	clock := fields[len(fields)-1]
+	at, err := time.ParseInLocation("15:04", clock, now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, use e.g. \"tomorrow 17:45\"", s)
+	}
+	day := time.Date(now.Year(), now.Month(), now.Day(), at.Hour(), at.Minute(), 0, 0, now.Location())
+	switch {
+	case len(fields) == 1:
+		if day.Before(now) {
+			day = day.AddDate(0, 0, 1)
+		}
+	case len(fields) > 2:
+		return time.Time{}, fmt.Errorf("invalid time %q, use e.g. \"tomorrow 17:45\"", s)
+	case fields[0] == "today":
+	case fields[0] == "tomorrow":
+		day = day.AddDate(0, 0, 1)
+	default:
+		if date, err := time.ParseInLocation(time.DateOnly, fields[0], now.Location()); err == nil {
+			day = time.Date(date.Year(), date.Month(), date.Day(), at.Hour(), at.Minute(), 0, 0, now.Location())
+			break
+		}
+		weekday := -1
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if name := strings.ToLower(d.String()); fields[0] == name || fields[0] == name[:3] {
+				weekday = int(d)
+			}
+		}
+		if weekday < 0 {
+			return time.Time{}, fmt.Errorf("invalid day %q, use today, tomorrow, a weekday or YYYY-MM-DD", fields[0])
+		}
+		day = day.AddDate(0, 0, (weekday-int(now.Weekday())+7)%7)
+		if day.Before(now) {
+			day = day.AddDate(0, 0, 7)
+		}
+	}
+	return day, nil
+}
+
+// interpolate estimates conditions at t between a and b, linearly for the
+// numbers and from the nearer point for the condition.
+func interpolate(a, b ForecastPoint, t time.Time) ForecastPoint {
+	f := 0.0
+	if span := b.At.Sub(a.At); span > 0 {
+		f = float64(t.Sub(a.At)) / float64(span)
+	}
+	lerp := func(x, y float64) float64 { return x + (y-x)*f }
+	p := ForecastPoint{
+		At: t, Temp: lerp(a.Temp, b.Temp), FeelsLike: lerp(a.FeelsLike, b.FeelsLike), Humidity: lerp(a.Humidity, b.Humidity),
+		WindSpeed: lerp(a.WindSpeed, b.WindSpeed), WindGust: lerp(a.WindGust, b.WindGust), Clouds: lerp(a.Clouds, b.Clouds),
+		Pop: lerp(a.Pop, b.Pop), Condition: a.Condition, Description: a.Description,
+	}
+	if f >= 0.5 {
+		p.Condition, p.Description = b.Condition, b.Description
+	}
+	return p
+}
+
+// runAt implements `weather at`, estimating the conditions at a given time
+// from the forecast entries around it.
+func runAt(args []string) error {
+	fs := flag.NewFlagSet("at", flag.ExitOnError)
+	locFlags := addLocationFlags(fs)
+	fs.Parse(args)
+
+	usage := fmt.Errorf("usage: weather at [flags] <time> <city|@favorite>, e.g. weather at \"tomorrow 17:45\" Nairobi")
+	if fs.NArg() == 0 {
+		return usage
+	}
+	now := time.Now()
+	at, err := parseWhen(fs.Arg(0), now)
+	if err != nil {
+		return err
+	}
+	loc, hasFlagLoc, err := locFlags.location()
+	switch {
+	case err != nil:
+	case hasFlagLoc:
+	case fs.NArg() > 1:
+		loc, err = locFlags.resolveCity(strings.Join(fs.Args()[1:], " "))
+	default:
+		return usage
+	}
+	if err != nil {
+		return err
+	}
+	usePreferences(loc)
+	if at.Before(now.Add(-time.Minute)) {
+		return fmt.Errorf("%s is in the past", at.Format("Mon Jan 2 15:04"))
+	}
+
+	apiKey := apiKeyFromEnv()
+	current, err := GetCurrentWeatherAt(loc, apiKey)
+	if err != nil {
+		return fmt.Errorf("fetching current weather for %s: %w", loc, err)
+	}
+	forecast, err := GetForecastAt(loc, apiKey)
+	if err != nil {
+		return fmt.Errorf("fetching forecast for %s: %w", loc, err)
+	}
+
+	// The current weather anchors times before the first forecast entry.
+	now = time.Unix(current.Dt, 0)
+	points := []ForecastPoint{{
+		At: now, Temp: current.Main.Temp, FeelsLike: current.Main.FeelsLike, Humidity: float64(current.Main.Humidity),
+		WindSpeed: current.Wind.Speed, WindGust: current.Wind.Gust, Clouds: float64(current.Clouds.All),
+	}}
+	if len(current.Weather) > 0 {
+		points[0].Condition, points[0].Description = current.Weather[0].Main, current.Weather[0].Description
+	}
+	for _, p := range forecastPointsFrom(forecast) {
+		if p.At.After(now) {
+			points = append(points, p)
+		}
+	}
+	last := points[len(points)-1]
+	if at.After(last.At) {
+		return fmt.Errorf("the forecast only reaches %s", last.At.Local().Format("Mon Jan 2 15:04"))
+	}
+	from, to := points[0], points[0]
+	for i := 1; i < len(points) && at.After(from.At); i++ {
+		from, to = points[i-1], points[i]
+		if !at.After(to.At) {
+			break
+		}
+	}
+	p := interpolate(from, to, at)
+
+	fmt.Printf("Estimated conditions in %s at %s:\n", current.Name, at.Local().Format("Mon Jan 2 15:04"))
+	temp, speed := displayUnits.temp.symbol, displayUnits.speed.symbol
+	lprintf("  Temperature: %.*f%s (Feels like: %.*f%s)\n", decimals("temp", 1), showTemp(p.Temp), temp, decimals("temp", 1), showTemp(p.FeelsLike), temp)
+	fmt.Printf("  Conditions: %s\n", p.Description)
+	fmt.Printf("  Chance of precipitation: %.0f%%\n", p.Pop*100)
+	lprintf("  Wind: %.*f %s", decimals("wind", 1), showSpeed(p.WindSpeed), speed)
+	if p.WindGust > 0 {
+		lprintf(" (gusts %.*f %s)", decimals("wind", 1), showSpeed(p.WindGust), speed)
+	}
+	fmt.Println()
+	fmt.Printf("  Humidity: %.0f%%\n", p.Humidity)
+	fmt.Printf("  Cloudiness: %.0f%%\n", p.Clouds)
+	if from.At != to.At {
+		fmt.Printf("Interpolated between %s and %s.\n", from.At.Local().Format("15:04"), to.At.Local().Format("Mon 15:04"))
+	}
+	return nil
+}
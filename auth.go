@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// ServerToken is one API key for the server.
+type ServerToken struct {
+	Name  string `json:"name"`
+	Token string `json:"token"`
+	// RateLimit is the number of requests per minute allowed with this
+	// token; zero falls back to the server's rate_limit.
+	RateLimit int `json:"rate_limit,omitempty"`
+	// Admin grants access to the /admin endpoints.
+	Admin bool `json:"admin,omitempty"`
+}
+
+// apiClient is an authenticated caller.
+type apiClient struct {
+	name  string
+	token string
+	admin bool
+}
+
+// clientKey is the request context key holding the authenticated
+// *apiClient.
+type clientKey struct{}
+
+// requireToken rejects requests without a configured API key, passed as
+// "Authorization: Bearer KEY" or "X-API-Key: KEY", and records the caller
+// in the request context. Paths in public are always allowed.
+func requireToken(tokens []ServerToken, public []string, next http.Handler) http.Handler {
+	if len(tokens) == 0 {
+		return next
+	}
+	clients := make([]*apiClient, len(tokens))
+	for i, t := range tokens {
+		clients[i] = &apiClient{name: t.Name, token: t.Token, admin: t.Admin}
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if slices.Contains(public, r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		client := findClient(clients, requestToken(r))
+		if client == nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="weather-tool"`)
+			writeJSONError(w, http.StatusUnauthorized, fmt.Errorf("missing or invalid API key"))
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientKey{}, client)))
+	})
+}
+
+// requireAdmin only lets through callers whose API key has "admin" set.
+// Without any API keys configured the admin endpoints are disabled.
+func requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		client, _ := r.Context().Value(clientKey{}).(*apiClient)
+		if client == nil || !client.admin {
+			writeJSONError(w, http.StatusForbidden, fmt.Errorf("admin endpoints need an API key with \"admin\": true"))
+			return
+		}
+		next(w, r)
+	}
+}
+
+func requestToken(r *http.Request) string {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return r.Header.Get("X-API-Key")
+}
+
+// findClient returns the client with token, comparing in constant time.
+func findClient(clients []*apiClient, token string) *apiClient {
+	if token == "" {
+		return nil
+	}
+	var found *apiClient
+	for _, c := range clients {
+		if subtle.ConstantTimeCompare([]byte(c.token), []byte(token)) == 1 {
+			found = c
+		}
+	}
+	return found
+}
+
+// allowCORS adds CORS headers for the configured origins and answers
+// preflight requests.
+func allowCORS(origins []string, next http.Handler) http.Handler {
+	if len(origins) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin != "" && (slices.Contains(origins, "*") || slices.Contains(origins, origin)) {
+			h := w.Header()
+			h.Set("Access-Control-Allow-Origin", origin)
+			h.Add("Vary", "Origin")
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				h.Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+				h.Set("Access-Control-Allow-Headers", "Authorization, X-API-Key, "+tenantKeyHeader)
+				h.Set("Access-Control-Max-Age", "600")
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const timeMachineURL = "https://api.openweathermap.org/data/3.0/onecall/timemachine"
+
+// TimeMachineResponse is the One Call API 3.0 historical weather response
+// for one point in time.
+type TimeMachineResponse struct {
+	Lat      float64 `json:"lat"`
+	Lon      float64 `json:"lon"`
+	Timezone string  `json:"timezone"`
+	Data     []struct {
+		Dt        int64     `json:"dt"`
+		Temp      float64   `json:"temp"`
+		FeelsLike float64   `json:"feels_like"`
+		Pressure  int       `json:"pressure"`
+		Humidity  int       `json:"humidity"`
+		Clouds    int       `json:"clouds"`
+		WindSpeed float64   `json:"wind_speed"`
+		Weather   []Weather `json:"weather"`
+		Rain      struct {
+			OneHour float64 `json:"1h"`
+		} `json:"rain"`
+	} `json:"data"`
+}
+
+// GetTimeMachine fetches the historical weather at coordinates closest to
+// at. It needs a One Call API 3.0 subscription.
+func GetTimeMachine(lat, lon float64, at time.Time, apiKey string) (*TimeMachineResponse, error) {
+	params := url.Values{
+		"lat":   {strconv.FormatFloat(lat, 'f', -1, 64)},
+		"lon":   {strconv.FormatFloat(lon, 'f', -1, 64)},
+		"dt":    {strconv.FormatInt(at.Unix(), 10)},
+		"units": {"metric"},
+		"appid": {apiKey},
+	}
+	var data TimeMachineResponse
+	if err := fetchWeatherData(timeMachineURL+"?"+params.Encode(), &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// runHistory implements `weather history backfill`.
+func runHistory(args []string) error {
+	if len(args) == 0 || args[0] != "backfill" {
+		return fmt.Errorf("usage: weather history backfill --from DATE [--to DATE] [--step DURATION] [--rate N] <city|@favorite>")
+	}
+	return runBackfill(args[1:])
+}
+
+// runBackfill fills the observation store with historical weather from the
+// One Call timemachine endpoint. Times that already have an observation
+// are skipped, so an interrupted backfill resumes where it stopped.
+func runBackfill(args []string) error {
+	fs := flag.NewFlagSet("history backfill", flag.ExitOnError)
+	locFlags := addLocationFlags(fs)
+	from := fs.String("from", "", "First day to fetch, YYYY-MM-DD")
+	to := fs.String("to", "", "Last day to fetch, YYYY-MM-DD (default today)")
+	step := fs.Duration("step", 3*time.Hour, "Time between fetched readings; each reading is one API call")
+	perMinute := fs.Int("rate", 30, "Maximum API calls per minute")
+	fs.Parse(args)
+
+	loc, hasFlagLoc, err := locFlags.location()
+	switch {
+	case err != nil:
+	case hasFlagLoc:
+	case fs.NArg() > 0:
+		loc, err = locFlags.resolveCity(strings.Join(fs.Args(), " "))
+	default:
+		return fmt.Errorf("usage: weather history backfill --from DATE [--to DATE] [--step DURATION] [--rate N] <city|@favorite>")
+	}
+	if err != nil {
+		return err
+	}
+	start, err := time.ParseInLocation(time.DateOnly, *from, time.Local)
+	if err != nil {
+		return fmt.Errorf("invalid --from date %q, use YYYY-MM-DD", *from)
+	}
+	end := time.Now()
+	if *to != "" {
+		day, err := time.ParseInLocation(time.DateOnly, *to, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid --to date %q, use YYYY-MM-DD", *to)
+		}
+		if next := day.AddDate(0, 0, 1); next.Before(end) {
+			end = next
+		}
+	}
+	if !start.Before(end) {
+		return fmt.Errorf("--from must be before --to")
+	}
+	if *step < time.Hour {
+		return fmt.Errorf("--step must be at least 1h, the resolution of historical data")
+	}
+	if *perMinute < 1 {
+		return fmt.Errorf("--rate must be at least 1")
+	}
+
+	apiKey := apiKeyFromEnv()
+	lat, lon, err := loc.coordinates(apiKey)
+	if err != nil {
+		return err
+	}
+	name := loc.String()
+
+	observations, err := readObservations()
+	if err != nil {
+		return err
+	}
+	var have []time.Time
+	for _, o := range observations {
+		if o.Location == name {
+			have = append(have, o.At)
+		}
+	}
+	var pending []time.Time
+	for t := start; t.Before(end); t = t.Add(*step) {
+		if !observedNear(have, t, *step/2) {
+			pending = append(pending, t)
+		}
+	}
+	if len(pending) == 0 {
+		fmt.Printf("%s already has readings from %s to %s.\n", name, start.Format(time.DateOnly), end.Add(-time.Second).Format(time.DateOnly))
+		return nil
+	}
+	fmt.Printf("Fetching %d readings for %s at up to %d calls a minute (about %s)...\n",
+		len(pending), name, *perMinute, (time.Duration(len(pending)) * time.Minute / time.Duration(*perMinute)).Round(time.Minute))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	limiter := rate.NewLimiter(rate.Limit(float64(*perMinute)/60), 1)
+	saved := 0
+	for i, t := range pending {
+		data, err := fetchTimeMachinePaced(ctx, limiter, lat, lon, t, apiKey)
+		if errors.Is(err, context.Canceled) {
+			fmt.Printf("\nInterrupted after %d readings; run the same command again to resume.\n", saved)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("fetching %s for %s (saved %d readings, run again to resume): %w", t.Format(time.DateTime), name, saved, err)
+		}
+		var batch []Observation
+		for _, d := range data.Data {
+			obs := Observation{
+				Location:  name,
+				At:        time.Unix(d.Dt, 0).UTC(),
+				Temp:      d.Temp,
+				FeelsLike: d.FeelsLike,
+				Humidity:  d.Humidity,
+				Pressure:  d.Pressure,
+				WindSpeed: d.WindSpeed,
+				Clouds:    d.Clouds,
+				Rain:      d.Rain.OneHour,
+			}
+			if len(d.Weather) > 0 {
+				obs.Condition = d.Weather[0].Main
+			}
+			batch = append(batch, obs)
+		}
+		path, err := observationsPath()
+		if err == nil {
+			err = appendJSONLines(path, batch)
+		}
+		if err != nil {
+			return err
+		}
+		saved += len(batch)
+		fmt.Printf("\r%d/%d %s", i+1, len(pending), t.Format(time.DateOnly))
+	}
+	fmt.Printf("\nSaved %d readings for %s.\n", saved, name)
+	return nil
+}
+
+// fetchTimeMachinePaced waits for the limiter before each call, and backs
+// off and retries when the API reports its own rate limit.
+func fetchTimeMachinePaced(ctx context.Context, limiter *rate.Limiter, lat, lon float64, at time.Time, apiKey string) (*TimeMachineResponse, error) {
+	backoff := time.Minute
+	for attempt := 1; ; attempt++ {
+		if err := limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		data, err := GetTimeMachine(lat, lon, at, apiKey)
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests || attempt == 5 {
+			return data, err
+		}
+		fmt.Printf("\nRate limited by the API, waiting %s...\n", backoff)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// observedNear reports whether any of times lies within window of t.
+func observedNear(times []time.Time, t time.Time, window time.Duration) bool {
+	for _, at := range times {
+		if d := at.Sub(t); d > -window && d <= window {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// ProviderBenchmark is how one provider fared in `weather bench-providers`.
+type ProviderBenchmark struct {
+	Provider  string  `json:"provider"`
+	Runs      int     `json:"runs"`
+	Failures  int     `json:"failures"`
+	MedianMS  float64 `json:"median_ms"` // of the successful runs
+	MaxMS     float64 `json:"max_ms"`
+	LastError string  `json:"last_error,omitempty"`
+}
+
+// errorRate is the share of runs that failed.
+func (b ProviderBenchmark) errorRate() float64 {
+	if b.Runs == 0 {
+		return 1
+	}
+	return float64(b.Failures) / float64(b.Runs)
+}
+
+// providerBenchmarks is the stored result of the last benchmark.
+type providerBenchmarks struct {
+	MeasuredAt time.Time           `json:"measured_at"`
+	Results    []ProviderBenchmark `json:"results"`
+}
+
+func benchmarksPath() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "provider-bench.json"), nil
+}
+
+func loadBenchmarks() (providerBenchmarks, error) {
+	var b providerBenchmarks
+	path, err := benchmarksPath()
+	if err != nil {
+		return b, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return b, nil
+	}
+	if err != nil {
+		return b, fmt.Errorf("failed to read provider benchmarks: %w", err)
+	}
+	if err := json.Unmarshal(data, &b); err != nil {
+		return b, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return b, nil
+}
+
+func saveBenchmarks(b providerBenchmarks) error {
+	path, err := benchmarksPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode provider benchmarks: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write provider benchmarks: %w", err)
+	}
+	return nil
+}
+
+// rankBenchmarks sorts results best first: fewest failures, then lowest
+// median latency.
+func rankBenchmarks(results []ProviderBenchmark) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if ri, rj := results[i].errorRate(), results[j].errorRate(); ri != rj {
+			return ri < rj
+		}
+		return results[i].MedianMS < results[j].MedianMS
+	})
+}
+
+// orderByBenchmark reorders chain by results, best first. Providers that
+// weren't measured keep their order after the measured ones.
+func orderByBenchmark(chain []string, results []ProviderBenchmark) []string {
+	rank := make(map[string]int, len(results))
+	for i, r := range results {
+		rank[r.Provider] = i
+	}
+	ordered := slices.Clone(chain)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		ri, iok := rank[ordered[i]]
+		rj, jok := rank[ordered[j]]
+		if iok != jok {
+			return iok
+		}
+		return iok && ri < rj
+	})
+	return ordered
+}
+
+// benchOrder caches the auto-ordered fallback chain for the run.
+var benchOrder = sync.OnceValue(func() []string {
+	b, err := loadBenchmarks()
+	if err != nil {
+		return config.Providers
+	}
+	return orderByBenchmark(config.Providers, b.Results)
+})
+
+// providerChain returns the providers to try, in order, for each fetch:
+// the one chosen with --provider or WEATHER_TOOL_PROVIDER, else the
+// config file's "providers" fallback chain (ordered by the last benchmark
+// when "auto_order_providers" is set), else the single selected provider.
+func providerChain() []string {
+	switch {
+	case provider != "":
+		return []string{provider}
+	case len(config.Providers) > 0 && config.AutoOrderProviders:
+		return benchOrder()
+	case len(config.Providers) > 0:
+		return config.Providers
+	}
+	return []string{selectedProvider()}
+}
+
+// runBenchProviders implements `weather bench-providers`, timing a few
+// fetches from each provider and storing the results.
+func runBenchProviders(args []string) error {
+	fs := flag.NewFlagSet("bench-providers", flag.ExitOnError)
+	locFlags := addLocationFlags(fs)
+	rounds := fs.Int("rounds", 5, "Fetches per provider")
+	kind := fs.String("kind", "current", "Data to fetch: current or forecast")
+	fs.Parse(args)
+
+	loc, hasFlagLoc, err := locFlags.location()
+	switch {
+	case err != nil:
+	case hasFlagLoc:
+	case fs.NArg() > 0:
+		loc, err = locFlags.resolveCity(strings.Join(fs.Args(), " "))
+	case config.DefaultCity != "":
+		loc, err = locFlags.resolveCity(config.DefaultCity)
+	default:
+		return fmt.Errorf("usage: weather bench-providers [--rounds N] [--kind current|forecast] <city|@favorite>")
+	}
+	if err != nil {
+		return err
+	}
+	if *rounds < 1 {
+		return fmt.Errorf("--rounds must be at least 1")
+	}
+	if *kind != "current" && *kind != "forecast" {
+		return fmt.Errorf("invalid --kind %q, use current or forecast", *kind)
+	}
+
+	names := config.Providers
+	if len(names) == 0 {
+		plugins, err := providerPlugins()
+		if err != nil {
+			return err
+		}
+		names = append([]string{defaultProvider}, sortedKeys(plugins)...)
+	}
+	apiKey := os.Getenv("OPENWEATHER_API_KEY")
+	if apiKey == "" {
+		apiKey = config.APIKey
+	}
+
+	results := make([]ProviderBenchmark, 0, len(names))
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "Measuring %s...\n", name)
+		result := ProviderBenchmark{Provider: name, Runs: *rounds}
+		var latencies []float64
+		for range *rounds {
+			var target json.RawMessage
+			start := time.Now()
+			err := fetchFrom(name, *kind, loc, apiKey, &target)
+			elapsed := float64(time.Since(start).Microseconds()) / 1000
+			if err != nil {
+				result.Failures++
+				result.LastError = upstreamErrorSummary(err)
+				continue
+			}
+			latencies = append(latencies, elapsed)
+		}
+		if len(latencies) > 0 {
+			slices.Sort(latencies)
+			result.MedianMS = percentile(latencies, 50)
+			result.MaxMS = latencies[len(latencies)-1]
+		}
+		results = append(results, result)
+	}
+	rankBenchmarks(results)
+	if err := saveBenchmarks(providerBenchmarks{MeasuredAt: time.Now().UTC(), Results: results}); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROVIDER\tERRORS\tMEDIAN\tMAX\tLAST ERROR")
+	for _, r := range results {
+		median, slowest := "-", "-"
+		if r.Failures < r.Runs {
+			median, slowest = fmt.Sprintf("%.0f ms", r.MedianMS), fmt.Sprintf("%.0f ms", r.MaxMS)
+		}
+		fmt.Fprintf(tw, "%s\t%d/%d\t%s\t%s\t%s\n", r.Provider, r.Failures, r.Runs, median, slowest, r.LastError)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	switch {
+	case len(config.Providers) == 0:
+		fmt.Println("\nTo fall back between providers, list them as \"providers\" in the config file.")
+	case config.AutoOrderProviders:
+		fmt.Printf("\nFallback chain is now: %s\n", strings.Join(orderByBenchmark(config.Providers, results), ", "))
+	default:
+		fmt.Printf("\nSet \"auto_order_providers\": true in the config file to try providers in this order: %s\n",
+			strings.Join(orderByBenchmark(config.Providers, results), ", "))
+	}
+	return nil
+}
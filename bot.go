@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// botWeatherReply answers a chat command with a one-line weather summary.
+func botWeatherReply(city, apiKey string) string {
+	data, err := GetCurrentWeather(city, apiKey)
+	if err != nil {
+		return fmt.Sprintf("Sorry, I couldn't get the weather for %s.", city)
+	}
+	msg := currentWeatherMessage(data)
+	return msg.Title + ": " + msg.Body()
+}
+
+// botForecastReply answers a chat command with the next few forecast
+// entries on a single line.
+func botForecastReply(city, apiKey string, entries int) string {
+	data, err := GetForecast(city, apiKey)
+	if err != nil {
+		return fmt.Sprintf("Sorry, I couldn't get the forecast for %s.", city)
+	}
+	return forecastLine(data, entries)
+}
+
+// forecastLine summarizes the first few forecast entries on a single line.
+func forecastLine(data *ForecastResponse, entries int) string {
+	var parts []string
+	for i, entry := range data.List {
+		if i == entries {
+			break
+		}
+		condition := "N/A"
+		if len(entry.Weather) > 0 {
+			condition = entry.Weather[0].Description
+		}
+		parts = append(parts, lsprintf("%s %.*f%s %s (%.0f%% rain)",
+			formatDate(time.Unix(entry.Dt, 0).Local(), "Mon 15:04"), decimals("temp", 0), showTemp(entry.Main.Temp), displayUnits.temp.symbol, condition, entry.Pop*100))
+	}
+	return lsprintf("%s, %s forecast: %s", data.City.Name, data.City.Country, strings.Join(parts, " | "))
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// briefing is what goes into a morning briefing for one location. Alerts
+// and yesterday are optional: warnings need a One Call subscription and
+// yesterday's weather a day summary or recorded observations.
+type briefing struct {
+	current   *CurrentWeatherResponse
+	forecast  []ForecastPoint
+	alerts    []Alert
+	yesterday *ruleDay
+}
+
+// fetchBriefing gathers a briefing for loc. Only a missing current weather
+// or forecast is an error.
+func fetchBriefing(loc Location, apiKey string, now time.Time) (*briefing, error) {
+	report := fetchFullReport(loc, apiKey)
+	if report.currentErr != nil {
+		return nil, fmt.Errorf("fetching current weather for %s: %w", loc, report.currentErr)
+	}
+	if report.forecastErr != nil {
+		return nil, fmt.Errorf("fetching forecast for %s: %w", loc, report.forecastErr)
+	}
+	b := &briefing{current: report.current, forecast: forecastPointsFrom(report.forecast)}
+	for _, a := range report.alerts {
+		b.alerts = append(b.alerts, alertFrom(a))
+	}
+	coord := report.current.Coord
+	if day, err := summarizeDayAt(loc.String(), coord.Lat, coord.Lon, now.AddDate(0, 0, -1), apiKey); err == nil {
+		b.yesterday = &day
+	}
+	return b, nil
+}
+
+// text renders the briefing as a plain paragraph suitable for email, chat
+// or text-to-speech, e.g. "Good morning. Today in Nairobi: 14 to 26
+// degrees, partly cloudy, rain likely after 3 pm. ..."
+func (b *briefing) text(now time.Time) string {
+	var s strings.Builder
+	s.WriteString(greeting(now))
+
+	// Today is the rest of the local day, or the next 12 hours late in
+	// the evening, when what's left of it hardly matters.
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, 1)
+	if end.Sub(now) < 6*time.Hour {
+		end = now.Add(12 * time.Hour)
+	}
+	low, high := b.current.Main.Temp, b.current.Main.Temp
+	var wind float64
+	var rainAt time.Time
+	for _, p := range b.forecast {
+		if !p.At.Before(end) {
+			break
+		}
+		low, high, wind = min(low, p.Temp), max(high, p.Temp), max(wind, p.WindSpeed, p.WindGust)
+		if rainAt.IsZero() && p.Pop >= rainPopThreshold && p.At.After(now.Add(-3*time.Hour)) {
+			rainAt = p.At.Local()
+		}
+	}
+	condition := "clear"
+	if len(b.current.Weather) > 0 {
+		condition = b.current.Weather[0].Description
+	}
+	s.WriteString(lsprintf(" Today in %s: %.0f to %.0f %s, currently %.0f and %s.", b.current.Name, showTemp(low), showTemp(high), displayUnits.tempWord, showTemp(b.current.Main.Temp), condition))
+
+	switch {
+	case rainAt.IsZero():
+		s.WriteString(" No rain expected.")
+	case !rainAt.After(now):
+		s.WriteString(" Rain likely now.")
+	default:
+		s.WriteString(" Rain likely " + spokenTime(rainAt, now) + ".")
+	}
+
+	wind = max(wind, b.current.Wind.Speed, b.current.Wind.Gust)
+	switch {
+	case wind >= 17.2:
+		s.WriteString(lsprintf(" Gale-force wind, gusting to %.0f %s.", showSpeed(wind), displayUnits.speed.symbol))
+	case wind >= 10.8:
+		s.WriteString(lsprintf(" Strong wind, up to %.0f %s.", showSpeed(wind), displayUnits.speed.symbol))
+	case wind >= 5.5:
+		s.WriteString(" A breezy day.")
+	}
+
+	sunrise, sunset := time.Unix(b.current.Sys.Sunrise, 0).Local(), time.Unix(b.current.Sys.Sunset, 0).Local()
+	if b.current.Sys.Sunrise != 0 && b.current.Sys.Sunset != 0 {
+		fmt.Fprintf(&s, " Sunrise %s, sunset %s.", spokenClock(sunrise), spokenClock(sunset))
+	}
+
+	if d := b.yesterday; d != nil {
+		switch diff := high - d.TempMax; {
+		case diff >= 2:
+			s.WriteString(lsprintf(" About %.0f %s warmer than yesterday.", showTempChange(diff), displayUnits.tempWord))
+		case diff <= -2:
+			s.WriteString(lsprintf(" About %.0f %s cooler than yesterday.", showTempChange(-diff), displayUnits.tempWord))
+		default:
+			s.WriteString(" Similar to yesterday.")
+		}
+	}
+
+	switch len(b.alerts) {
+	case 0:
+	case 1:
+		fmt.Fprintf(&s, " Warning in force: %s.", b.alerts[0].Event)
+	default:
+		events := make([]string, len(b.alerts))
+		for i, a := range b.alerts {
+			events[i] = a.Event
+		}
+		fmt.Fprintf(&s, " %d warnings in force: %s.", len(b.alerts), strings.Join(events, "; "))
+	}
+	return s.String()
+}
+
+// greeting opens the briefing according to the time of day.
+func greeting(now time.Time) string {
+	switch h := now.Hour(); {
+	case h < 12:
+		return "Good morning."
+	case h < 18:
+		return "Good afternoon."
+	}
+	return "Good evening."
+}
+
+// spokenClock phrases a clock time the way spokenTime does, e.g. "6:12 am".
+func spokenClock(t time.Time) string {
+	return strings.ToLower(t.Format("3:04 PM"))
+}
+
+// runBriefing implements `weather briefing`, a paragraph on the day ahead
+// that can be printed, read aloud or sent through notification channels.
+func runBriefing(args []string) error {
+	fs := flag.NewFlagSet("briefing", flag.ExitOnError)
+	locFlags := addLocationFlags(fs)
+	say := fs.Bool("speak", false, "Read the briefing aloud through the system's text-to-speech engine")
+	var channels stringList
+	fs.Var(&channels, "channel", "Also send the briefing through this notification channel (repeatable)")
+	fs.Parse(args)
+
+	loc, hasFlagLoc, err := locFlags.location()
+	switch {
+	case err != nil:
+	case hasFlagLoc:
+	case fs.NArg() > 0:
+		loc, err = locFlags.resolveCity(strings.Join(fs.Args(), " "))
+	case config.DefaultCity != "":
+		loc, err = locFlags.resolveCity(config.DefaultCity)
+	default:
+		return fmt.Errorf("usage: weather briefing [--speak] [--channel NAME] <city|@favorite>, or set default_city in the config file")
+	}
+	if err != nil {
+		return err
+	}
+	usePreferences(loc)
+	targets, err := newNotifiers(channels)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	b, err := fetchBriefing(loc, apiKeyFromEnv(), now)
+	if err != nil {
+		return err
+	}
+	text := b.text(now)
+	fmt.Println(text)
+
+	if len(targets) > 0 {
+		msg := Message{Title: "Weather briefing for " + b.current.Name, Parts: []string{text}}
+		if err := sendAll(channels, targets, msg); err != nil {
+			return err
+		}
+	}
+	if *say {
+		return speak(text)
+	}
+	return nil
+}
+
+// botBriefingReply answers a chat command with the day's briefing.
+func botBriefingReply(city, apiKey string) string {
+	loc, err := resolveLocation(city)
+	if err != nil {
+		return fmt.Sprintf("Sorry, I don't know where %s is.", city)
+	}
+	now := time.Now()
+	b, err := fetchBriefing(loc, apiKey, now)
+	if err != nil {
+		return fmt.Sprintf("Sorry, I couldn't get a briefing for %s.", city)
+	}
+	return b.text(now)
+}
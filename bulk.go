@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	bulkCityListURL = "https://bulk.openweathermap.org/sample/city.list.json.gz"
+	bulkSnapshotURL = "https://bulk.openweathermap.org/snapshot/"
+)
+
+// BulkCity is one entry of the bulk city list.
+type BulkCity struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	State   string `json:"state"`
+	Country string `json:"country"`
+	Coord   Coord  `json:"coord"`
+}
+
+// BulkSnapshot is one city's current weather in a bulk snapshot file.
+type BulkSnapshot struct {
+	City    BulkCity  `json:"city"`
+	Time    int64     `json:"time"`
+	Main    Main      `json:"main"`
+	Wind    Wind      `json:"wind"`
+	Clouds  Clouds    `json:"clouds"`
+	Weather []Weather `json:"weather"`
+}
+
+// bulkFilter selects cities by ID, country and name.
+type bulkFilter struct {
+	ids     []int
+	country string
+	name    string
+}
+
+func (f bulkFilter) matches(c BulkCity) bool {
+	return (len(f.ids) == 0 || slices.Contains(f.ids, c.ID)) &&
+		(f.country == "" || strings.EqualFold(c.Country, f.country)) &&
+		(f.name == "" || strings.Contains(strings.ToLower(c.Name), f.name))
+}
+
+// openBulkFile opens a bulk file from a local path or URL, decompressing
+// it on the fly when it is gzipped. The API key is added to bulk server
+// URLs that need one.
+func openBulkFile(source, apiKey string) (io.ReadCloser, error) {
+	var body io.ReadCloser
+	if strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://") {
+		if apiKey != "" && strings.HasPrefix(source, bulkSnapshotURL) {
+			source += "?" + url.Values{"appid": {apiKey}}.Encode()
+		}
+		resp, err := http.Get(source)
+		if err != nil {
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				urlErr.URL, _, _ = strings.Cut(urlErr.URL, "?")
+			}
+			return nil, fmt.Errorf("failed to download bulk file: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+			resp.Body.Close()
+			return nil, &APIError{StatusCode: resp.StatusCode, Body: string(bodyBytes)}
+		}
+		body = resp.Body
+	} else {
+		f, err := os.Open(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open bulk file: %w", err)
+		}
+		body = f
+	}
+
+	buffered := bufio.NewReaderSize(body, 64*1024)
+	magic, _ := buffered.Peek(2)
+	if len(magic) < 2 || magic[0] != 0x1f || magic[1] != 0x8b {
+		return struct {
+			io.Reader
+			io.Closer
+		}{buffered, body}, nil
+	}
+	gz, err := gzip.NewReader(buffered)
+	if err != nil {
+		body.Close()
+		return nil, fmt.Errorf("failed to decompress bulk file: %w", err)
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{gz, body}, nil
+}
+
+// eachBulkCity streams the city list, a JSON array, calling fn for every
+// city without holding the whole list in memory.
+func eachBulkCity(r io.Reader, fn func(BulkCity) error) error {
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return fmt.Errorf("invalid city list: expected a JSON array")
+	}
+	for dec.More() {
+		var c BulkCity
+		if err := dec.Decode(&c); err != nil {
+			return fmt.Errorf("invalid city list: %w", err)
+		}
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eachBulkSnapshot streams a snapshot file, one JSON object per line.
+func eachBulkSnapshot(r io.Reader, fn func(BulkSnapshot) error) error {
+	dec := json.NewDecoder(r)
+	for {
+		var s BulkSnapshot
+		err := dec.Decode(&s)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid snapshot file: %w", err)
+		}
+		if err := fn(s); err != nil {
+			return err
+		}
+	}
+}
+
+// runBulk implements `weather bulk cities|snapshot`.
+func runBulk(args []string) error {
+	usage := fmt.Errorf("usage: weather bulk cities [flags] | snapshot --file NAME|PATH|URL [flags]")
+	if len(args) == 0 || (args[0] != "cities" && args[0] != "snapshot") {
+		return usage
+	}
+	snapshot := args[0] == "snapshot"
+	fs := flag.NewFlagSet("bulk "+args[0], flag.ExitOnError)
+	source := fs.String("file", "", "Local file or URL to read instead of downloading; for snapshot, a bulk file name such as weather_14.json.gz")
+	country := fs.String("country", "", "Only cities in this ISO 3166 country code, e.g. KE")
+	name := fs.String("name", "", "Only cities whose name contains this text")
+	ids := fs.String("ids", "", "Only these comma-separated city IDs")
+	asJSON := fs.Bool("json", false, "Print matching entries as JSON lines instead of a table")
+	fs.Parse(args[1:])
+	if fs.NArg() > 0 {
+		return usage
+	}
+
+	filter := bulkFilter{country: *country, name: strings.ToLower(*name)}
+	if *ids != "" {
+		for _, s := range strings.Split(*ids, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil {
+				return fmt.Errorf("invalid city ID %q", s)
+			}
+			filter.ids = append(filter.ids, id)
+		}
+	}
+	var apiKey string
+	switch {
+	case !snapshot && *source == "":
+		*source = bulkCityListURL
+	case snapshot && *source == "":
+		return fmt.Errorf("please name the snapshot with --file, e.g. weather_14.json.gz from your bulk subscription")
+	case snapshot && !strings.ContainsAny(*source, `/\`):
+		// A bare name that isn't a local file names a file on the bulk server.
+		if _, err := os.Stat(*source); err != nil {
+			*source = bulkSnapshotURL + *source
+			apiKey = apiKeyFromEnv()
+		}
+	}
+
+	r, err := openBulkFile(*source, apiKey)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	enc := json.NewEncoder(out)
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	matched := 0
+	if snapshot {
+		if !*asJSON {
+			fmt.Fprintf(tw, "ID\tCITY\tCOUNTRY\tTEMP %s\tCONDITIONS\tHUMIDITY\tWIND %s\tTIME\n", displayUnits.temp.symbol, displayUnits.speed.symbol)
+		}
+		err = eachBulkSnapshot(r, func(s BulkSnapshot) error {
+			if !filter.matches(s.City) {
+				return nil
+			}
+			matched++
+			if *asJSON {
+				return enc.Encode(s)
+			}
+			condition := "N/A"
+			if len(s.Weather) > 0 {
+				condition = s.Weather[0].Description
+			}
+			_, err := fmt.Fprint(tw, lsprintf("%d\t%s\t%s\t%.*f\t%s\t%d%%\t%.*f\t%s\n", s.City.ID, s.City.Name, s.City.Country,
+				decimals("temp", 1), showTemp(s.Main.Temp), condition, s.Main.Humidity, decimals("wind", 1), showSpeed(s.Wind.Speed),
+				time.Unix(s.Time, 0).Local().Format("2006-01-02 15:04")))
+			return err
+		})
+	} else {
+		if !*asJSON {
+			fmt.Fprintln(tw, "ID\tCITY\tSTATE\tCOUNTRY\tLAT\tLON")
+		}
+		err = eachBulkCity(r, func(c BulkCity) error {
+			if !filter.matches(c) {
+				return nil
+			}
+			matched++
+			if *asJSON {
+				return enc.Encode(c)
+			}
+			_, err := fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%.4f\t%.4f\n", c.ID, c.Name, c.State, c.Country, c.Coord.Lat, c.Coord.Lon)
+			return err
+		})
+	}
+	if err != nil {
+		return err
+	}
+	if !*asJSON {
+		tw.Flush()
+	}
+	out.Flush()
+	fmt.Fprintf(os.Stderr, "%d matching cities\n", matched)
+	return nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
+)
+
+var (
+	tempColor = drawing.ColorFromHex("d9480f")
+	rainColor = drawing.ColorFromHex("1c7ed6")
+	windColor = drawing.ColorFromHex("495057")
+)
+
+// renderForecastChart draws points as a chart: temperature as a line,
+// precipitation as bars on an axis of its own and wind direction as arrows
+// along the bottom. format is chart.PNG or chart.SVG.
+func renderForecastChart(w io.Writer, title string, points []ForecastPoint, format chart.RendererProvider) error {
+	if len(points) < 2 {
+		return fmt.Errorf("not enough forecast entries to chart")
+	}
+	times := make([]time.Time, len(points))
+	temps := make([]float64, len(points))
+	precipitation := make([]float64, len(points))
+	for i, p := range points {
+		times[i], temps[i], precipitation[i] = p.At.Local(), showTemp(p.Temp), p.Precipitation
+	}
+
+	// A tick at each midnight, labelled with the day that starts there.
+	// Explicit ticks also set the axis range, hence the unlabelled ends.
+	first, last := times[0], times[len(times)-1]
+	ticks := []chart.Tick{{Value: chart.TimeToFloat64(first)}}
+	for day := time.Date(first.Year(), first.Month(), first.Day()+1, 0, 0, 0, 0, first.Location()); day.Before(last); day = day.AddDate(0, 0, 1) {
+		ticks = append(ticks, chart.Tick{Value: chart.TimeToFloat64(day), Label: formatDate(day, "Mon Jan 2")})
+	}
+	ticks = append(ticks, chart.Tick{Value: chart.TimeToFloat64(last)})
+
+	// The legend sits centred in the top padding, below the title if any.
+	top := 40
+	if title != "" {
+		top = 90
+	}
+	graph := chart.Chart{
+		Title:      title,
+		TitleStyle: chart.Style{FontSize: 14},
+		Width:      960,
+		Height:     400,
+		Background: chart.Style{
+			Padding: chart.Box{Top: top, Left: 20, Right: 20, Bottom: 20},
+		},
+		XAxis: chart.XAxis{Ticks: ticks},
+		YAxis: chart.YAxis{
+			Name:           displayUnits.temp.symbol,
+			ValueFormatter: func(v any) string { return lsprintf("%.0f", v) },
+		},
+		YAxisSecondary: chart.YAxis{
+			Name:           "mm",
+			ValueFormatter: func(v any) string { return lsprintf("%.1f", v) },
+		},
+		Series: []chart.Series{
+			chart.HistogramSeries{
+				Name:        "Precipitation (mm/3h)",
+				YAxis:       chart.YAxisSecondary,
+				Style:       chart.Style{StrokeColor: rainColor, FillColor: rainColor.WithAlpha(160)},
+				InnerSeries: chart.TimeSeries{XValues: times, YValues: precipitation},
+			},
+			chart.TimeSeries{
+				Name:    "Temperature (" + displayUnits.temp.symbol + ")",
+				Style:   chart.Style{StrokeColor: tempColor, StrokeWidth: 2},
+				XValues: times,
+				YValues: temps,
+			},
+			windArrows{points: points},
+		},
+	}
+	graph.Elements = []chart.Renderable{chart.LegendThin(&graph)}
+	if err := graph.Render(format, w); err != nil {
+		return fmt.Errorf("failed to render chart: %w", err)
+	}
+	return nil
+}
+
+// windArrows is a chart series drawing an arrow per forecast point along
+// the bottom of the canvas, pointing where the wind blows and longer the
+// stronger it is.
+type windArrows struct {
+	points []ForecastPoint
+}
+
+func (windArrows) GetName() string           { return "Wind direction" }
+func (windArrows) GetStyle() chart.Style     { return chart.Style{StrokeColor: windColor} }
+func (windArrows) GetYAxis() chart.YAxisType { return chart.YAxisPrimary }
+func (windArrows) Validate() error           { return nil }
+
+// Render implements chart.Series.
+func (a windArrows) Render(r chart.Renderer, canvas chart.Box, xrange, _ chart.Range, _ chart.Style) {
+	r.SetStrokeColor(windColor)
+	r.SetStrokeWidth(1.5)
+	y := float64(canvas.Bottom - 16)
+	for _, p := range a.points {
+		x := float64(canvas.Left + xrange.Translate(chart.TimeToFloat64(p.At.Local())))
+		// Wind direction is where it comes from; the arrow shows where it
+		// goes. Screen y grows downwards.
+		angle := (p.WindDeg + 180) * math.Pi / 180
+		length := 6 + min(p.WindSpeed, 15)
+		dx, dy := math.Sin(angle), -math.Cos(angle)
+		tipX, tipY := x+dx*length/2, y+dy*length/2
+		r.MoveTo(int(x-dx*length/2), int(y-dy*length/2))
+		r.LineTo(int(tipX), int(tipY))
+		for _, side := range []float64{-0.5, 0.5} {
+			r.MoveTo(int(tipX), int(tipY))
+			r.LineTo(int(tipX-4*math.Sin(angle+side)), int(tipY+4*math.Cos(angle+side)))
+		}
+		r.Stroke()
+	}
+}
+
+// chartFormat picks the renderer for an output file from its extension.
+func chartFormat(path string) (chart.RendererProvider, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png":
+		return chart.PNG, nil
+	case ".svg":
+		return chart.SVG, nil
+	}
+	return nil, fmt.Errorf("unsupported chart format %q, use .png or .svg", filepath.Ext(path))
+}
+
+// runChart implements `weather chart`, writing the forecast for a location
+// as a PNG or SVG chart.
+func runChart(args []string) error {
+	fs := flag.NewFlagSet("chart", flag.ExitOnError)
+	locFlags := addLocationFlags(fs)
+	out := fs.String("out", "forecast.png", "File to write; the extension picks the format, .png or .svg")
+	fs.Parse(args)
+
+	loc, hasFlagLoc, err := locFlags.location()
+	switch {
+	case err != nil:
+	case hasFlagLoc:
+	case fs.NArg() > 0:
+		loc, err = locFlags.resolveCity(strings.Join(fs.Args(), " "))
+	default:
+		return fmt.Errorf("usage: weather chart [--out forecast.png] <city|@favorite>")
+	}
+	if err != nil {
+		return err
+	}
+	usePreferences(loc)
+	format, err := chartFormat(*out)
+	if err != nil {
+		return err
+	}
+
+	forecast, err := GetForecastAt(loc, apiKeyFromEnv())
+	if err != nil {
+		return fmt.Errorf("fetching forecast for %s: %w", loc, err)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", *out, err)
+	}
+	title := fmt.Sprintf("5-Day Forecast for %s, %s", forecast.City.Name, forecast.City.Country)
+	if err := renderForecastChart(f, title, forecastPointsFrom(forecast), format); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", *out, err)
+	}
+	fmt.Printf("Wrote forecast chart for %s to %s\n", forecast.City.Name, *out)
+	return nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Nagios plugin states, whose index is the plugin's exit code.
+const (
+	checkOK = iota
+	checkWarning
+	checkCritical
+	checkUnknown
+)
+
+var checkStateNames = [...]string{"OK", "WARNING", "CRITICAL", "UNKNOWN"}
+
+// checkTextPipes rewrites the pipes in a status text, such as those of a
+// rule's ||, as Nagios and Icinga take everything after the first | for
+// perfdata.
+var checkTextPipes = strings.NewReplacer("||", "or", "|", "/")
+
+// runCheck implements `weather check`, a Nagios/Icinga plugin. It prints
+// one status line with perfdata and exits 0, 1, 2 or 3 for OK, WARNING,
+// CRITICAL or UNKNOWN, so it never returns to main's error handling.
+func runCheck(args []string) error {
+	state, summary, perfdata := checkWeather(args)
+	fmt.Println(checkLine(state, summary, perfdata))
+	os.Exit(state)
+	return nil
+}
+
+// checkLine formats the plugin's status line: the state, the summary and
+// any perfdata after a |.
+func checkLine(state int, summary, perfdata string) string {
+	line := fmt.Sprintf("WEATHER %s - %s", checkStateNames[state], checkTextPipes.Replace(summary))
+	if perfdata != "" {
+		line += " | " + perfdata
+	}
+	return line
+}
+
+// checkWeather evaluates the check's --warn and --crit conditions against
+// the current weather. Any failure, including bad usage, is UNKNOWN.
+func checkWeather(args []string) (state int, summary, perfdata string) {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	locFlags := addLocationFlags(fs)
+	warn := fs.String("warn", "", "Condition for WARNING, e.g. 'temp > 35' (see Alert Conditions in the README)")
+	crit := fs.String("crit", "", "Condition for CRITICAL, e.g. 'temp > 40 || alert.severity == \"extreme\"'")
+	if err := fs.Parse(args); err != nil {
+		return checkUnknown, err.Error(), ""
+	}
+
+	loc, hasFlagLoc, err := locFlags.location()
+	switch {
+	case err != nil:
+	case hasFlagLoc:
+	case fs.NArg() > 0:
+		loc, err = locFlags.resolveCity(strings.Join(fs.Args(), " "))
+	default:
+		err = errors.New("usage: weather check [--warn CONDITION] [--crit CONDITION] <city|@favorite>")
+	}
+	if err != nil {
+		return checkUnknown, err.Error(), ""
+	}
+	if *warn == "" && *crit == "" {
+		return checkUnknown, "give a --warn or --crit condition", ""
+	}
+	var rules [checkUnknown]*Rule
+	for state, condition := range map[int]string{checkWarning: *warn, checkCritical: *crit} {
+		if condition == "" {
+			continue
+		}
+		if rules[state], err = compileRule(condition); err != nil {
+			return checkUnknown, err.Error(), ""
+		}
+	}
+
+	// Checked here rather than by apiKeyFromEnv, which exits with 1.
+	apiKey := os.Getenv("OPENWEATHER_API_KEY")
+	if apiKey == "" {
+		apiKey = config.APIKey
+	}
+	if apiKey == "" && selectedProvider() == defaultProvider {
+		return checkUnknown, "OpenWeatherMap API key not found; set OPENWEATHER_API_KEY", ""
+	}
+	data, err := GetCurrentWeatherAt(loc, apiKey)
+	if err != nil {
+		return checkUnknown, fmt.Sprintf("fetching current weather for %s: %v", loc, err), ""
+	}
+
+	msg := currentWeatherMessage(data)
+	summary = fmt.Sprintf("%s: %s", msg.Title, strings.Join(msg.Parts[:2], ", "))
+	perfdata = checkPerfdata(data)
+
+	for _, state := range []int{checkCritical, checkWarning} {
+		if rules[state] == nil {
+			continue
+		}
+		matched, err := rules[state].Eval(loc, data, apiKey)
+		if err != nil {
+			return checkUnknown, err.Error(), perfdata
+		}
+		if matched {
+			return state, summary + " (" + rules[state].Source + ")", perfdata
+		}
+	}
+	return checkOK, summary, perfdata
+}
+
+// checkPerfdata returns the perfdata for the current weather, in °C, m/s
+// and hPa whatever the --units, so graphs keep one scale.
+func checkPerfdata(data *CurrentWeatherResponse) string {
+	return fmt.Sprintf("temp=%.1f feels_like=%.1f humidity=%d%%;;;0;100 pressure=%d wind_speed=%.1f;;;0 clouds=%d%%;;;0;100",
+		data.Main.Temp, data.Main.FeelsLike, data.Main.Humidity, data.Main.Pressure, data.Wind.Speed, data.Clouds.All)
+}
@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestCheckLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		state    int
+		summary  string
+		perfdata string
+		want     string
+	}{
+		{"ok", checkOK, "Nairobi: 24°C, Clouds", "temp=24.0", "WEATHER OK - Nairobi: 24°C, Clouds | temp=24.0"},
+		{"no perfdata", checkUnknown, "give a --warn or --crit condition", "", "WEATHER UNKNOWN - give a --warn or --crit condition"},
+		{
+			"rule with ||", checkCritical, `Nairobi: 41°C (temp > 40 || alert.severity == "extreme")`, "temp=41.0",
+			`WEATHER CRITICAL - Nairobi: 41°C (temp > 40 or alert.severity == "extreme") | temp=41.0`,
+		},
+		{"single pipe", checkWarning, "a | b", "temp=1.0", "WEATHER WARNING - a / b | temp=1.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkLine(tt.state, tt.summary, tt.perfdata); got != tt.want {
+				t.Errorf("checkLine = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckPerfdata(t *testing.T) {
+	var data CurrentWeatherResponse
+	data.Main.Temp, data.Main.FeelsLike, data.Main.Humidity, data.Main.Pressure = 24.26, 23.9, 60, 1013
+	data.Wind.Speed, data.Clouds.All = 3.46, 40
+	want := "temp=24.3 feels_like=23.9 humidity=60%;;;0;100 pressure=1013 wind_speed=3.5;;;0 clouds=40%;;;0;100"
+	if got := checkPerfdata(&data); got != want {
+		t.Errorf("checkPerfdata = %q, want %q", got, want)
+	}
+}
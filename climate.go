@@ -0,0 +1,252 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// rainDayThreshold is the rainfall in mm that makes a rain day, following
+// the WMO convention.
+const rainDayThreshold = 1.0
+
+// climateDay aggregates one local calendar day of observations.
+type climateDay struct {
+	date          time.Time
+	min, max, sum float64
+	readings      int
+	rain          float64
+	rainy         bool
+}
+
+// ClimatePeriod summarizes a month or season of one year.
+type ClimatePeriod struct {
+	Period   string  `json:"period"` // e.g. "2023-01" or "2023 DJF"
+	Year     int     `json:"year"`
+	Name     string  `json:"name"` // e.g. "Jan" or "DJF"
+	Days     int     `json:"days"`
+	Mean     float64 `json:"temp_mean"`
+	Min      float64 `json:"temp_min"`
+	Max      float64 `json:"temp_max"`
+	RainDays int     `json:"rain_days"`
+	Rain     float64 `json:"rain_mm"`
+}
+
+// ClimateComparison compares one month or season across years: each
+// year's mean temperature and its difference from the mean of all years.
+type ClimateComparison struct {
+	Name    string          `json:"name"`
+	Mean    float64         `json:"temp_mean"`
+	ByYear  map[int]float64 `json:"by_year"`
+	Anomaly map[int]float64 `json:"anomaly"`
+}
+
+// seasons maps months to meteorological seasons. December counts towards
+// the following year's winter.
+var seasons = [...]string{"DJF", "DJF", "MAM", "MAM", "MAM", "JJA", "JJA", "JJA", "SON", "SON", "SON", "DJF"}
+
+// runClimate implements `weather climate`, summarizing the observation
+// store by month or season.
+func runClimate(args []string) error {
+	fs := flag.NewFlagSet("climate", flag.ExitOnError)
+	city := fs.String("city", "", "City or @favorite to summarize, as recorded by record tasks or history backfill")
+	by := fs.String("by", "month", "Group by month or season")
+	asJSON := fs.Bool("json", false, "Print the summaries as JSON")
+	fs.Parse(args)
+
+	name := *city
+	if name == "" {
+		name = strings.Join(fs.Args(), " ")
+	}
+	if name == "" {
+		return fmt.Errorf("usage: weather climate [--by month|season] [--json] --city <city|@favorite>")
+	}
+	if *by != "month" && *by != "season" {
+		return fmt.Errorf("invalid --by %q, use month or season", *by)
+	}
+	loc, err := resolveLocation(name)
+	if err != nil {
+		return err
+	}
+	usePreferences(loc)
+	observations, err := readObservations()
+	if err != nil {
+		return err
+	}
+	periods := climatePeriods(observations, loc.String(), *by == "season")
+	if len(periods) == 0 {
+		return fmt.Errorf("no observations of %s recorded; add a record task or run weather history backfill", loc)
+	}
+	comparisons := compareClimate(periods)
+
+	if *asJSON {
+		data, err := json.MarshalIndent(struct {
+			Location    string              `json:"location"`
+			Periods     []*ClimatePeriod    `json:"periods"`
+			Comparisons []ClimateComparison `json:"comparisons"`
+		}{loc.String(), periods, comparisons}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode climate summary: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Printf("Climate summary for %s\n\n", loc)
+	temp := displayUnits.temp.symbol
+	fmt.Fprintf(tw, "PERIOD\tDAYS\tMEAN %s\tMIN %s\tMAX %s\tRAIN DAYS\tRAIN mm\t\n", temp, temp, temp)
+	for _, p := range periods {
+		fmt.Fprint(tw, lsprintf("%s\t%d\t%.1f\t%.1f\t%.1f\t%d\t%.1f\t\n", p.Period, p.Days, showTemp(p.Mean), showTemp(p.Min), showTemp(p.Max), p.RainDays, p.Rain))
+	}
+	tw.Flush()
+
+	years := climateYears(periods)
+	if len(years) < 2 {
+		return nil
+	}
+	fmt.Printf("\nMean temperature by year (%s, difference from the average of all years)\n\n", temp)
+	header := []string{strings.ToUpper(*by), "AVG"}
+	for _, y := range years {
+		header = append(header, fmt.Sprint(y))
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t")+"\t")
+	for _, c := range comparisons {
+		row := []string{c.Name, lsprintf("%.1f", showTemp(c.Mean))}
+		for _, y := range years {
+			if mean, ok := c.ByYear[y]; ok {
+				row = append(row, lsprintf("%.1f (%+.1f)", showTemp(mean), showTempChange(c.Anomaly[y])))
+			} else {
+				row = append(row, "-")
+			}
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t")+"\t")
+	}
+	return tw.Flush()
+}
+
+// climatePeriods aggregates location's observations into days, then into
+// calendar months (or seasons), oldest first.
+func climatePeriods(observations []Observation, location string, bySeason bool) []*ClimatePeriod {
+	days := make(map[string]*climateDay)
+	for _, o := range observations {
+		if !strings.EqualFold(o.Location, location) {
+			continue
+		}
+		at := o.At.Local()
+		key := at.Format(time.DateOnly)
+		d, ok := days[key]
+		if !ok {
+			d = &climateDay{date: at, min: math.Inf(1), max: math.Inf(-1)}
+			days[key] = d
+		}
+		d.min, d.max = min(d.min, o.Temp), max(d.max, o.Temp)
+		d.sum += o.Temp
+		d.readings++
+		d.rain += o.Rain
+		switch o.Condition {
+		case "Rain", "Drizzle", "Thunderstorm":
+			d.rainy = true
+		}
+	}
+
+	periods := make(map[string]*ClimatePeriod)
+	sums := make(map[string]float64)
+	for _, d := range days {
+		year, name := d.date.Year(), d.date.Month().String()[:3]
+		period := fmt.Sprintf("%d-%02d", year, d.date.Month())
+		if bySeason {
+			name = seasons[d.date.Month()-1]
+			if d.date.Month() == time.December {
+				year++
+			}
+			period = fmt.Sprintf("%d %s", year, name)
+		}
+		p, ok := periods[period]
+		if !ok {
+			p = &ClimatePeriod{Period: period, Year: year, Name: name, Min: math.Inf(1), Max: math.Inf(-1)}
+			periods[period] = p
+		}
+		p.Days++
+		p.Min, p.Max = min(p.Min, d.min), max(p.Max, d.max)
+		p.Rain += d.rain
+		if d.rain >= rainDayThreshold || d.rainy {
+			p.RainDays++
+		}
+		sums[period] += d.sum / float64(d.readings)
+	}
+
+	result := make([]*ClimatePeriod, 0, len(periods))
+	for key, p := range periods {
+		p.Mean = sums[key] / float64(p.Days)
+		result = append(result, p)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Year != result[j].Year {
+			return result[i].Year < result[j].Year
+		}
+		return periodOrder(result[i].Name) < periodOrder(result[j].Name)
+	})
+	return result
+}
+
+// periodOrder sorts month and season names through the year.
+func periodOrder(name string) int {
+	for i, s := range []string{"DJF", "MAM", "JJA", "SON"} {
+		if name == s {
+			return i
+		}
+	}
+	t, _ := time.Parse("Jan", name)
+	return int(t.Month())
+}
+
+// compareClimate groups periods by month or season name across years.
+func compareClimate(periods []*ClimatePeriod) []ClimateComparison {
+	byName := make(map[string]*ClimateComparison)
+	var names []string
+	for _, p := range periods {
+		c, ok := byName[p.Name]
+		if !ok {
+			c = &ClimateComparison{Name: p.Name, ByYear: make(map[int]float64), Anomaly: make(map[int]float64)}
+			byName[p.Name] = c
+			names = append(names, p.Name)
+		}
+		c.ByYear[p.Year] = p.Mean
+	}
+	sort.Slice(names, func(i, j int) bool { return periodOrder(names[i]) < periodOrder(names[j]) })
+
+	comparisons := make([]ClimateComparison, len(names))
+	for i, name := range names {
+		c := byName[name]
+		for _, mean := range c.ByYear {
+			c.Mean += mean
+		}
+		c.Mean /= float64(len(c.ByYear))
+		for year, mean := range c.ByYear {
+			c.Anomaly[year] = mean - c.Mean
+		}
+		comparisons[i] = *c
+	}
+	return comparisons
+}
+
+// climateYears returns the years covered by periods, in order.
+func climateYears(periods []*ClimatePeriod) []int {
+	seen := make(map[int]bool)
+	var years []int
+	for _, p := range periods {
+		if !seen[p.Year] {
+			seen[p.Year] = true
+			years = append(years, p.Year)
+		}
+	}
+	sort.Ints(years)
+	return years
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// copyToClipboard places text on the system clipboard using the platform's
+// clipboard utility.
+func copyToClipboard(text string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("pbcopy")
+	case "windows":
+		cmd = exec.Command("clip")
+	default:
+		switch {
+		case os.Getenv("WAYLAND_DISPLAY") != "":
+			cmd = exec.Command("wl-copy")
+		default:
+			tool, err := findExecutable("xclip", "xsel")
+			if err != nil {
+				return fmt.Errorf("no clipboard utility found; install wl-clipboard, xclip or xsel")
+			}
+			if strings.HasSuffix(tool, "xsel") {
+				cmd = exec.Command(tool, "--clipboard", "--input")
+			} else {
+				cmd = exec.Command(tool, "-selection", "clipboard")
+			}
+		}
+	}
+	cmd.Stdin = strings.NewReader(text)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to copy to clipboard: %w", err)
+	}
+	return nil
+}
+
+// teeStdout runs fn, letting its output through to stdout while also
+// returning a copy of everything it printed.
+func teeStdout(fn func()) (string, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return "", fmt.Errorf("failed to capture output: %w", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+
+	var captured strings.Builder
+	done := make(chan error)
+	go func() {
+		_, err := io.Copy(io.MultiWriter(stdout, &captured), r)
+		done <- err
+	}()
+
+	fn()
+	os.Stdout = stdout
+	w.Close()
+	if err := <-done; err != nil {
+		return "", fmt.Errorf("failed to capture output: %w", err)
+	}
+	return captured.String(), nil
+}
+
+// displayCurrentWeatherLine prints a one-line summary of current weather.
+func displayCurrentWeatherLine(data *CurrentWeatherResponse) {
+	msg := currentWeatherMessage(data)
+	fmt.Println(msg.Title + ": " + msg.Body())
+}
+
+// displayForecastLine prints a one-line summary of the next forecast entries.
+func displayForecastLine(data *ForecastResponse) {
+	fmt.Println(forecastLine(data, 4))
+}
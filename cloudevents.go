@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// CloudEvent types emitted by the daemon. Alert events get the alert log
+// event appended, e.g. "com.github.mugambi645.weather.alert.fired".
+const (
+	cloudEventObservation = "com.github.mugambi645.weather.observation"
+	cloudEventAlert       = "com.github.mugambi645.weather.alert"
+)
+
+// CloudEventsConfig is the "cloudevents" section of the config file.
+type CloudEventsConfig struct {
+	// URL receives each event as an HTTP POST in structured mode, e.g. a
+	// Knative broker or an EventBridge API destination. Without one no
+	// events are sent.
+	URL string `json:"url,omitempty"`
+	// Source is the events' source attribute; it defaults to
+	// "/weather-tool".
+	Source string `json:"source,omitempty"`
+	// Headers are added to every request, e.g. for authorization.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// cloudEvent is a CloudEvents 1.0 envelope in the JSON event format.
+type cloudEvent struct {
+	SpecVersion     string    `json:"specversion"`
+	ID              string    `json:"id"`
+	Source          string    `json:"source"`
+	Type            string    `json:"type"`
+	Subject         string    `json:"subject,omitempty"`
+	Time            time.Time `json:"time"`
+	DataContentType string    `json:"datacontenttype"`
+	Data            any       `json:"data"`
+}
+
+// emitCloudEvent wraps data in a CloudEvent of eventType about subject and
+// posts it to the configured endpoint, if any.
+func emitCloudEvent(eventType, subject string, at time.Time, data any) error {
+	cfg := config.CloudEvents
+	if cfg.URL == "" {
+		return nil
+	}
+	id := make([]byte, 16)
+	rand.Read(id)
+	event := cloudEvent{
+		SpecVersion:     "1.0",
+		ID:              hex.EncodeToString(id),
+		Source:          cfg.Source,
+		Type:            eventType,
+		Subject:         subject,
+		Time:            at,
+		DataContentType: "application/json",
+		Data:            data,
+	}
+	if event.Source == "" {
+		event.Source = "/weather-tool"
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode CloudEvent: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid cloudevents url: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/cloudevents+json; charset=utf-8")
+	for name, value := range cfg.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send CloudEvent: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("CloudEvent endpoint returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+	return nil
+}
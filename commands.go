@@ -0,0 +1,81 @@
+package main
+
+import "strings"
+
+// commands maps subcommand names to their entry points. Each entry point
+// parses its own flags from the arguments following the subcommand name.
+var commands = map[string]func(args []string) error{
+	"accuracy":        runAccuracy,
+	"admin":           runAdmin,
+	"air":             runAir,
+	"alerts":          runAlerts,
+	"ask":             runAsk,
+	"at":              runAt,
+	"bench-providers": runBenchProviders,
+	"briefing":        runBriefing,
+	"bulk":            runBulk,
+	"chart":           runChart,
+	"check":           runCheck,
+	"climate":         runClimate,
+	"compare":         runCompare,
+	"convert":         runConvert,
+	"current":         runCurrent,
+	"daemon":          runDaemon,
+	"ensemble":        runEnsemble,
+	"fav":             runFav,
+	"forecast":        runForecast,
+	"frost":           runFrost,
+	"full":            runFull,
+	"history":         runHistory,
+	"hourly":          runHourly,
+	"index":           runIndex,
+	"irc":             runIRC,
+	"last":            runLast,
+	"map":             runMap,
+	"matrix":          runMatrix,
+	"monthly":         runMonthly,
+	"notify":          runNotify,
+	"nowcast":         runNowcast,
+	"paths":           runPaths,
+	"prompt":          runPrompt,
+	"providers":       runProviders,
+	"recent":          runRecent,
+	"roadrisk":        runRoadRisk,
+	"search":          runSearch,
+	"share":           runShare,
+	"serve":           runServe,
+	"site":            runSite,
+	"solar":           runSolar,
+	"speak":           runSpeak,
+	"station":         runStation,
+	"stress":          runStress,
+	"tray":            runTray,
+	"triggers":        runTriggers,
+	"warnings":        runWarnings,
+	"zabbix":          runZabbix,
+}
+
+// stringList is a flag.Value collecting every occurrence of a repeatable
+// flag, e.g. `--city Nairobi --city Meru`.
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// countSet returns how many of the given conditions are true, e.g. to check
+// that mutually exclusive flags weren't combined.
+func countSet(conditions ...bool) int {
+	n := 0
+	for _, c := range conditions {
+		if c {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// runCompare implements `weather compare <city|@favorite|@group>...`,
+// printing current conditions for several locations side by side.
+func runCompare(args []string) error {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		return fmt.Errorf("usage: weather compare <city|@favorite|@group>...")
+	}
+	var locations []Location
+	for _, arg := range fs.Args() {
+		resolved, err := resolveLocations(arg)
+		if err != nil {
+			return err
+		}
+		locations = append(locations, resolved...)
+	}
+	apiKey := apiKeyFromEnv()
+
+	// A location that can't be fetched gets a row saying so, and the
+	// reasons are listed after the table.
+	var failed []string
+	var reasons []error
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Location\tTemp\tFeels\tConditions\tHumidity\tWind")
+	for _, loc := range locations {
+		data, err := GetCurrentWeatherAt(loc, apiKey)
+		if err != nil {
+			fmt.Fprintf(w, "%s\t-\t-\tnot available (see below)\t-\t-\n", loc)
+			failed = append(failed, loc.String())
+			reasons = append(reasons, fmt.Errorf("%s: %w", loc, withSuggestions(err, loc, apiKey)))
+			continue
+		}
+		condition := "N/A"
+		if len(data.Weather) > 0 {
+			condition = data.Weather[0].Description
+		}
+		temp, speed := displayUnits.temp.symbol, displayUnits.speed.symbol
+		fmt.Fprint(w, lsprintf("%s\t%.*f%s\t%.*f%s\t%s\t%d%%\t%.*f %s\n",
+			loc, decimals("temp", 1), showTemp(data.Main.Temp), temp, decimals("temp", 1), showTemp(data.Main.FeelsLike), temp,
+			condition, data.Main.Humidity, decimals("wind", 1), showSpeed(data.Wind.Speed), speed))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	fmt.Println()
+	for _, reason := range reasons {
+		fmt.Printf("  %v\n", reason)
+	}
+	return fmt.Errorf("fetching current weather failed for %s", strings.Join(failed, ", "))
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// Config holds settings read from the config file. Every field is optional;
+// command-line flags and environment variables take precedence.
+type Config struct {
+	APIKey      string `json:"api_key,omitempty"`
+	DefaultCity string `json:"default_city,omitempty"`
+	Output      string `json:"output,omitempty"`
+	Provider    string `json:"provider,omitempty"`
+	// Providers is a fallback chain used instead of Provider: each fetch
+	// tries them in order until one succeeds. AutoOrderProviders orders
+	// them by the last `weather bench-providers` results instead.
+	Providers          []string `json:"providers,omitempty"`
+	AutoOrderProviders bool     `json:"auto_order_providers,omitempty"`
+	// Pro enables commands using OpenWeatherMap's paid Pro endpoints, such
+	// as the hourly forecast. Set it when the API key's plan includes them.
+	Pro bool `json:"pro,omitempty"`
+	// Locale formats numbers and dates, e.g. "de-DE"; it defaults to the
+	// LC_ALL or LANG environment variable.
+	Locale string `json:"locale,omitempty"`
+	// Units are the default for --units: "metric", "imperial" or
+	// "standard".
+	Units string `json:"units,omitempty"`
+	// Lang is the default for --lang, the language of condition
+	// descriptions, e.g. "de".
+	Lang string `json:"lang,omitempty"`
+	// Precision is the default for --precision: the decimals to show
+	// each field with, e.g. {"temp": 0, "wind": 1}.
+	Precision map[string]int `json:"precision,omitempty"`
+	// FeelsLikeAlgo is the default for --feels-like-algo.
+	FeelsLikeAlgo string `json:"feels_like_algo,omitempty"`
+	// Locations holds settings for single favorites, keyed by favorite
+	// name; see LocationPreferences.
+	Locations map[string]LocationPreferences `json:"locations,omitempty"`
+	// QuietHours maps notification channels to a daily range such as
+	// "22:00-07:00" during which non-severe messages are held back.
+	QuietHours map[string]string `json:"quiet_hours,omitempty"`
+	// ExecChannels defines extra notification channels that run a command,
+	// keyed by channel name; see ExecNotifier.
+	ExecChannels map[string][]string `json:"exec_channels,omitempty"`
+
+	// Email configures the built-in email channel.
+	Email EmailConfig `json:"email"`
+	// Retry tunes how failed OpenWeatherMap requests are retried.
+	Retry RetryConfig `json:"retry"`
+
+	Hooks       HooksConfig       `json:"hooks"`
+	Daemon      DaemonConfig      `json:"daemon"`
+	Server      ServerConfig      `json:"server"`
+	CloudEvents CloudEventsConfig `json:"cloudevents"`
+	Zabbix      ZabbixConfig      `json:"zabbix"`
+	StatsD      StatsDConfig      `json:"statsd"`
+}
+
+// config is the loaded configuration, populated by loadConfig at startup.
+var config Config
+
+// loadConfig reads the config file into config. A missing default config
+// file is fine; a missing file named explicitly with --config is an error.
+func loadConfig() error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && configFile == "" {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
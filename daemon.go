@@ -0,0 +1,577 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// DaemonConfig is the "daemon" section of the config file.
+type DaemonConfig struct {
+	Tasks []DaemonTask `json:"tasks"`
+	Hooks DaemonHooks  `json:"hooks,omitempty"`
+}
+
+// DaemonTask is one scheduled job run by `weather daemon`.
+type DaemonTask struct {
+	// Name identifies the task in logs; it defaults to the task type.
+	Name string `json:"name,omitempty"`
+	// Type selects what the task does; see daemonTaskTypes.
+	Type string `json:"type"`
+	// Schedule is a five-field cron expression ("30 6 * * *") or a
+	// descriptor such as "@hourly" or "@every 10m".
+	Schedule string `json:"schedule"`
+	// Locations are cities, @favorites or @groups the task applies to.
+	// Warnings tasks without locations watch every favorite.
+	Locations []string `json:"locations"`
+	// Forecasts are the providers, e.g. ["openweathermap", "met"], whose
+	// forecasts record tasks also store for `weather accuracy`.
+	Forecasts []string `json:"forecasts,omitempty"`
+	// Channels are the notification channels used by digest, alerts and
+	// warnings tasks.
+	Channels []string `json:"channels,omitempty"`
+	// Routes are further channels, each with its own minimum severity and
+	// message template.
+	Routes []NotifyRoute `json:"routes,omitempty"`
+	// Out is the output directory for site tasks.
+	Out string `json:"out,omitempty"`
+	// Station is the OpenWeatherMap station ID station tasks submit to.
+	Station string `json:"station,omitempty"`
+	// Source is the command station tasks run for readings; it prints a
+	// JSON reading, or an array of them, in the Stations API's fields.
+	Source []string `json:"source,omitempty"`
+	// Condition is an alert rule expression over ruleEnv, e.g.
+	// "max(pop, 0h, 12h) > 0.6 && temp_min < 2". Without one, alerts
+	// tasks fire on severe weather.
+	Condition string `json:"condition,omitempty"`
+	// Severity is reported when Condition matches: "warning" (the
+	// default), "severe" or "extreme". Severe alerts skip quiet hours.
+	Severity string `json:"severity,omitempty"`
+	// Match is "any" (the default) to alert for each location where the
+	// condition holds, or "all" to alert only when it holds everywhere.
+	Match string `json:"match,omitempty"`
+	// Cooldown is how long a still-firing alert waits before being sent
+	// again, e.g. "3h"; it defaults to defaultAlertCooldown.
+	Cooldown string `json:"cooldown,omitempty"`
+	// MinSeverity and Categories limit warnings tasks to warnings at least
+	// this severe ("minor", "moderate", "severe" or "extreme") and in one
+	// of these categories, e.g. ["flood", "wind"].
+	MinSeverity string   `json:"min_severity,omitempty"`
+	Categories  []string `json:"categories,omitempty"`
+	// Ping is a healthchecks.io-style URL requested after every successful
+	// run, so a monitor notices when runs stop or fail.
+	Ping string `json:"ping,omitempty"`
+	// PingFail is posted the error after a failed run; it defaults to Ping
+	// followed by "/fail".
+	PingFail string `json:"ping_fail,omitempty"`
+}
+
+// daemonTaskTypes builds the job for each task type. Each returned func is
+// called on every tick of the task's schedule.
+var daemonTaskTypes = map[string]func(task DaemonTask, locations []Location, apiKey string) (func() error, error){
+	"record":   recordTask,
+	"digest":   digestTask,
+	"alerts":   alertsTask,
+	"site":     siteTask,
+	"station":  stationTask,
+	"warnings": warningsTask,
+}
+
+// daemonReload guards the config and statsd globals while the daemon runs:
+// jobs, whether run on schedule or by a hook, hold it for reading, and a
+// SIGHUP reload holds it for writing while it replaces them.
+var daemonReload sync.RWMutex
+
+// daemonTaskTypeNames lists the accepted task types for error messages.
+func daemonTaskTypeNames() string {
+	names := make([]string, 0, len(daemonTaskTypes))
+	for name := range daemonTaskTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// runDaemon implements `weather daemon`, running the tasks defined in the
+// "daemon" section of the config file until interrupted, and
+// `weather daemon install`.
+func runDaemon(args []string) error {
+	if len(args) > 0 && args[0] == "install" {
+		return runDaemonInstall(args[1:])
+	}
+	fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+	fs.Parse(args)
+
+	if len(config.Daemon.Tasks) == 0 {
+		path, _ := configPath()
+		return fmt.Errorf("no daemon tasks configured; add a \"daemon\" section to %s", path)
+	}
+	scheduler, jobs, err := newScheduler(config.Daemon.Tasks, apiKeyFromEnv())
+	if err != nil {
+		return err
+	}
+	hookSrv, hooks, err := startHookServer(config.Daemon.Hooks, jobs)
+	if err != nil {
+		return err
+	}
+	if statsd, err = newStatsDEmitter(config.StatsD); err != nil {
+		return err
+	}
+	defer func() { statsd.Close() }()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	scheduler.Start()
+	log.Printf("daemon started with %d tasks", len(config.Daemon.Tasks))
+
+	for sig := range signals {
+		if sig != syscall.SIGHUP {
+			log.Printf("received %s, waiting for running tasks to finish", sig)
+			break
+		}
+		daemonReload.Lock()
+		reloaded, jobs, err := reloadScheduler()
+		if err == nil {
+			statsd.Close()
+			if statsd, err = newStatsDEmitter(config.StatsD); err != nil {
+				log.Printf("reload: %v; not sending metrics", err)
+				err = nil
+			}
+		}
+		daemonReload.Unlock()
+		if err != nil {
+			log.Printf("reload failed, keeping the current tasks: %v", err)
+			continue
+		}
+		// Stopped outside the lock, as it waits for running jobs, which
+		// take it too.
+		<-scheduler.Stop().Done()
+		scheduler = reloaded
+		scheduler.Start()
+		if hooks != nil {
+			hooks.setJobs(jobs)
+		}
+		log.Printf("config reloaded, running %d tasks", len(config.Daemon.Tasks))
+	}
+
+	// A second signal while tasks are finishing exits straight away.
+	done := make(chan struct{})
+	go func() {
+		<-scheduler.Stop().Done()
+		stopHookServer(hookSrv)
+		close(done)
+	}()
+	signal.Reset(syscall.SIGHUP)
+	select {
+	case <-done:
+	case sig := <-signals:
+		return fmt.Errorf("received %s, exiting without waiting for running tasks", sig)
+	}
+	log.Printf("daemon stopped")
+	return nil
+}
+
+// reloadScheduler rereads the config file and builds a scheduler for its
+// tasks. The global config is only replaced if the new tasks are valid. The
+// caller holds daemonReload.
+func reloadScheduler() (*cron.Cron, map[string]*daemonJob, error) {
+	previous := config
+	config = Config{}
+	if err := loadConfig(); err != nil {
+		config = previous
+		return nil, nil, err
+	}
+	apiKey, err := lookupAPIKey()
+	if err != nil {
+		config = previous
+		return nil, nil, err
+	}
+	scheduler, jobs, err := newScheduler(config.Daemon.Tasks, apiKey)
+	if err != nil {
+		config = previous
+		return nil, nil, err
+	}
+	return scheduler, jobs, nil
+}
+
+// newScheduler validates tasks and registers them on a cron scheduler,
+// returning them by name for hooks as well. Runs of a task that is still
+// busy, from its previous tick or a hook, are skipped.
+func newScheduler(tasks []DaemonTask, apiKey string) (*cron.Cron, map[string]*daemonJob, error) {
+	logger := cron.PrintfLogger(log.Default())
+	scheduler := cron.New(cron.WithLogger(logger), cron.WithChain(cron.Recover(logger)))
+	jobs := make(map[string]*daemonJob, len(tasks))
+	for i, task := range tasks {
+		if task.Name == "" {
+			task.Name = task.Type
+		}
+		newJob, ok := daemonTaskTypes[task.Type]
+		if !ok {
+			return nil, nil, fmt.Errorf("daemon task %d: unknown type %q, use one of: %s", i+1, task.Type, daemonTaskTypeNames())
+		}
+		if len(task.Locations) == 0 && task.Type != "station" && task.Type != "warnings" {
+			return nil, nil, fmt.Errorf("daemon task %q: no locations given", task.Name)
+		}
+		var locations []Location
+		for _, arg := range task.Locations {
+			locs, err := resolveLocations(arg)
+			if err != nil {
+				return nil, nil, fmt.Errorf("daemon task %q: %w", task.Name, err)
+			}
+			locations = append(locations, locs...)
+		}
+		job, err := newJob(task, locations, apiKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("daemon task %q: %w", task.Name, err)
+		}
+		j := &daemonJob{name: task.Name, ping: task.Ping, pingFail: task.PingFail, job: job}
+		if j.pingFail == "" && j.ping != "" {
+			j.pingFail = strings.TrimSuffix(j.ping, "/") + "/fail"
+		}
+		if _, dup := jobs[task.Name]; dup {
+			jobs[task.Name] = nil // ambiguous for hooks
+		} else {
+			jobs[task.Name] = j
+		}
+		_, err = scheduler.AddFunc(task.Schedule, func() {
+			if err := j.run(); errors.Is(err, errTaskRunning) {
+				log.Printf("%s: still running, skipping this run", j.name)
+			}
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("daemon task %q: invalid schedule %q: %w", task.Name, task.Schedule, err)
+		}
+	}
+	// Send email recipients with a schedule what was queued for them.
+	for _, r := range config.Email.Recipients {
+		if r.Schedule == "" {
+			continue
+		}
+		_, err := scheduler.AddFunc(r.Schedule, func() {
+			daemonReload.RLock()
+			defer daemonReload.RUnlock()
+			if err := flushEmailQueue(r.Address); err != nil {
+				log.Printf("email to %s: %v", r.Address, err)
+			}
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("email recipient %s: invalid schedule %q: %w", r.Address, r.Schedule, err)
+		}
+	}
+	// Deliver messages held during quiet hours once those hours end.
+	if len(config.QuietHours) > 0 {
+		scheduler.AddFunc("* * * * *", func() {
+			daemonReload.RLock()
+			defer daemonReload.RUnlock()
+			if err := flushQuietQueues(); err != nil {
+				log.Printf("quiet hours: %v", err)
+			}
+		})
+	}
+	return scheduler, jobs, nil
+}
+
+// recordTask fetches the current weather for each location and appends it
+// to the observation log, emitting it as a CloudEvent when configured. It
+// also stores the forecasts of the task's forecast providers, at most once
+// every forecastRecordInterval.
+func recordTask(task DaemonTask, locations []Location, apiKey string) (func() error, error) {
+	lastForecast := make(map[string]time.Time)
+	return func() error {
+		var failed []string
+		for _, loc := range locations {
+			data, err := GetCurrentWeatherAt(loc, apiKey)
+			if err == nil {
+				obs := observationFrom(loc.String(), data)
+				err = errors.Join(appendObservation(obs), emitCloudEvent(cloudEventObservation, obs.Location, obs.At, obs))
+			}
+			for _, p := range task.Forecasts {
+				key := p + "|" + loc.String()
+				if time.Since(lastForecast[key]) < forecastRecordInterval {
+					continue
+				}
+				var forecast ForecastResponse
+				ferr := fetchFrom(p, "forecast", loc, apiKey, &forecast)
+				if ferr == nil {
+					ferr = recordForecast(loc.String(), p, time.Now(), &forecast)
+				}
+				if ferr != nil {
+					err = errors.Join(err, fmt.Errorf("%s forecast: %w", p, ferr))
+					continue
+				}
+				lastForecast[key] = time.Now()
+			}
+			if err != nil {
+				log.Printf("%s: %s: %v", task.Name, loc, err)
+				failed = append(failed, loc.String())
+			}
+		}
+		if len(failed) > 0 {
+			return fmt.Errorf("recording failed for %s", strings.Join(failed, ", "))
+		}
+		return nil
+	}, nil
+}
+
+// digestTask sends a spoken-style summary of the current weather and the
+// day ahead for each location.
+func digestTask(task DaemonTask, locations []Location, apiKey string) (func() error, error) {
+	channels, targets, err := taskNotifiers(task)
+	if err != nil {
+		return nil, err
+	}
+	return func() error {
+		msg := Message{Title: "Weather digest"}
+		var failed []string
+		for _, loc := range locations {
+			current, err := GetCurrentWeatherAt(loc, apiKey)
+			var forecast *ForecastResponse
+			if err == nil {
+				forecast, err = GetForecastAt(loc, apiKey)
+			}
+			if err != nil {
+				// Send the rest of the digest, noting the gap.
+				log.Printf("%s: %s: %v", task.Name, loc, err)
+				failed = append(failed, loc.String())
+				msg.Parts = append(msg.Parts, fmt.Sprintf("The weather for %s is not available right now.", loc))
+				continue
+			}
+			msg.Parts = append(msg.Parts, spokenSummary(current, forecast, time.Now()))
+		}
+		if len(failed) == len(locations) {
+			return fmt.Errorf("fetching the weather failed for %s", strings.Join(failed, ", "))
+		}
+		if err := sendAll(channels, targets, msg); err != nil {
+			return err
+		}
+		if len(failed) > 0 {
+			return fmt.Errorf("digest sent without %s", strings.Join(failed, ", "))
+		}
+		return nil
+	}, nil
+}
+
+// alertsTask notifies when a location's weather turns severe, or when the
+// task's condition matches. The task's name is the rule name used for alert
+// state and snoozing; see AlertStore.update for when an alert is sent again.
+func alertsTask(task DaemonTask, locations []Location, apiKey string) (func() error, error) {
+	channels, targets, err := taskNotifiers(task)
+	if err != nil {
+		return nil, err
+	}
+	cooldown := defaultAlertCooldown
+	if task.Cooldown != "" {
+		if cooldown, err = time.ParseDuration(task.Cooldown); err != nil {
+			return nil, fmt.Errorf("invalid cooldown %q: %w", task.Cooldown, err)
+		}
+	}
+	var rule *Rule
+	if task.Condition != "" {
+		if rule, err = compileRule(task.Condition); err != nil {
+			return nil, err
+		}
+	}
+	if task.Match != "" && task.Match != "any" && task.Match != "all" {
+		return nil, fmt.Errorf("invalid match %q, use any or all", task.Match)
+	}
+	severity := severityWarning
+	switch task.Severity {
+	case "", severityWarning:
+	case severitySevere, severityExtreme:
+		severity = task.Severity
+	default:
+		return nil, fmt.Errorf("invalid severity %q, use warning, severe or extreme", task.Severity)
+	}
+
+	return func() error {
+		// With match any, a location that can't be checked is reported
+		// and left as it was, and the others are still checked; match all
+		// can't be decided without it.
+		current := make(map[Location]*CurrentWeatherResponse)
+		levels := make(map[Location]string)
+		var checked []Location
+		var checkErr error
+		for _, loc := range locations {
+			level, data, err := checkAlert(loc, rule, severity, apiKey)
+			if err != nil {
+				if task.Match == "all" {
+					return err
+				}
+				checkErr = errors.Join(checkErr, err)
+				continue
+			}
+			current[loc], levels[loc] = data, level
+			checked = append(checked, loc)
+		}
+		if task.Match == "all" {
+			for _, loc := range locations {
+				if levels[loc] == severityNone {
+					clear(levels)
+					break
+				}
+			}
+		}
+
+		alertStoreMu.Lock()
+		defer alertStoreMu.Unlock()
+		store, err := loadAlertStore()
+		if err != nil {
+			return err
+		}
+		var firing []*CurrentWeatherResponse
+		var names []string
+		var entries []AlertLogEntry
+		highest := severityNone
+		now := time.Now()
+		for _, loc := range checked {
+			level := levels[loc]
+			notify, event := store.update(task.Name, loc, level, cooldown, now)
+			if event != "" {
+				entries = append(entries, AlertLogEntry{At: now, Rule: task.Name, Event: event, Location: loc.String(), Severity: level})
+			}
+			if notify {
+				firing = append(firing, current[loc])
+				names = append(names, loc.String())
+				if slices.Index(alertSeverities, level) > slices.Index(alertSeverities, highest) {
+					highest = level
+				}
+			}
+		}
+
+		// A failed send is retried after the cooldown rather than every tick.
+		var sendErr error
+		if len(firing) > 0 {
+			msg := alertMessage(task.Name, rule, firing, highest)
+			for i, n := range targets {
+				entry := AlertLogEntry{At: now, Rule: task.Name, Event: eventSent, Location: strings.Join(names, "; "), Channel: channels[i]}
+				skip, held := delivery(n, msg)
+				if skip {
+					continue
+				}
+				if held {
+					entry.Event = eventHeld
+				}
+				if err := n.Notify(msg); err != nil {
+					entry.Event, entry.Error = eventFailed, err.Error()
+					sendErr = errors.Join(sendErr, fmt.Errorf("sending via %s: %w", channels[i], err))
+				}
+				entries = append(entries, entry)
+			}
+		}
+		var emitErr error
+		for _, entry := range entries {
+			emitErr = errors.Join(emitErr, emitCloudEvent(cloudEventAlert+"."+entry.Event, entry.Location, entry.At, entry))
+		}
+		return errors.Join(checkErr, sendErr, saveAlertStore(store), logAlertEvents(entries...), emitErr)
+	}, nil
+}
+
+// checkAlert fetches the current weather at loc and returns its alert
+// level: severity if rule matches, or without a rule, how severe the
+// weather is.
+func checkAlert(loc Location, rule *Rule, severity, apiKey string) (string, *CurrentWeatherResponse, error) {
+	data, err := GetCurrentWeatherAt(loc, apiKey)
+	if err != nil {
+		return "", nil, fmt.Errorf("fetching current weather for %s: %w", loc, err)
+	}
+	if rule == nil {
+		return alertSeverity(loc, data), data, nil
+	}
+	matched, err := rule.Eval(loc, data, apiKey)
+	if err != nil {
+		return "", nil, fmt.Errorf("checking %s: %w", loc, err)
+	}
+	if matched {
+		return severity, data, nil
+	}
+	return severityNone, data, nil
+}
+
+// alertMessage builds one notification covering every location that fired
+// for a rule in the same run; severity is the highest among them.
+func alertMessage(name string, rule *Rule, firing []*CurrentWeatherResponse, severity string) Message {
+	var msg Message
+	if len(firing) == 1 {
+		msg = currentWeatherMessage(firing[0])
+	} else {
+		msg.Title = fmt.Sprintf("%d locations", len(firing))
+		for _, data := range firing {
+			line := currentWeatherMessage(data)
+			msg.Parts = append(msg.Parts, fmt.Sprintf("%s: %s", data.Name, line.Parts[0]))
+		}
+	}
+	msg.Severity, msg.Severe = severity, severity != severityWarning
+	if rule != nil {
+		msg.Title = name + ": " + msg.Title
+		msg.Parts = append([]string{rule.Source}, msg.Parts...)
+	} else if len(firing) > 1 {
+		msg.Title = "SEVERE WEATHER: " + msg.Title
+	}
+	return msg
+}
+
+// siteTask regenerates the static site into task.Out.
+func siteTask(task DaemonTask, locations []Location, apiKey string) (func() error, error) {
+	if task.Out == "" {
+		return nil, fmt.Errorf("site tasks need an \"out\" directory")
+	}
+	return func() error {
+		data, fetchErr := buildSiteData(locations, 30*time.Minute, apiKey)
+		if len(data.Pages) == 0 {
+			return fetchErr
+		}
+		return errors.Join(writeSite(task.Out, data), fetchErr)
+	}, nil
+}
+
+// stationTask submits the readings printed by task.Source to a personal
+// weather station, turning the daemon into a station uploader.
+func stationTask(task DaemonTask, locations []Location, apiKey string) (func() error, error) {
+	if task.Station == "" || len(task.Source) == 0 {
+		return nil, fmt.Errorf("station tasks need a \"station\" ID and a \"source\" command")
+	}
+	return func() error {
+		readings, err := readStationSource(task.Source, task.Station)
+		if err != nil {
+			return err
+		}
+		return PushStationMeasurements(readings, apiKey)
+	}, nil
+}
+
+// pingHealthcheck reports a task run to its monitoring URL: a GET on
+// success, or a POST with the error as the body, which healthchecks.io
+// shows in its log. Problems reaching the monitor are only logged.
+func pingHealthcheck(task, pingURL string, runErr error) {
+	if pingURL == "" {
+		return
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	var resp *http.Response
+	var err error
+	if runErr == nil {
+		resp, err = client.Get(pingURL)
+	} else {
+		resp, err = client.Post(pingURL, "text/plain; charset=utf-8", strings.NewReader(runErr.Error()))
+	}
+	if err != nil {
+		log.Printf("%s: ping failed: %v", task, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Printf("%s: ping returned status %d", task, resp.StatusCode)
+	}
+}
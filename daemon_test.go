@@ -0,0 +1,26 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReloadSchedulerWithoutKey(t *testing.T) {
+	t.Setenv("OPENWEATHER_API_KEY", "")
+	saved := configFile
+	configFile = filepath.Join(t.TempDir(), "config.json")
+	defer func() { configFile = saved }()
+	if err := os.WriteFile(configFile, []byte(`{"default_city": "Mombasa"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	withConfig(t, Config{APIKey: "key", DefaultCity: "Nairobi"}, func() {
+		if _, _, err := reloadScheduler(); !errors.Is(err, errNoAPIKey) {
+			t.Errorf("reloadScheduler error = %v, want %v", err, errNoAPIKey)
+		}
+		if config.APIKey != "key" || config.DefaultCity != "Nairobi" {
+			t.Errorf("config after a failed reload = %+v, want the previous one", config)
+		}
+	})
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DaemonHooks is the "hooks" part of the daemon section, an HTTP endpoint
+// through which automations (Shortcuts, IFTTT, CI) run a task on demand.
+type DaemonHooks struct {
+	// Listen is the address to serve on, e.g. "127.0.0.1:8090"; empty
+	// disables the endpoint.
+	Listen string `json:"listen,omitempty"`
+	// Tokens are the API keys callers must present. At least one is
+	// required.
+	Tokens []ServerToken `json:"tokens,omitempty"`
+}
+
+// errTaskRunning is returned when a task is triggered while already busy.
+var errTaskRunning = errors.New("task is still running")
+
+// daemonJob is a configured daemon task, run by its schedule or a hook.
+type daemonJob struct {
+	name, ping, pingFail string
+	job                  func() error
+	running              sync.Mutex
+}
+
+// run runs the task once, logging the outcome and pinging its health
+// check. It returns errTaskRunning without running if a run is in progress.
+func (j *daemonJob) run() error {
+	if !j.running.TryLock() {
+		return errTaskRunning
+	}
+	defer j.running.Unlock()
+	daemonReload.RLock()
+	defer daemonReload.RUnlock()
+	start := time.Now()
+	if err := j.job(); err != nil {
+		log.Printf("%s: %v", j.name, err)
+		pingHealthcheck(j.name, j.pingFail, err)
+		return err
+	}
+	log.Printf("%s: done in %s", j.name, time.Since(start).Round(time.Millisecond))
+	pingHealthcheck(j.name, j.ping, nil)
+	return nil
+}
+
+// hookServer serves POST /hooks/report?task=NAME, running the named task
+// straight away and answering once it has finished.
+type hookServer struct {
+	mu   sync.Mutex
+	jobs map[string]*daemonJob
+}
+
+// setJobs replaces the tasks hooks can run, after a reload.
+func (h *hookServer) setJobs(jobs map[string]*daemonJob) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.jobs = jobs
+}
+
+func (h *hookServer) serveReport(w http.ResponseWriter, r *http.Request) {
+	name := r.FormValue("task")
+	if name == "" {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("missing task parameter"))
+		return
+	}
+	h.mu.Lock()
+	job, ok := h.jobs[name]
+	h.mu.Unlock()
+	switch {
+	case !ok:
+		writeJSONError(w, http.StatusNotFound, fmt.Errorf("no daemon task named %q", name))
+		return
+	case job == nil:
+		writeJSONError(w, http.StatusConflict, fmt.Errorf("several daemon tasks are named %q; give them distinct names", name))
+		return
+	}
+
+	log.Printf("%s: triggered by hook", name)
+	start := time.Now()
+	err := job.run()
+	switch {
+	case errors.Is(err, errTaskRunning):
+		writeJSONError(w, http.StatusConflict, fmt.Errorf("%s: %w", name, err))
+	case err != nil:
+		writeJSONError(w, http.StatusBadGateway, fmt.Errorf("%s: %w", name, err))
+	default:
+		writeJSON(w, http.StatusOK, map[string]string{
+			"task":     name,
+			"status":   "done",
+			"duration": time.Since(start).Round(time.Millisecond).String(),
+		})
+	}
+}
+
+// startHookServer serves the hooks endpoint for jobs in the background. The
+// returned server is nil when no listen address is configured.
+func startHookServer(cfg DaemonHooks, jobs map[string]*daemonJob) (*http.Server, *hookServer, error) {
+	if cfg.Listen == "" {
+		return nil, nil, nil
+	}
+	if len(cfg.Tokens) == 0 {
+		return nil, nil, fmt.Errorf("daemon hooks: no tokens configured; add at least one to \"hooks\"")
+	}
+	hooks := &hookServer{jobs: jobs}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /hooks/report", hooks.serveReport)
+	srv := &http.Server{
+		Addr:              cfg.Listen,
+		Handler:           requireToken(cfg.Tokens, nil, mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	ln, err := net.Listen("tcp", cfg.Listen)
+	if err != nil {
+		return nil, nil, fmt.Errorf("daemon hooks: %w", err)
+	}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("hooks: %v", err)
+		}
+	}()
+	log.Printf("hooks listening on http://%s/hooks/report", cfg.Listen)
+	return srv, hooks, nil
+}
+
+// stopHookServer stops accepting hooks and waits for the runs they
+// triggered to finish.
+func stopHookServer(srv *http.Server) {
+	if srv == nil {
+		return
+	}
+	if err := srv.Shutdown(context.Background()); err != nil {
+		log.Printf("hooks: %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// forecastStep is how long each forecast point stands for.
+const forecastStep = 3 * time.Hour
+
+// dailySummary condenses one local day of 3-hour forecast steps.
+// Condition is the commonest one, ties going to the first alphabetically,
+// and is empty when no step reported any. Sunshine is estimated hours of
+// sun; see sunshineHours.
+type dailySummary struct {
+	Date      time.Time `json:"date"` // local midnight
+	Low       float64   `json:"temp_min"`
+	High      float64   `json:"temp_max"`
+	Condition string    `json:"condition"`
+	AvgWind   float64   `json:"wind_avg"`
+	MaxPop    float64   `json:"pop_max"`
+	Sunshine  float64   `json:"sunshine_hours"`
+	Steps     int       `json:"steps"`
+}
+
+// summarizeDays groups points forecast for lat, lon by local date, in the
+// order the days first appear, and summarizes each day. Temperatures are
+// °C and wind m/s, as in the points.
+func summarizeDays(points []ForecastPoint, lat, lon float64) []dailySummary {
+	var days []dailySummary
+	var conditions []map[string]int
+	index := make(map[time.Time]int)
+	for _, p := range points {
+		at := p.At.Local()
+		date := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.Local)
+		i, ok := index[date]
+		if !ok {
+			i = len(days)
+			index[date] = i
+			days = append(days, dailySummary{Date: date, Low: p.Temp, High: p.Temp})
+			conditions = append(conditions, make(map[string]int))
+		}
+		d := &days[i]
+		d.Low, d.High, d.MaxPop = min(d.Low, p.Temp), max(d.High, p.Temp), max(d.MaxPop, p.Pop)
+		d.AvgWind += p.WindSpeed
+		d.Sunshine += sunshineHours(p.At, p.Clouds, lat, lon)
+		d.Steps++
+		if p.Condition != "" {
+			conditions[i][p.Condition]++
+		}
+	}
+	for i := range days {
+		d := &days[i]
+		d.AvgWind /= float64(d.Steps)
+		for _, c := range sortedKeys(conditions[i]) {
+			if d.Condition == "" || conditions[i][c] > conditions[i][d.Condition] {
+				d.Condition = c
+			}
+		}
+	}
+	return days
+}
+
+// dailySummariesFrom summarizes a forecast response by day.
+func dailySummariesFrom(data *ForecastResponse) []dailySummary {
+	return summarizeDays(forecastPointsFrom(data), data.City.Coord.Lat, data.City.Coord.Lon)
+}
+
+// sunshineHours estimates the hours of sunshine in the forecast step
+// starting at at, with clouds % cloud cover, at lat, lon: the daylight in
+// the step times the clear fraction of the sky. It's rough, as thin cloud
+// lets the sun through and the cover is only forecast for the step's start.
+func sunshineHours(at time.Time, clouds, lat, lon float64) float64 {
+	length := dayLength(at, lat)
+	// Solar noon, ignoring the equation of time (under 17 minutes).
+	utc := at.UTC()
+	noon := time.Date(utc.Year(), utc.Month(), utc.Day(), 12, 0, 0, 0, time.UTC).Add(time.Duration(-lon / 15 * float64(time.Hour)))
+	// The step can catch the end of one day's daylight and the start of the
+	// next's.
+	var daylight time.Duration
+	for _, n := range []time.Time{noon.AddDate(0, 0, -1), noon, noon.AddDate(0, 0, 1)} {
+		from, to := n.Add(-length/2), n.Add(length/2)
+		if at.After(from) {
+			from = at
+		}
+		if end := at.Add(forecastStep); end.Before(to) {
+			to = end
+		}
+		if to.After(from) {
+			daylight += to.Sub(from)
+		}
+	}
+	return daylight.Hours() * (1 - min(max(clouds, 0), 100)/100)
+}
+
+// dayLength returns the time from sunrise to sunset on the day of at at
+// latitude lat: 0 in the polar night and 24 hours in the midnight sun.
+func dayLength(at time.Time, lat float64) time.Duration {
+	const rad = math.Pi / 180
+	declination := -23.44 * rad * math.Cos(2*math.Pi/365*float64(at.UTC().YearDay()+10))
+	cosHourAngle := -math.Tan(lat*rad) * math.Tan(declination)
+	hourAngle := math.Acos(min(max(cosHourAngle, -1), 1)) / rad
+	return time.Duration(2 * hourAngle / 15 * float64(time.Hour))
+}
+
+// conditionOrNA is a summary's condition for the text outputs.
+func (d dailySummary) conditionOrNA() string {
+	if d.Condition == "" {
+		return "N/A"
+	}
+	return d.Condition
+}
+
+// displayForecastDaily prints the forecast as one line per day, for
+// --daily.
+func displayForecastDaily(data *ForecastResponse) {
+	lprintf("5-Day Forecast for %s, %s:\n", data.City.Name, data.City.Country)
+	fmt.Println("------------------------------------")
+	temp, speed := displayUnits.temp.symbol, displayUnits.speed.symbol
+	for _, d := range dailySummariesFrom(data) {
+		lprintf("  %s: %.*f–%.*f%s, %s, Wind: %.*f %s, Pop: %.0f%%, Sun: %.1f h\n",
+			formatDate(d.Date, "2006-01-02 (Mon)"),
+			decimals("temp", 0), showTemp(d.Low), decimals("temp", 0), showTemp(d.High), temp,
+			d.conditionOrNA(),
+			decimals("wind", 1), showSpeed(d.AvgWind), speed,
+			d.MaxPop*100,
+			d.Sunshine,
+		)
+	}
+	fmt.Println("------------------------------------")
+}
+
+// displayForecastDailyPlain is displayForecastDaily for --output plain.
+func displayForecastDailyPlain(data *ForecastResponse) {
+	lprintf("Forecast for %s %s by day.\n", data.City.Name, data.City.Country)
+	for _, d := range dailySummariesFrom(data) {
+		condition := "no conditions reported"
+		if d.Condition != "" {
+			condition = d.Condition
+		}
+		lprintf("%s, %.0f to %.0f degrees, %s, wind %.0f %s on average, up to %.0f percent chance of rain, about %.0f hours of sunshine.\n",
+			formatDate(d.Date, "Monday 2 January"), showTemp(d.Low), showTemp(d.High), condition,
+			showSpeed(d.AvgWind), displayUnits.speedWords, d.MaxPop*100, d.Sunshine)
+	}
+}
+
+// dailyForecastDoc is the --daily document for --output json and yaml, in
+// the --units display units.
+type dailyForecastDoc struct {
+	City    string         `json:"city"`
+	Country string         `json:"country"`
+	Units   unitsV1        `json:"units"`
+	Days    []dailySummary `json:"days"`
+}
+
+func dailyForecastDocFrom(data *ForecastResponse) dailyForecastDoc {
+	days := dailySummariesFrom(data)
+	for i := range days {
+		d := &days[i]
+		d.Low, d.High, d.AvgWind = showTemp(d.Low), showTemp(d.High), showSpeed(d.AvgWind)
+	}
+	return dailyForecastDoc{City: data.City.Name, Country: data.City.Country, Units: unitsV1Now(), Days: days}
+}
+
+// dailyForecastFormats are the --output formats supporting --daily, and
+// their forecast display functions.
+var dailyForecastFormats = map[string]func(*ForecastResponse){
+	"text":  displayForecastDaily,
+	"plain": displayForecastDailyPlain,
+	"json":  func(data *ForecastResponse) { printJSON(dailyForecastDocFrom(data)) },
+	"yaml":  func(data *ForecastResponse) { printYAML(dailyForecastDocFrom(data)) },
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSummarizeDays(t *testing.T) {
+	at := func(day, hour int) time.Time { return time.Date(2026, 10, day, hour, 0, 0, 0, time.Local) }
+	points := []ForecastPoint{
+		{At: at(17, 18), Temp: 14, WindSpeed: 2, Pop: 0.1, Condition: "Clouds"},
+		{At: at(17, 21), Temp: 11, WindSpeed: 4, Pop: 0.4, Condition: "Rain"},
+		{At: at(18, 0), Temp: 9, WindSpeed: 3, Pop: 0.2, Condition: "Rain"},
+		{At: at(18, 3), Temp: 8, WindSpeed: 5, Pop: 0.7, Condition: "Rain"},
+		{At: at(18, 6), Temp: 10, WindSpeed: 1, Pop: 0, Condition: "Clouds"},
+		{At: at(19, 0), Temp: 7, WindSpeed: 6},
+	}
+	want := []dailySummary{
+		{Date: at(17, 0), Low: 11, High: 14, Condition: "Clouds", AvgWind: 3, MaxPop: 0.4, Steps: 2},
+		{Date: at(18, 0), Low: 8, High: 10, Condition: "Rain", AvgWind: 3, MaxPop: 0.7, Steps: 3},
+		{Date: at(19, 0), Low: 7, High: 7, AvgWind: 6, Steps: 1},
+	}
+
+	got := summarizeDays(points, 0, 0)
+	if len(got) != len(want) {
+		t.Fatalf("summarizeDays returned %d days, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if !got[i].Date.Equal(want[i].Date) {
+			t.Errorf("day %d: Date = %v, want %v", i, got[i].Date, want[i].Date)
+		}
+		// Sunshine has its own test below.
+		got[i].Date, got[i].Sunshine = want[i].Date, 0
+		if got[i] != want[i] {
+			t.Errorf("day %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestSummarizeDaysEmpty(t *testing.T) {
+	if got := summarizeDays(nil, 0, 0); len(got) != 0 {
+		t.Errorf("summarizeDays(nil, 0, 0) = %+v, want no days", got)
+	}
+}
+
+func TestSummarizeDaysConditionTie(t *testing.T) {
+	at := time.Date(2026, 10, 17, 12, 0, 0, 0, time.Local)
+	got := summarizeDays([]ForecastPoint{
+		{At: at, Condition: "Rain"},
+		{At: at.Add(3 * time.Hour), Condition: "Clear"},
+	}, 0, 0)
+	if len(got) != 1 || got[0].Condition != "Clear" {
+		t.Errorf("summarizeDays condition = %+v, want one day of Clear", got)
+	}
+}
+
+func TestSunshineHours(t *testing.T) {
+	equinox := time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		at       time.Time
+		clouds   float64
+		lat, lon float64
+		want     float64
+	}{
+		{"clear afternoon on the equator", equinox.Add(12 * time.Hour), 0, 0, 0, 3},
+		{"half cloud", equinox.Add(12 * time.Hour), 50, 0, 0, 1.5},
+		{"overcast", equinox.Add(12 * time.Hour), 100, 0, 0, 0},
+		{"sunrise", equinox.Add(3 * time.Hour), 0, 0, 0, 0},
+		{"night", equinox.Add(21 * time.Hour), 0, 0, 0, 0},
+		{"solar noon follows longitude", equinox.Add(3 * time.Hour), 0, 0, 135, 3},
+		{"polar night", time.Date(2026, 12, 21, 12, 0, 0, 0, time.UTC), 0, 80, 0, 0},
+		{"midnight sun", time.Date(2026, 6, 21, 0, 0, 0, 0, time.UTC), 0, 80, 0, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sunshineHours(tt.at, tt.clouds, tt.lat, tt.lon)
+			if math.Abs(got-tt.want) > 0.01 {
+				t.Errorf("sunshineHours = %.2f, want %.2f", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DaySummaryResponse is One Call API 3.0's aggregate of one day's
+// weather at a location.
+type DaySummaryResponse struct {
+	Lat         float64 `json:"lat"`
+	Lon         float64 `json:"lon"`
+	Date        string  `json:"date"` // YYYY-MM-DD
+	Temperature struct {
+		Min       float64 `json:"min"`
+		Max       float64 `json:"max"`
+		Morning   float64 `json:"morning"`
+		Afternoon float64 `json:"afternoon"`
+		Evening   float64 `json:"evening"`
+		Night     float64 `json:"night"`
+	} `json:"temperature"`
+	Precipitation struct {
+		Total float64 `json:"total"` // mm
+	} `json:"precipitation"`
+	Wind struct {
+		Max struct {
+			Speed     float64 `json:"speed"`
+			Direction float64 `json:"direction"`
+		} `json:"max"`
+	} `json:"wind"`
+}
+
+// GetDaySummary fetches the aggregated weather at coordinates for the
+// local calendar day containing day, in day's time zone. It needs a One
+// Call API 3.0 subscription.
+func GetDaySummary(lat, lon float64, day time.Time, apiKey string) (*DaySummaryResponse, error) {
+	loc := Location{Lat: lat, Lon: lon, HasCoords: true}
+	params := loc.query(apiKey)
+	params.Set("date", day.Format(time.DateOnly))
+	params.Set("tz", day.Format("-07:00"))
+	var data DaySummaryResponse
+	if err := fetchWeatherData(oneCallURL+"/day_summary?"+params.Encode(), &data); err != nil {
+		return nil, planError(err, "the One Call API 3.0 day summary")
+	}
+	return &data, nil
+}
+
+// ruleDayFrom converts a day summary to the rule variables. The average
+// is taken over the four times of day the API reports.
+func ruleDayFrom(data *DaySummaryResponse) ruleDay {
+	t := data.Temperature
+	return ruleDay{
+		TempMin: t.Min,
+		TempMax: t.Max,
+		TempAvg: (t.Morning + t.Afternoon + t.Evening + t.Night) / 4,
+		WindMax: data.Wind.Max.Speed,
+		Rain:    data.Precipitation.Total,
+	}
+}
+
+// daySummaries caches past days' summaries by location and date, since
+// they no longer change and rules are evaluated every daemon tick.
+// noDaySummaries is set once the API key turns out not to include the
+// endpoint, so it isn't asked again.
+var (
+	daySummaries   = map[string]ruleDay{}
+	noDaySummaries bool
+	daySummariesMu sync.Mutex
+)
+
+// summarizeDayAt summarizes location's weather on the local calendar day
+// containing day, from the day_summary endpoint where the API key's plan
+// includes it and otherwise from the observations recorded for location.
+func summarizeDayAt(location string, lat, lon float64, day time.Time, apiKey string) (ruleDay, error) {
+	key := fmt.Sprintf("%s|%s", location, day.Format(time.DateOnly))
+	daySummariesMu.Lock()
+	summary, ok := daySummaries[key]
+	unavailable := noDaySummaries
+	daySummariesMu.Unlock()
+	if ok {
+		return summary, nil
+	}
+
+	apiErr := errors.New("not included in the API key's plan")
+	if !unavailable {
+		var data *DaySummaryResponse
+		data, apiErr = GetDaySummary(lat, lon, day, apiKey)
+		var status *APIError
+		daySummariesMu.Lock()
+		switch {
+		case apiErr == nil:
+			daySummaries[key] = ruleDayFrom(data)
+		case errors.As(apiErr, &status) && status.StatusCode == http.StatusUnauthorized:
+			noDaySummaries = true
+		}
+		daySummariesMu.Unlock()
+		if apiErr == nil {
+			return ruleDayFrom(data), nil
+		}
+	}
+
+	observations, err := readObservations()
+	if err != nil {
+		return ruleDay{}, err
+	}
+	summary = summarizeDay(observations, location, day)
+	if summary.Observations == 0 {
+		return ruleDay{}, fmt.Errorf("no day summary for %s (%v) and no observations of it recorded; add a record task for it", location, apiErr)
+	}
+	return summary, nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultCacheTTL is how long fetched weather is reused by the current and
+// forecast commands, unless --cache-ttl says otherwise.
+const defaultCacheTTL = 10 * time.Minute
+
+// diskCacheEntry is one cached response in the cache directory.
+type diskCacheEntry struct {
+	FetchedAt time.Time       `json:"fetched_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// diskCachePath returns the file caching kind ("current" or "forecast") data
+// for loc. The key covers everything that changes the answer: the
+// location, the providers asked and the language.
+func diskCachePath(kind string, loc Location) (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	key := kind + "\n" + strings.Join(providerChain(), ",") + "\n" + loc.query("").Encode()
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(dir, "responses", hex.EncodeToString(sum[:12])+".json"), nil
+}
+
+// fetchCached fills target with kind data for loc from the disk cache if it
+// was fetched less than ttl ago, and otherwise fetches and caches it. It
+// returns when the data was fetched. A ttl of zero always fetches, leaving
+// the cache alone. Cache errors never fail a fetch.
+func fetchCached(kind string, loc Location, apiKey string, ttl time.Duration, target any) (time.Time, error) {
+	return fetchCachedFunc(kind, loc, ttl, target, func() error { return fetchWithHooks(kind, loc, apiKey, target) })
+}
+
+// fetchCachedFunc is fetchCached with fetch filling target on a miss, so
+// that whatever it adds to the data, such as a place name, is cached too.
+func fetchCachedFunc(kind string, loc Location, ttl time.Duration, target any, fetch func() error) (time.Time, error) {
+	now := time.Now()
+	if ttl <= 0 {
+		return now, fetch()
+	}
+	path, err := diskCachePath(kind, loc)
+	if err != nil {
+		return now, fetch()
+	}
+
+	var entry diskCacheEntry
+	if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &entry) == nil &&
+		now.Sub(entry.FetchedAt) < ttl && json.Unmarshal(entry.Data, target) == nil {
+		return entry.FetchedAt, nil
+	} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring response cache: %v\n", err)
+	}
+
+	if err := fetch(); err != nil {
+		return now, err
+	}
+	if err := writeDiskCache(path, now, target); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to cache response: %v\n", err)
+	}
+	return now, nil
+}
+
+func writeDiskCache(path string, fetchedAt time.Time, data any) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(diskCacheEntry{FetchedAt: fetchedAt, Data: raw})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	// Write then rename, so a script running alongside never reads half a
+	// file.
+	tmp, err := os.CreateTemp(filepath.Dir(path), "response-*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(encoded); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// dataAge describes how long ago cached data was fetched, for the text
+// outputs.
+func dataAge(fetchedAt time.Time) string {
+	age := time.Since(fetchedAt)
+	switch {
+	case age < time.Minute:
+		return "Cached data from less than a minute ago (--no-cache to refresh)"
+	case age < 2*time.Minute:
+		return "Cached data from 1 minute ago (--no-cache to refresh)"
+	}
+	return lsprintf("Cached data from %d minutes ago (--no-cache to refresh)", int(age/time.Minute))
+}
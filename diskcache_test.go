@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFetchCachedFunc(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	loc := Location{Lat: -1.29, Lon: 36.82, HasCoords: true}
+	fetches := 0
+	fetch := func(target *CurrentWeatherResponse) func() error {
+		return func() error {
+			fetches++
+			target.Name = "near Nairobi"
+			return nil
+		}
+	}
+
+	var first CurrentWeatherResponse
+	if _, err := fetchCachedFunc("current", loc, time.Minute, &first, fetch(&first)); err != nil {
+		t.Fatal(err)
+	}
+	// A hit reads what the fetch made of the data, without fetching.
+	var second CurrentWeatherResponse
+	fetchedAt, err := fetchCachedFunc("current", loc, time.Minute, &second, fetch(&second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetches != 1 || second.Name != "near Nairobi" {
+		t.Errorf("after a hit: %d fetches, name %q; want 1, near Nairobi", fetches, second.Name)
+	}
+	if time.Since(fetchedAt) > time.Minute {
+		t.Errorf("fetchedAt = %s, want the first fetch", fetchedAt)
+	}
+
+	// Without a ttl the cache is left alone, and errors come through.
+	failure := errors.New("upstream down")
+	var third CurrentWeatherResponse
+	if _, err := fetchCachedFunc("current", loc, 0, &third, func() error { return failure }); !errors.Is(err, failure) {
+		t.Errorf("error = %v, want %v", err, failure)
+	}
+}
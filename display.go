@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// displayCurrentWeather prints the current weather report.
+func displayCurrentWeather(data *CurrentWeatherResponse) {
+	lprintf("Current Weather for %s, %s:\n", data.Name, data.Sys.Country)
+	temp, speed := displayUnits.temp.symbol, displayUnits.speed.symbol
+	lprintf("  Temperature: %.*f%s (Feels like: %.*f%s)\n", decimals("temp", 1), showTemp(data.Main.Temp), temp, decimals("temp", 1), showTemp(data.Main.FeelsLike), temp)
+	if len(data.Weather) > 0 {
+		lprintf("  Conditions: %s (%s)\n", data.Weather[0].Main, data.Weather[0].Description)
+	} else {
+		fmt.Println("  Conditions: N/A")
+	}
+	lprintf("  Humidity: %d%%\n", data.Main.Humidity)
+	if data.Main.Temp >= humidexMinTemp {
+		h := humidex(data.Main.Temp, float64(data.Main.Humidity))
+		if level := humidexLevel(h); level != "" {
+			lprintf("  Humidex: %.0f (%s)\n", h, level)
+		} else {
+			lprintf("  Humidex: %.0f\n", h)
+		}
+	}
+	lprintf("  Wet-bulb: %.*f%s\n", decimals("temp", 1), showTemp(wetBulb(data.Main.Temp, float64(data.Main.Humidity), data.Main.StationPressure())), temp)
+	lprintf("  Wind: %.*f %s\n", decimals("wind", 1), showSpeed(data.Wind.Speed), speed)
+	lprintf("  Pressure: %d hPa", data.Main.Pressure)
+	if data.Main.SeaLevel > 0 && data.Main.GrndLevel > 0 {
+		lprintf(" (sea level %d hPa, ground level %d hPa)", data.Main.SeaLevel, data.Main.GrndLevel)
+	}
+	fmt.Println()
+	lprintf("  Cloudiness: %d%%\n", data.Clouds.All)
+	lprintf("  Visibility: %s (%s)\n", formatVisibility(data.Visibility, displayUnits), visibilityClass(data.Visibility, data.Main.Humidity))
+	lprintf("  Sunrise: %s\n", time.Unix(data.Sys.Sunrise, 0).Local().Format("15:04"))
+	lprintf("  Sunset: %s\n", time.Unix(data.Sys.Sunset, 0).Local().Format("15:04"))
+	fmt.Println("------------------------------------")
+}
+
+// groupForecastByDay buckets forecast entries by local calendar day and
+// returns the day labels in chronological order.
+func groupForecastByDay(data *ForecastResponse) ([]string, map[string][]ForecastListEntry) {
+	dailyForecasts := make(map[string][]ForecastListEntry)
+	for _, entry := range data.List {
+		date := formatDate(time.Unix(entry.Dt, 0).Local(), "2006-01-02 (Mon)")
+		dailyForecasts[date] = append(dailyForecasts[date], entry)
+	}
+
+	var dates []string
+	for date := range dailyForecasts {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	return dates, dailyForecasts
+}
+
+// displayForecast prints the 5-day / 3-hour forecast details.
+func displayForecast(data *ForecastResponse) {
+	lprintf("5-Day / 3-Hour Forecast for %s, %s:\n", data.City.Name, data.City.Country)
+	fmt.Println("------------------------------------")
+
+	dates, dailyForecasts := groupForecastByDay(data)
+	temp, speed := displayUnits.temp.symbol, displayUnits.speed.symbol
+
+	for _, date := range dates {
+		lprintf("\nDate: %s\n", date)
+		for _, entry := range dailyForecasts[date] {
+			forecastTime := time.Unix(entry.Dt, 0).Local().Format("15:04")
+
+			// --- FIX STARTS HERE ---
+			var mainWeather, descWeather string
+			if len(entry.Weather) > 0 {
+				mainWeather = entry.Weather[0].Main
+				descWeather = entry.Weather[0].Description
+			} else {
+				// Provide default values if weather array is empty
+				mainWeather = "N/A"
+				descWeather = "No specific conditions"
+			}
+			// --- FIX ENDS HERE ---
+
+			lprintf("  %s: Temp: %.*f%s, Feels: %.*f%s, Cond: %s (%s), Wind: %.*f %s, Pop: %.0f%%\n",
+				forecastTime,
+				decimals("temp", 1), showTemp(entry.Main.Temp), temp,
+				decimals("temp", 1), showTemp(entry.Main.FeelsLike), temp,
+				mainWeather, // Use the checked variable
+				descWeather, // Use the checked variable
+				decimals("wind", 1), showSpeed(entry.Wind.Speed), speed,
+				entry.Pop*100,
+			)
+		}
+	}
+	fmt.Println("------------------------------------")
+}
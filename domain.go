@@ -0,0 +1,132 @@
+package main
+
+import "time"
+
+// The types in this file are the tool's own view of the weather, free of
+// any provider's wire format. Every provider answers in OpenWeatherMap's
+// JSON, the plugin contract, and the functions here map that into them;
+// alert rules, the history store and renderers that don't need a
+// particular API's extras work with these types instead.
+
+// Observation is the weather at a location at one time, as recorded in
+// the history store.
+type Observation struct {
+	Location  string    `json:"location"`
+	At        time.Time `json:"at"`
+	Temp      float64   `json:"temp"`
+	FeelsLike float64   `json:"feels_like"`
+	Humidity  int       `json:"humidity"`
+	Pressure  int       `json:"pressure"`
+	WindSpeed float64   `json:"wind_speed"`
+	Clouds    int       `json:"clouds"`
+	Condition string    `json:"condition"`
+	Rain      float64   `json:"rain,omitempty"` // mm in the hour before At
+}
+
+// observationFrom converts a current-weather response into an Observation
+// recorded under name.
+func observationFrom(name string, data *CurrentWeatherResponse) Observation {
+	obs := Observation{
+		Location:  name,
+		At:        time.Unix(data.Dt, 0).UTC(),
+		Temp:      data.Main.Temp,
+		FeelsLike: data.Main.FeelsLike,
+		Humidity:  data.Main.Humidity,
+		Pressure:  data.Main.Pressure,
+		WindSpeed: data.Wind.Speed,
+		Clouds:    data.Clouds.All,
+		Rain:      data.Rain.OneHour,
+	}
+	if len(data.Weather) > 0 {
+		obs.Condition = data.Weather[0].Main
+	}
+	return obs
+}
+
+// ForecastPoint is the forecast weather for one time.
+type ForecastPoint struct {
+	At          time.Time `json:"at"`
+	Temp        float64   `json:"temp"`
+	FeelsLike   float64   `json:"feels_like"`
+	TempMin     float64   `json:"temp_min"`
+	TempMax     float64   `json:"temp_max"`
+	Humidity    float64   `json:"humidity"`
+	Pressure    float64   `json:"pressure"`
+	Clouds      float64   `json:"clouds"`
+	Visibility  float64   `json:"visibility"` // metres
+	WindSpeed   float64   `json:"wind_speed"`
+	WindGust    float64   `json:"wind_gust"`
+	WindDeg     float64   `json:"wind_deg"` // direction the wind blows from
+	Pop         float64   `json:"pop"`      // probability of precipitation, 0 to 1
+	Condition   string    `json:"condition"`
+	Description string    `json:"description"`
+	// Precipitation is the rain and snow in mm expected in the forecast
+	// step starting at At.
+	Precipitation float64 `json:"precipitation,omitempty"`
+}
+
+// forecastPointFrom converts one forecast entry.
+func forecastPointFrom(e ForecastListEntry) ForecastPoint {
+	p := ForecastPoint{
+		At:            time.Unix(e.Dt, 0),
+		Temp:          e.Main.Temp,
+		FeelsLike:     e.Main.FeelsLike,
+		TempMin:       e.Main.TempMin,
+		TempMax:       e.Main.TempMax,
+		Humidity:      float64(e.Main.Humidity),
+		Pressure:      float64(e.Main.Pressure),
+		Clouds:        float64(e.Clouds.All),
+		Visibility:    float64(e.Visibility),
+		WindSpeed:     e.Wind.Speed,
+		WindGust:      e.Wind.Gust,
+		WindDeg:       float64(e.Wind.Deg),
+		Pop:           e.Pop,
+		Precipitation: e.Rain.ThreeHours + e.Snow.ThreeHours,
+	}
+	if len(e.Weather) > 0 {
+		p.Condition, p.Description = e.Weather[0].Main, e.Weather[0].Description
+	}
+	return p
+}
+
+// forecastPointsFrom converts a forecast response, oldest first.
+func forecastPointsFrom(data *ForecastResponse) []ForecastPoint {
+	points := make([]ForecastPoint, len(data.List))
+	for i, e := range data.List {
+		points[i] = forecastPointFrom(e)
+	}
+	return points
+}
+
+// Alert is an official weather warning.
+type Alert struct {
+	Sender      string    `json:"sender"`
+	Event       string    `json:"event"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end,omitzero"` // zero when open-ended
+	Description string    `json:"description"`
+	// Severity, Certainty and Urgency are the CAP gradings, e.g. "severe",
+	// "likely" and "expected", where the issuer gives them.
+	Severity   string   `json:"severity,omitempty"`
+	Certainty  string   `json:"certainty,omitempty"`
+	Urgency    string   `json:"urgency,omitempty"`
+	Categories []string `json:"categories,omitempty"`
+}
+
+// alertFrom converts a One Call alert.
+func alertFrom(a WeatherAlert) Alert {
+	alert := Alert{
+		Sender:      a.SenderName,
+		Event:       a.Event,
+		Start:       time.Unix(a.Start, 0),
+		Description: a.Description,
+		Severity:    a.severity(),
+		Certainty:   a.certainty(),
+		Urgency:     a.urgency(),
+		Categories:  a.categories(),
+	}
+	if a.End != 0 {
+		alert.End = time.Unix(a.End, 0)
+	}
+	return alert
+}
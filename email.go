@@ -0,0 +1,426 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// emailTimeout bounds a whole SMTP session, from connecting to QUIT.
+const emailTimeout = 30 * time.Second
+
+// defaultEmailSubject is the subject template used when none is configured.
+const defaultEmailSubject = "{{.Title}}"
+
+// EmailConfig configures the built-in email channel from the "email"
+// section of the config file.
+type EmailConfig struct {
+	Host string `json:"host"`
+	// Port defaults to 465 with "tls" security and 587 otherwise.
+	Port int `json:"port,omitempty"`
+	// Security is "starttls" (the default), which refuses servers that
+	// don't offer it, "tls" for implicit TLS, or "none".
+	Security string `json:"security,omitempty"`
+	Username string `json:"username,omitempty"`
+	// Password is used with Username; the SMTP_PASSWORD environment
+	// variable wins over it.
+	Password string `json:"password,omitempty"`
+	From     string `json:"from"`
+	// Subject is a text/template executed with the Message, e.g.
+	// "[{{.Severity}}] {{.Title}}"; it defaults to the title.
+	Subject    string           `json:"subject,omitempty"`
+	Recipients []EmailRecipient `json:"recipients"`
+}
+
+// EmailRecipient is one address the email channel sends to, with its own
+// schedule, filters and subject.
+type EmailRecipient struct {
+	Address string `json:"address"`
+	// Schedule is a cron expression, e.g. "30 6 * * *". Messages for a
+	// recipient with a schedule are queued and sent together once the
+	// schedule comes round, except those Immediate lets through.
+	Schedule string `json:"schedule,omitempty"`
+	// Immediate is the least severe alert that skips the schedule:
+	// "warning", "severe" (the default) or "extreme". Severe weather
+	// always does.
+	Immediate string `json:"immediate,omitempty"`
+	// MinSeverity is the least severe alert sent at all, as for
+	// NotifyRoute. Messages without a severity, such as digests, are
+	// always sent.
+	MinSeverity string `json:"min_severity,omitempty"`
+	// Subject replaces the channel's subject template for this recipient.
+	Subject string `json:"subject,omitempty"`
+}
+
+// EmailNotifier delivers messages by SMTP to each configured recipient,
+// sending them right away or queueing them for the recipient's schedule.
+type EmailNotifier struct {
+	config EmailConfig
+	// sender is the bare address of From, for the SMTP envelope.
+	sender     string
+	recipients []emailRecipient
+}
+
+// emailRecipient is an EmailRecipient with its settings checked.
+type emailRecipient struct {
+	EmailRecipient
+	schedule cron.Schedule // nil without a schedule
+	subject  *template.Template
+}
+
+// newEmailNotifierFromConfig builds an EmailNotifier from the "email"
+// section of the config file.
+func newEmailNotifierFromConfig() (Notifier, error) {
+	n, err := newEmailNotifier(config.Email)
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// newEmailNotifier checks c and builds an EmailNotifier from it.
+func newEmailNotifier(c EmailConfig) (*EmailNotifier, error) {
+	if password := os.Getenv("SMTP_PASSWORD"); password != "" {
+		c.Password = password
+	}
+	if c.Host == "" || c.From == "" || len(c.Recipients) == 0 {
+		return nil, fmt.Errorf(`"host", "from" and "recipients" must all be set in the "email" section of the config file`)
+	}
+	switch c.Security {
+	case "":
+		c.Security = "starttls"
+	case "starttls", "tls", "none":
+	default:
+		return nil, fmt.Errorf("invalid security %q, use starttls, tls or none", c.Security)
+	}
+	if c.Port == 0 {
+		c.Port = 587
+		if c.Security == "tls" {
+			c.Port = 465
+		}
+	}
+
+	from, err := mail.ParseAddress(c.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address %q: %w", c.From, err)
+	}
+
+	// Re-encoded, so that a display name outside ASCII is valid in the
+	// header.
+	c.From = from.String()
+	n := &EmailNotifier{config: c, sender: from.Address}
+	for _, r := range c.Recipients {
+		if _, err := mail.ParseAddress(r.Address); err != nil {
+			return nil, fmt.Errorf("invalid recipient address %q: %w", r.Address, err)
+		}
+		recipient := emailRecipient{EmailRecipient: r}
+		if r.Schedule != "" {
+			recipient.schedule, err = cron.ParseStandard(r.Schedule)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid schedule %q: %w", r.Address, r.Schedule, err)
+			}
+		}
+		if r.Immediate == "" {
+			recipient.Immediate = severitySevere
+		}
+		if r.MinSeverity == "" {
+			recipient.MinSeverity = severityWarning
+		}
+		for _, level := range []string{recipient.Immediate, recipient.MinSeverity} {
+			if !slices.Contains(alertSeverities, level) {
+				return nil, fmt.Errorf("%s: invalid severity %q, use warning, severe or extreme", r.Address, level)
+			}
+		}
+		subject := cmp.Or(r.Subject, c.Subject, defaultEmailSubject)
+		recipient.subject, err = template.New(r.Address).Option("missingkey=error").Parse(subject)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid subject template: %w", r.Address, err)
+		}
+		n.recipients = append(n.recipients, recipient)
+	}
+	return n, nil
+}
+
+// skips reports whether msg is below the recipient's minimum severity.
+func (r emailRecipient) skips(msg Message) bool {
+	return msg.Severity != severityNone && slices.Index(alertSeverities, msg.Severity) < slices.Index(alertSeverities, r.MinSeverity)
+}
+
+// queues reports whether msg waits for the recipient's schedule.
+func (r emailRecipient) queues(msg Message) bool {
+	if r.schedule == nil || msg.Severe {
+		return false
+	}
+	return msg.Severity == severityNone || slices.Index(alertSeverities, msg.Severity) < slices.Index(alertSeverities, r.Immediate)
+}
+
+// Notify sends msg to every recipient it is for, queueing it for those
+// whose schedule holds it, then sends any queued messages that are due. A
+// failure for one recipient doesn't stop the others.
+func (n *EmailNotifier) Notify(msg Message) error {
+	now := time.Now()
+	var errs error
+	for _, r := range n.recipients {
+		switch {
+		case r.skips(msg):
+		case r.queues(msg):
+			errs = errors.Join(errs, enqueueEmail(r.Address, msg, now))
+		default:
+			errs = errors.Join(errs, n.send(r, msg))
+		}
+	}
+	for _, r := range n.recipients {
+		if r.schedule != nil {
+			errs = errors.Join(errs, n.flush(r, now))
+		}
+	}
+	return errs
+}
+
+// send renders msg for r and delivers it.
+func (n *EmailNotifier) send(r emailRecipient, msg Message) error {
+	var subject strings.Builder
+	if err := r.subject.Execute(&subject, msg); err != nil {
+		return fmt.Errorf("%s: rendering subject: %w", r.Address, err)
+	}
+	data := composeEmail(n.config.From, r.Address, strings.TrimSpace(subject.String()), msg, time.Now())
+	if err := n.deliver(r.Address, data); err != nil {
+		return fmt.Errorf("sending to %s: %w", r.Address, err)
+	}
+	return nil
+}
+
+// composeEmail builds a plain-text email of msg, one part per line.
+func composeEmail(from, to, subject string, msg Message, date time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	w := quotedprintable.NewWriter(&b)
+	for _, part := range msg.Parts {
+		fmt.Fprintf(w, "%s\r\n", part)
+	}
+	w.Close()
+	return b.Bytes()
+}
+
+// deliver sends data to one address in its own SMTP session.
+func (n *EmailNotifier) deliver(to string, data []byte) error {
+	c := n.config
+	addr := net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+	tlsConfig := &tls.Config{ServerName: c.Host}
+	var conn net.Conn
+	var err error
+	if c.Security == "tls" {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: emailTimeout}, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = net.DialTimeout("tcp", addr, emailTimeout)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(emailTimeout))
+	client, err := smtp.NewClient(conn, c.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if c.Security == "starttls" {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("%s doesn't offer STARTTLS; set \"security\" to tls or none", c.Host)
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if c.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", c.Username, c.Password, c.Host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(n.sender); err != nil {
+		return err
+	}
+	rcpt, err := mail.ParseAddress(to)
+	if err != nil {
+		return err
+	}
+	if err := client.Rcpt(rcpt.Address); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// queuedMessage is a notification held back for later delivery.
+type queuedMessage struct {
+	At    time.Time `json:"at"`
+	Title string    `json:"title"`
+	Body  string    `json:"body"`
+}
+
+// readMessageQueue reads a file of held messages, keyed by where they are
+// going; what names the queue in errors.
+func readMessageQueue(path, what string) (map[string][]queuedMessage, error) {
+	queue := make(map[string][]queuedMessage)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return queue, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", what, err)
+	}
+	if err := json.Unmarshal(data, &queue); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return queue, nil
+}
+
+func writeMessageQueue(path, what string, queue map[string][]queuedMessage) error {
+	data, err := json.MarshalIndent(queue, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", what, err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", what, err)
+	}
+	return nil
+}
+
+// emailQueueMu serializes access to the email queue file.
+var emailQueueMu sync.Mutex
+
+func emailQueuePath() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "email-queue.json"), nil
+}
+
+func loadEmailQueue() (map[string][]queuedMessage, error) {
+	path, err := emailQueuePath()
+	if err != nil {
+		return nil, err
+	}
+	return readMessageQueue(path, "email queue")
+}
+
+func saveEmailQueue(queue map[string][]queuedMessage) error {
+	path, err := emailQueuePath()
+	if err != nil {
+		return err
+	}
+	return writeMessageQueue(path, "email queue", queue)
+}
+
+// enqueueEmail holds msg for address until its next scheduled delivery.
+func enqueueEmail(address string, msg Message, at time.Time) error {
+	emailQueueMu.Lock()
+	defer emailQueueMu.Unlock()
+	queue, err := loadEmailQueue()
+	if err != nil {
+		return err
+	}
+	queue[address] = append(queue[address], queuedMessage{At: at, Title: msg.Title, Body: msg.Body()})
+	return saveEmailQueue(queue)
+}
+
+// takeDueEmails removes and returns the messages queued for r, if its
+// schedule has come round since the oldest of them was queued.
+func takeDueEmails(r emailRecipient, now time.Time) ([]queuedMessage, error) {
+	emailQueueMu.Lock()
+	defer emailQueueMu.Unlock()
+	queue, err := loadEmailQueue()
+	if err != nil {
+		return nil, err
+	}
+	queued := queue[r.Address]
+	if len(queued) == 0 || r.schedule.Next(queued[0].At).After(now) {
+		return nil, nil
+	}
+	delete(queue, r.Address)
+	return queued, saveEmailQueue(queue)
+}
+
+// requeueEmails puts messages that failed to send back at the front of
+// address's queue.
+func requeueEmails(address string, msgs []queuedMessage) error {
+	emailQueueMu.Lock()
+	defer emailQueueMu.Unlock()
+	queue, err := loadEmailQueue()
+	if err != nil {
+		return err
+	}
+	queue[address] = append(msgs, queue[address]...)
+	return saveEmailQueue(queue)
+}
+
+// flushEmailQueue sends address what is queued for it, as one email. The
+// daemon calls it on the recipient's schedule.
+func flushEmailQueue(address string) error {
+	n, err := newEmailNotifier(config.Email)
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(n.recipients, func(r emailRecipient) bool { return r.Address == address })
+	if i < 0 {
+		return nil
+	}
+	return n.flush(n.recipients[i], time.Now())
+}
+
+// flush sends r everything queued for it as one email once its schedule
+// is due. The queue isn't locked while sending; a failed batch goes back
+// in the queue for the next attempt.
+func (n *EmailNotifier) flush(r emailRecipient, now time.Time) error {
+	queued, err := takeDueEmails(r, now)
+	if err != nil || len(queued) == 0 {
+		return err
+	}
+	batch := Message{Title: fmt.Sprintf("%d weather notifications", len(queued))}
+	if len(queued) == 1 {
+		batch.Title = queued[0].Title
+	}
+	for _, m := range queued {
+		batch.Parts = append(batch.Parts, fmt.Sprintf("%s %s: %s", m.At.Local().Format("Mon 15:04"), m.Title, m.Body))
+	}
+	if err := n.send(r, batch); err != nil {
+		return errors.Join(err, requeueEmails(r.Address, queued))
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestComposeEmail(t *testing.T) {
+	date := time.Date(2026, time.March, 2, 6, 30, 0, 0, time.UTC)
+	msg := Message{Title: "Zürich, CH", Parts: []string{"12°C light rain", "wind 4 m/s"}}
+	got := string(composeEmail("weather@example.com", "me@example.com", "Zürich, CH", msg, date))
+	want := "From: weather@example.com\r\n" +
+		"To: me@example.com\r\n" +
+		"Subject: =?utf-8?q?Z=C3=BCrich,_CH?=\r\n" +
+		"Date: Mon, 02 Mar 2026 06:30:00 +0000\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"Content-Transfer-Encoding: quoted-printable\r\n\r\n" +
+		"12=C2=B0C light rain\r\n" +
+		"wind 4 m/s\r\n"
+	if got != want {
+		t.Errorf("composeEmail =\n%q\nwant\n%q", got, want)
+	}
+
+	injected := string(composeEmail("a@example.com", "b@example.com", "Hi\r\nBcc: c@example.com", msg, date))
+	if strings.Contains(injected, "\r\nBcc:") {
+		t.Errorf("composeEmail let a header through the subject:\n%s", injected)
+	}
+}
+
+func TestEmailRecipientRouting(t *testing.T) {
+	n, err := newEmailNotifier(EmailConfig{
+		Host: "smtp.example.com",
+		From: "weather@example.com",
+		Recipients: []EmailRecipient{
+			{Address: "now@example.com"},
+			{Address: "morning@example.com", Schedule: "30 6 * * *"},
+			{Address: "storms@example.com", MinSeverity: severitySevere},
+			{Address: "quiet@example.com", Schedule: "0 18 * * *", Immediate: severityExtreme},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		msg  Message
+		// want is what happens for each recipient: "send", "queue" or
+		// "skip".
+		want []string
+	}{
+		{"digest", Message{Title: "Nairobi"}, []string{"send", "queue", "send", "queue"}},
+		{"warning", Message{Severity: severityWarning}, []string{"send", "queue", "skip", "queue"}},
+		{"severe alert", Message{Severity: severitySevere}, []string{"send", "send", "send", "queue"}},
+		{"severe weather", Message{Severe: true}, []string{"send", "send", "send", "send"}},
+		{"extreme alert", Message{Severity: severityExtreme, Severe: true}, []string{"send", "send", "send", "send"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, r := range n.recipients {
+				got := "send"
+				switch {
+				case r.skips(tt.msg):
+					got = "skip"
+				case r.queues(tt.msg):
+					got = "queue"
+				}
+				if got != tt.want[i] {
+					t.Errorf("%s: %s, want %s", r.Address, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestEmailQueueFlushesWhenDue(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	n, err := newEmailNotifier(EmailConfig{
+		Host:       "smtp.example.com",
+		From:       "weather@example.com",
+		Recipients: []EmailRecipient{{Address: "me@example.com", Schedule: "30 6 * * *"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := n.recipients[0]
+	queuedAt := time.Date(2026, time.March, 1, 20, 0, 0, 0, time.Local)
+	if err := enqueueEmail(r.Address, Message{Title: "Nairobi", Parts: []string{"light rain"}}, queuedAt); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		now  time.Time
+		want int
+	}{
+		{queuedAt.Add(time.Hour), 0},
+		{time.Date(2026, time.March, 2, 6, 29, 0, 0, time.Local), 0},
+		{time.Date(2026, time.March, 2, 6, 30, 0, 0, time.Local), 1},
+		{time.Date(2026, time.March, 3, 6, 30, 0, 0, time.Local), 0},
+	}
+	for _, tt := range tests {
+		got, err := takeDueEmails(r, tt.now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != tt.want {
+			t.Errorf("at %s: took %d messages, want %d", tt.now.Format(time.DateTime), len(got), tt.want)
+		}
+	}
+}
+
+func TestEmailQueueKeepsFailedBatch(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	// A port nothing listens on, so sending fails.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+	n, err := newEmailNotifier(EmailConfig{
+		Host:       "127.0.0.1",
+		Port:       port,
+		Security:   "none",
+		From:       "weather@example.com",
+		Recipients: []EmailRecipient{{Address: "me@example.com", Schedule: "30 6 * * *"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := n.recipients[0]
+	queuedAt := time.Date(2026, time.March, 1, 20, 0, 0, 0, time.Local)
+	if err := enqueueEmail(r.Address, Message{Title: "Nairobi"}, queuedAt); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.flush(r, time.Date(2026, time.March, 2, 7, 0, 0, 0, time.Local)); err == nil {
+		t.Fatal("flush succeeded without a server")
+	}
+	queue, err := loadEmailQueue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := queue[r.Address]; len(got) != 1 || got[0].Title != "Nairobi" {
+		t.Errorf("queue after a failed send = %+v, want the batch back", got)
+	}
+}
+
+func TestNewEmailNotifier(t *testing.T) {
+	base := func() EmailConfig {
+		return EmailConfig{Host: "smtp.example.com", From: "weather@example.com", Recipients: []EmailRecipient{{Address: "me@example.com"}}}
+	}
+	tests := []struct {
+		name     string
+		change   func(*EmailConfig)
+		wantPort int
+		wantErr  string
+	}{
+		{"starttls by default", func(c *EmailConfig) {}, 587, ""},
+		{"implicit tls", func(c *EmailConfig) { c.Security = "tls" }, 465, ""},
+		{"explicit port", func(c *EmailConfig) { c.Security = "none"; c.Port = 25 }, 25, ""},
+		{"no host", func(c *EmailConfig) { c.Host = "" }, 0, `"host", "from" and "recipients"`},
+		{"bad security", func(c *EmailConfig) { c.Security = "ssl" }, 0, `invalid security "ssl"`},
+		{"bad schedule", func(c *EmailConfig) { c.Recipients[0].Schedule = "06:30" }, 0, `invalid schedule "06:30"`},
+		{"bad severity", func(c *EmailConfig) { c.Recipients[0].Immediate = "urgent" }, 0, `invalid severity "urgent"`},
+		{"bad subject", func(c *EmailConfig) { c.Subject = "{{.Title" }, 0, "invalid subject template"},
+		{"named from", func(c *EmailConfig) { c.From = "Weather <weather@example.com>" }, 587, ""},
+		{"bad from", func(c *EmailConfig) { c.From = "weather" }, 0, `invalid from address "weather"`},
+		{"bad recipient", func(c *EmailConfig) { c.Recipients[0].Address = "" }, 0, `invalid recipient address ""`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := base()
+			tt.change(&c)
+			n, err := newEmailNotifier(c)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("newEmailNotifier error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n.config.Port != tt.wantPort {
+				t.Errorf("port = %d, want %d", n.config.Port, tt.wantPort)
+			}
+		})
+	}
+}
+
+// fakeSMTP serves one SMTP session on a local port and returns its port
+// and a channel receiving the commands and message data it was sent.
+func fakeSMTP(t *testing.T) (int, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	session := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var got strings.Builder
+		r := bufio.NewReader(conn)
+		fmt.Fprint(conn, "220 localhost ready\r\n")
+		for inData := false; ; {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				break
+			}
+			got.WriteString(line)
+			switch {
+			case inData:
+				if line == ".\r\n" {
+					inData = false
+					fmt.Fprint(conn, "250 queued\r\n")
+				}
+			case strings.HasPrefix(line, "EHLO"):
+				fmt.Fprint(conn, "250-localhost\r\n250 AUTH PLAIN\r\n")
+			case strings.HasPrefix(line, "DATA"):
+				inData = true
+				fmt.Fprint(conn, "354 go ahead\r\n")
+			case strings.HasPrefix(line, "AUTH"):
+				fmt.Fprint(conn, "235 ok\r\n")
+			case strings.HasPrefix(line, "QUIT"):
+				fmt.Fprint(conn, "221 bye\r\n")
+				session <- got.String()
+				return
+			default:
+				fmt.Fprint(conn, "250 ok\r\n")
+			}
+		}
+		session <- got.String()
+	}()
+	return ln.Addr().(*net.TCPAddr).Port, session
+}
+
+func TestEmailNotifierSends(t *testing.T) {
+	port, session := fakeSMTP(t)
+	n, err := newEmailNotifier(EmailConfig{
+		Host:       "localhost",
+		Port:       port,
+		Security:   "none",
+		Username:   "weather",
+		Password:   "secret",
+		From:       "Weather <weather@example.com>",
+		Subject:    "[{{.Severity}}] {{.Title}}",
+		Recipients: []EmailRecipient{{Address: "Me <me@example.com>"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Notify(Message{Title: "Nairobi", Parts: []string{"thunderstorm"}, Severity: severitySevere}); err != nil {
+		t.Fatal(err)
+	}
+	got := <-session
+	for _, want := range []string{
+		"AUTH PLAIN ",
+		"MAIL FROM:<weather@example.com>",
+		"RCPT TO:<me@example.com>",
+		"From: \"Weather\" <weather@example.com>\r\n",
+		"To: Me <me@example.com>\r\n",
+		"Subject: [severe] Nairobi\r\n",
+		"\r\nthunderstorm\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("session lacks %q:\n%s", want, got)
+		}
+	}
+}
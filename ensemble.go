@@ -0,0 +1,270 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const ensembleURL = "https://ensemble-api.open-meteo.com/v1/ensemble"
+
+// defaultEnsembleModel is the Open-Meteo ensemble used unless --model says
+// otherwise: ECMWF's 51-member global ensemble.
+const defaultEnsembleModel = "ecmwf_ifs025"
+
+// ensembleRainThreshold is the precipitation in mm over a 6-hour block
+// that counts as rain for a member.
+const ensembleRainThreshold = 0.2
+
+// EnsembleResponse is an Open-Meteo ensemble forecast. Hourly holds the
+// "time" array and one array per member and variable, named like
+// "temperature_2m" for the control run and "temperature_2m_member01" for
+// the others.
+type EnsembleResponse struct {
+	Latitude  float64                    `json:"latitude"`
+	Longitude float64                    `json:"longitude"`
+	Timezone  string                     `json:"timezone"`
+	Hourly    map[string]json.RawMessage `json:"hourly"`
+}
+
+// EnsembleDay is the spread of the ensemble members' forecasts for one
+// local day: the 10th to 90th percentiles of the members' highs and lows,
+// and the lowest and highest chance of rain over the day's 6-hour blocks.
+type EnsembleDay struct {
+	Date     string     `json:"date"`
+	Members  int        `json:"members"`
+	High     [2]float64 `json:"high"`
+	Low      [2]float64 `json:"low"`
+	RainProb [2]float64 `json:"rain_probability"`
+}
+
+// GetEnsemble fetches the hourly temperature and precipitation of every
+// member of an Open-Meteo ensemble model for the next days days. It needs
+// no API key.
+func GetEnsemble(lat, lon float64, model string, days int) (*EnsembleResponse, error) {
+	params := url.Values{}
+	params.Set("latitude", strconv.FormatFloat(lat, 'f', 4, 64))
+	params.Set("longitude", strconv.FormatFloat(lon, 'f', 4, 64))
+	params.Set("hourly", "temperature_2m,precipitation")
+	params.Set("models", model)
+	params.Set("forecast_days", strconv.Itoa(days))
+	params.Set("timezone", "auto")
+	var data EnsembleResponse
+	if err := fetchWeatherData(ensembleURL+"?"+params.Encode(), &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// members returns each member's hourly values of variable; nulls, for
+// hours a member doesn't cover, are NaN.
+func (r *EnsembleResponse) members(variable string) ([][]float64, error) {
+	var members [][]float64
+	for _, key := range sortedKeys(r.Hourly) {
+		if key != variable && !strings.HasPrefix(key, variable+"_member") {
+			continue
+		}
+		var raw []*float64
+		if err := json.Unmarshal(r.Hourly[key], &raw); err != nil {
+			return nil, fmt.Errorf("failed to parse ensemble %s: %w", key, err)
+		}
+		values := make([]float64, len(raw))
+		for i, v := range raw {
+			values[i] = math.NaN()
+			if v != nil {
+				values[i] = *v
+			}
+		}
+		members = append(members, values)
+	}
+	return members, nil
+}
+
+// percentile returns the p-th percentile (0–100) of sorted values,
+// interpolating between the nearest ranks.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	i := int(rank)
+	if i+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[i] + (sorted[i+1]-sorted[i])*(rank-float64(i))
+}
+
+// ensembleDays summarizes an ensemble forecast per local day.
+func ensembleDays(data *EnsembleResponse) ([]EnsembleDay, error) {
+	var times []string
+	if err := json.Unmarshal(data.Hourly["time"], &times); err != nil {
+		return nil, fmt.Errorf("failed to parse ensemble times: %w", err)
+	}
+	temps, err := data.members("temperature_2m")
+	if err != nil {
+		return nil, err
+	}
+	precip, err := data.members("precipitation")
+	if err != nil {
+		return nil, err
+	}
+	if len(temps) == 0 {
+		return nil, fmt.Errorf("the ensemble response has no temperatures")
+	}
+
+	// hours maps each date to its hour indexes, in order.
+	var dates []string
+	hours := make(map[string][]int)
+	for i, t := range times {
+		date, _, _ := strings.Cut(t, "T")
+		if _, ok := hours[date]; !ok {
+			dates = append(dates, date)
+		}
+		hours[date] = append(hours[date], i)
+	}
+
+	var days []EnsembleDay
+	for _, date := range dates {
+		var highs, lows []float64
+		for _, member := range temps {
+			high, low := math.Inf(-1), math.Inf(1)
+			for _, i := range hours[date] {
+				if i < len(member) && !math.IsNaN(member[i]) {
+					high, low = max(high, member[i]), min(low, member[i])
+				}
+			}
+			if !math.IsInf(high, 0) {
+				highs, lows = append(highs, high), append(lows, low)
+			}
+		}
+		if len(highs) == 0 {
+			continue
+		}
+		slices.Sort(highs)
+		slices.Sort(lows)
+		day := EnsembleDay{
+			Date:     date,
+			Members:  len(highs),
+			High:     [2]float64{percentile(highs, 10), percentile(highs, 90)},
+			Low:      [2]float64{percentile(lows, 10), percentile(lows, 90)},
+			RainProb: [2]float64{1, 0},
+		}
+		for block := 0; block < len(hours[date]); block += 6 {
+			indexes := hours[date][block:min(block+6, len(hours[date]))]
+			wet, counted := 0, 0
+			for _, member := range precip {
+				total, valid := 0.0, false
+				for _, i := range indexes {
+					if i < len(member) && !math.IsNaN(member[i]) {
+						total, valid = total+member[i], true
+					}
+				}
+				if valid {
+					counted++
+					if total >= ensembleRainThreshold {
+						wet++
+					}
+				}
+			}
+			if counted > 0 {
+				p := float64(wet) / float64(counted)
+				day.RainProb = [2]float64{min(day.RainProb[0], p), max(day.RainProb[1], p)}
+			}
+		}
+		if day.RainProb[0] > day.RainProb[1] {
+			day.RainProb = [2]float64{}
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+// formatRange formats a low–high range, as a single value when both round
+// the same.
+func formatRange(r [2]float64, format string) string {
+	low, high := fmt.Sprintf(format, r[0]), fmt.Sprintf(format, r[1])
+	if low == high {
+		return low
+	}
+	return low + "–" + high
+}
+
+// runEnsemble implements `weather ensemble`, showing how far the members of
+// an ensemble forecast disagree.
+func runEnsemble(args []string) error {
+	fs := flag.NewFlagSet("ensemble", flag.ExitOnError)
+	locFlags := addLocationFlags(fs)
+	days := fs.Int("days", 7, "Number of days to show, up to 15")
+	model := fs.String("model", defaultEnsembleModel, "Open-Meteo ensemble model, e.g. icon_seamless, gfs025 or ecmwf_ifs025")
+	asJSON := fs.Bool("json", false, "Print the daily ranges as JSON")
+	fs.Parse(args)
+
+	loc, hasFlagLoc, err := locFlags.location()
+	switch {
+	case err != nil:
+	case hasFlagLoc:
+	case fs.NArg() > 0:
+		loc, err = locFlags.resolveCity(strings.Join(fs.Args(), " "))
+	default:
+		return fmt.Errorf("usage: weather ensemble [--days N] [--model NAME] [--json] <city|@favorite>")
+	}
+	if err != nil {
+		return err
+	}
+	usePreferences(loc)
+	if *days < 1 || *days > 15 {
+		return fmt.Errorf("--days must be between 1 and 15")
+	}
+	lat, lon, err := loc.coordinates(apiKeyFromEnv())
+	if err != nil {
+		return err
+	}
+	data, err := GetEnsemble(lat, lon, *model, *days)
+	if err != nil {
+		return fmt.Errorf("fetching ensemble forecast for %s: %w", loc, err)
+	}
+	summary, err := ensembleDays(data)
+	if err != nil {
+		return err
+	}
+	if len(summary) == 0 {
+		return fmt.Errorf("the %s ensemble has no forecast for %s", *model, loc)
+	}
+
+	if *asJSON {
+		out, err := json.MarshalIndent(summary, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode ensemble forecast: %w", err)
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	fmt.Printf("Ensemble forecast for %s (%s, 10th–90th percentile of %d members)\n\n", loc, *model, summary[0].Members)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DATE\tHIGH\tLOW\tRAIN PROBABILITY")
+	for _, d := range summary {
+		date := d.Date
+		if t, err := time.Parse(time.DateOnly, d.Date); err == nil {
+			date = t.Format("Mon Jan 2")
+		}
+		rain := [2]float64{d.RainProb[0] * 100, d.RainProb[1] * 100}
+		high := [2]float64{showTemp(d.High[0]), showTemp(d.High[1])}
+		low := [2]float64{showTemp(d.Low[0]), showTemp(d.Low[1])}
+		temp := displayUnits.temp.symbol
+		fmt.Fprintf(tw, "%s\t%s%s\t%s%s\t%s%%\n", date, formatRange(high, "%.0f"), temp, formatRange(low, "%.0f"), temp, formatRange(rain, "%.0f"))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Println("\nWide ranges mean the models disagree and the forecast is uncertain.")
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// execTimeout bounds how long an exec channel, plugin or hook command may
+// run.
+const execTimeout = 30 * time.Second
+
+// ExecNotifier delivers messages by running a user-supplied command. The
+// message is written to its stdin as JSON and also passed in the
+// WEATHER_TITLE, WEATHER_BODY and WEATHER_SEVERE environment variables.
+type ExecNotifier struct {
+	Command []string
+}
+
+// execPayload is the JSON document written to an exec command's stdin.
+type execPayload struct {
+	Title    string   `json:"title"`
+	Body     string   `json:"body"`
+	Parts    []string `json:"parts"`
+	Severe   bool     `json:"severe"`
+	Severity string   `json:"severity,omitempty"`
+}
+
+// newExecNotifier builds an ExecNotifier for a channel configured in the
+// "exec_channels" section of the config file.
+func newExecNotifier(command []string) (Notifier, error) {
+	if len(command) == 0 || command[0] == "" {
+		return nil, fmt.Errorf("exec channel has no command")
+	}
+	return &ExecNotifier{Command: command}, nil
+}
+
+// Notify runs the command and waits for it to exit. A non-zero exit status
+// is reported along with what the command wrote to stderr.
+func (n *ExecNotifier) Notify(msg Message) error {
+	payload, err := json.Marshal(execPayload{Title: msg.Title, Body: msg.Body(), Parts: msg.Parts, Severe: msg.Severe, Severity: msg.Severity})
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	severe := "0"
+	if msg.Severe {
+		severe = "1"
+	}
+	_, err = runCommand(n.Command, payload, []string{
+		"WEATHER_TITLE=" + msg.Title,
+		"WEATHER_BODY=" + msg.Body(),
+		"WEATHER_SEVERE=" + severe,
+	})
+	return err
+}
+
+// runCommand runs argv with stdin and extra environment variables and
+// returns its stdout. Failures include whatever it wrote to stderr.
+func runCommand(argv []string, stdin []byte, env []string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), execTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, expandHome(argv[0]), argv[1:]...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Env = append(os.Environ(), env...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("%s timed out after %s", argv[0], execTimeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %w: %s", argv[0], err, msg)
+		}
+		return nil, fmt.Errorf("%s: %w", argv[0], err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// expandHome replaces a leading ~/ with the user's home directory, since
+// commands from the config file aren't run through a shell.
+func expandHome(path string) string {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return home + string(os.PathSeparator) + rest
+}
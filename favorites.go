@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Favorite is a named saved location. Its coordinates are resolved once via
+// geocoding when it is added, so later queries are unambiguous.
+type Favorite struct {
+	Place  string   `json:"place"`
+	Lat    float64  `json:"lat"`
+	Lon    float64  `json:"lon"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// Location returns the coordinates-based Location for the favorite saved
+// as name.
+func (f Favorite) Location(name string) Location {
+	return Location{Name: f.Place, Lat: f.Lat, Lon: f.Lon, HasCoords: true, Favorite: name}
+}
+
+func favoritesPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "favorites.json"), nil
+}
+
+// loadFavorites reads the saved favorites, keyed by name.
+func loadFavorites() (map[string]Favorite, error) {
+	path, err := favoritesPath()
+	if err != nil {
+		return nil, err
+	}
+	favorites := make(map[string]Favorite)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return favorites, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read favorites: %w", err)
+	}
+	if err := json.Unmarshal(data, &favorites); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return favorites, nil
+}
+
+func saveFavorites(favorites map[string]Favorite) error {
+	path, err := favoritesPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(favorites, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode favorites: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write favorites: %w", err)
+	}
+	return nil
+}
+
+// sortedFavoriteNames returns the favorite names in alphabetical order.
+func sortedFavoriteNames(favorites map[string]Favorite) []string {
+	names := make([]string, 0, len(favorites))
+	for name := range favorites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// groupMembers returns the names of the favorites in group, in
+// alphabetical order.
+func groupMembers(favorites map[string]Favorite, group string) []string {
+	var members []string
+	for _, name := range sortedFavoriteNames(favorites) {
+		if slices.Contains(favorites[name].Groups, group) {
+			members = append(members, name)
+		}
+	}
+	return members
+}
+
+// runFav implements `weather fav add|remove|list|group|ungroup`.
+func runFav(args []string) error {
+	usage := fmt.Errorf("usage: weather fav add [--zip ZIP,CC | --state ST] [--country CC] <name> [city] | remove <name> | list | group <group> <name>... | ungroup <group> <name>...")
+	if len(args) == 0 {
+		return usage
+	}
+	favorites, err := loadFavorites()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "add":
+		addFlags := flag.NewFlagSet("fav add", flag.ExitOnError)
+		zip := addFlags.String("zip", "", "Postal code and country to save instead of a city, e.g. 10001,US")
+		state := addFlags.String("state", "", "US state code to disambiguate the city, e.g. OR")
+		country := addFlags.String("country", "", "Country code restricting the lookup, e.g. KE")
+		addFlags.Parse(args[1:])
+		if addFlags.NArg() == 0 || (*zip == "") == (addFlags.NArg() == 1) {
+			return usage
+		}
+		name := strings.TrimPrefix(addFlags.Arg(0), "@")
+		query := qualifyCity(strings.Join(addFlags.Args()[1:], " "), *state, *country)
+
+		var place *GeoLocation
+		if *zip != "" {
+			query = *zip
+			if *country != "" && !strings.Contains(query, ",") {
+				query += "," + strings.ToUpper(*country)
+			}
+			place, err = GeocodeZip(query, apiKeyFromEnv())
+		} else {
+			var results []GeoLocation
+			results, err = Geocode(query, 5, apiKeyFromEnv())
+			results = filterByCountry(results, *country)
+			if err == nil && len(results) > 0 {
+				place = &results[0]
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("looking up %s: %w", query, err)
+		}
+		if place == nil {
+			return fmt.Errorf("no place found matching %q", query)
+		}
+		// Adding an existing favorite again moves it, keeping its groups.
+		fav := favorites[name]
+		fav.Place, fav.Lat, fav.Lon = geoPlaceName(*place), place.Lat, place.Lon
+		favorites[name] = fav
+		if err := saveFavorites(favorites); err != nil {
+			return err
+		}
+		fmt.Printf("Saved @%s: %s (%.4f, %.4f)\n", name, fav.Place, fav.Lat, fav.Lon)
+
+	case "remove":
+		if len(args) != 2 {
+			return usage
+		}
+		name := strings.TrimPrefix(args[1], "@")
+		if _, ok := favorites[name]; !ok {
+			return fmt.Errorf("no favorite named %q", name)
+		}
+		delete(favorites, name)
+		if err := saveFavorites(favorites); err != nil {
+			return err
+		}
+		fmt.Printf("Removed @%s\n", name)
+
+	case "list":
+		if len(favorites) == 0 {
+			fmt.Println("No favorites saved yet. Add one with: weather fav add home \"Nairobi,KE\"")
+			return nil
+		}
+		for _, name := range sortedFavoriteNames(favorites) {
+			fav := favorites[name]
+			fmt.Printf("@%-15s %s (%.4f, %.4f)", name, fav.Place, fav.Lat, fav.Lon)
+			if len(fav.Groups) > 0 {
+				fmt.Printf("  [%s]", strings.Join(fav.Groups, ", "))
+			}
+			fmt.Println()
+		}
+
+	case "group", "ungroup":
+		if len(args) < 3 {
+			return usage
+		}
+		group := strings.TrimPrefix(args[1], "@")
+		if _, clash := favorites[group]; clash {
+			return fmt.Errorf("%q is already the name of a favorite", group)
+		}
+		for _, arg := range args[2:] {
+			name := strings.TrimPrefix(arg, "@")
+			fav, ok := favorites[name]
+			if !ok {
+				return fmt.Errorf("no favorite named %q", name)
+			}
+			fav.Groups = slices.DeleteFunc(fav.Groups, func(g string) bool { return g == group })
+			if args[0] == "group" {
+				fav.Groups = append(fav.Groups, group)
+			}
+			favorites[name] = fav
+		}
+		if err := saveFavorites(favorites); err != nil {
+			return err
+		}
+		fmt.Printf("@%s: %s\n", group, strings.Join(groupMembers(favorites, group), ", "))
+
+	default:
+		return usage
+	}
+	return nil
+}
+
+// geoPlaceName formats a geocoding result as "Name, State, CC".
+func geoPlaceName(g GeoLocation) string {
+	parts := []string{g.Name}
+	if g.State != "" {
+		parts = append(parts, g.State)
+	}
+	if g.Country != "" {
+		parts = append(parts, g.Country)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// runCurrent implements `weather current <city|@favorite>`.
+func runCurrent(args []string) error {
+	return runLocationCommand("current", false, args)
+}
+
+// runForecast implements `weather forecast <city|@favorite>`.
+func runForecast(args []string) error {
+	return runLocationCommand("forecast", true, args)
+}
+
+func runLocationCommand(name string, forecast bool, args []string) error {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	locFlags := addLocationFlags(fs)
+	opts := addDisplayFlags(fs)
+	fs.Parse(args)
+
+	apiKey := apiKeyFromEnv()
+	loc, hasFlagLoc, err := locFlags.location()
+	switch {
+	case err != nil:
+	case hasFlagLoc:
+	case fs.NArg() > 0:
+		loc, err = locFlags.resolveCity(strings.Join(fs.Args(), " "))
+	case canPick():
+		loc, err = pickLocation(apiKey)
+	default:
+		return fmt.Errorf("usage: weather %s [flags] <city|@favorite>", name)
+	}
+	if err != nil {
+		return err
+	}
+	usePreferences(loc)
+	return showWeather(loc, forecast, opts, apiKey)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// feelsLikeAlgorithms compute a feels-like temperature in °C from the
+// temperature in °C, relative humidity in % and wind speed in m/s. "api"
+// keeps the value the weather API returned.
+var feelsLikeAlgorithms = map[string]func(temp, humidity, wind float64) float64{
+	"api": nil,
+	"aat": apparentTemperature,
+	"nws": nwsFeelsLike,
+}
+
+// feelsLikeAlgorithmNames lists the accepted --feels-like-algo values.
+func feelsLikeAlgorithmNames() string {
+	names := make([]string, 0, len(feelsLikeAlgorithms))
+	for name := range feelsLikeAlgorithms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// apparentTemperature is the Australian Bureau of Meteorology's apparent
+// temperature (Steadman's, without solar radiation).
+func apparentTemperature(temp, humidity, wind float64) float64 {
+	e := humidity / 100 * 6.105 * math.Exp(17.27*temp/(237.7+temp))
+	return temp + 0.33*e - 0.70*wind - 4.00
+}
+
+// nwsFeelsLike is the US National Weather Service's combination: the heat
+// index at 80 °F and above, the wind chill at 50 °F and below with wind over
+// 3 mph, and the air temperature in between.
+func nwsFeelsLike(temp, humidity, wind float64) float64 {
+	t := temp*9/5 + 32
+	mph := wind / 0.44704
+	switch {
+	case t <= 50 && mph > 3:
+		v := math.Pow(mph, 0.16)
+		return (35.74 + 0.6215*t - 35.75*v + 0.4275*t*v - 32) * 5 / 9
+	case t >= 80:
+		return (heatIndex(t, humidity) - 32) * 5 / 9
+	}
+	return temp
+}
+
+// heatIndex is the NWS heat index in °F: Steadman's simple formula, or the
+// Rothfusz regression with its adjustments when that gives 80 °F or more.
+func heatIndex(t, rh float64) float64 {
+	hi := 0.5 * (t + 61 + (t-68)*1.2 + rh*0.094)
+	if (hi+t)/2 < 80 {
+		return hi
+	}
+	hi = -42.379 + 2.04901523*t + 10.14333127*rh - 0.22475541*t*rh - 0.00683783*t*t -
+		0.05481717*rh*rh + 0.00122874*t*t*rh + 0.00085282*t*rh*rh - 0.00000199*t*t*rh*rh
+	switch {
+	case rh < 13 && t >= 80 && t <= 112:
+		hi -= (13 - rh) / 4 * math.Sqrt((17-math.Abs(t-95))/17)
+	case rh > 85 && t >= 80 && t <= 87:
+		hi += (rh - 85) / 10 * (87 - t) / 5
+	}
+	return hi
+}
+
+// feelsLikeAlgorithm returns the named algorithm, falling back to the
+// config file's "feels_like_algo" and then the API's own value.
+func feelsLikeAlgorithm(name string) (func(temp, humidity, wind float64) float64, error) {
+	if name == "" {
+		name = config.FeelsLikeAlgo
+	}
+	if name == "" {
+		return nil, nil
+	}
+	algo, ok := feelsLikeAlgorithms[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown feels-like algorithm %q, use one of: %s", name, feelsLikeAlgorithmNames())
+	}
+	return algo, nil
+}
+
+// applyFeelsLike replaces the API's feels-like temperature with algo's.
+func applyFeelsLike(algo func(temp, humidity, wind float64) float64, main *Main, wind float64) {
+	if algo != nil {
+		main.FeelsLike = algo(main.Temp, float64(main.Humidity), wind)
+	}
+}
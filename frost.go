@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// FrostSeason is one frost season of recorded observations: July to June
+// in the Northern Hemisphere, so each winter is counted whole, and the
+// calendar year in the Southern. FirstSure and LastSure say whether
+// frost-free days were recorded before the first frost and after the last
+// one; if not, the records may have missed an earlier or later frost.
+type FrostSeason struct {
+	Season     string    `json:"season"` // e.g. "2024/25" or "2025"
+	Start      time.Time `json:"start"`
+	Days       int       `json:"days"` // days with readings
+	FrostDays  int       `json:"frost_days"`
+	FirstFrost time.Time `json:"first_frost,omitzero"`
+	FirstSure  bool      `json:"first_frost_sure"`
+	LastFrost  time.Time `json:"last_frost,omitzero"`
+	LastSure   bool      `json:"last_frost_sure"`
+	Lowest     float64   `json:"temp_min"`
+}
+
+// FrostOutlook projects the seasons' first and last frosts onto the coming
+// year: the earliest, median and latest dates seen.
+type FrostOutlook struct {
+	Seasons         int          `json:"seasons"`
+	FirstFrost      [3]time.Time `json:"first_frost,omitzero"` // earliest, median, latest
+	LastFrost       [3]time.Time `json:"last_frost,omitzero"`
+	FrostFreeMedian int          `json:"frost_free_days,omitempty"`
+}
+
+// frostSeasonStart returns the start of the frost season containing t.
+func frostSeasonStart(t time.Time, southern bool) time.Time {
+	if southern {
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, time.Local)
+	}
+	year := t.Year()
+	if t.Month() < time.July {
+		year--
+	}
+	return time.Date(year, time.July, 1, 0, 0, 0, 0, time.Local)
+}
+
+// runFrost implements `weather frost`, first and last frost dates and
+// frost days per season from the observation store, and when to expect
+// frost in the coming year.
+func runFrost(args []string) error {
+	fs := flag.NewFlagSet("frost", flag.ExitOnError)
+	city := fs.String("city", "", "City or @favorite to analyse, as recorded by record tasks or history backfill")
+	threshold := fs.Float64("threshold", math.NaN(), "Daily minimum, in the --units temperature unit, at or below which a day counts as a frost (default 0 °C)")
+	southern := fs.Bool("southern", false, "Southern Hemisphere: count frost seasons by calendar year instead of July to June")
+	asJSON := fs.Bool("json", false, "Print the seasons and outlook as JSON")
+	fs.Parse(args)
+
+	name := *city
+	if name == "" {
+		name = strings.Join(fs.Args(), " ")
+	}
+	if name == "" {
+		return fmt.Errorf("usage: weather frost [--threshold T] [--southern] [--json] --city <city|@favorite>")
+	}
+	loc, err := resolveLocation(name)
+	if err != nil {
+		return err
+	}
+	usePreferences(loc)
+	limit := 0.0
+	if !math.IsNaN(*threshold) {
+		limit = displayUnits.temp.toBase(*threshold)
+	}
+	observations, err := readObservations()
+	if err != nil {
+		return err
+	}
+	seasons := frostSeasons(observations, loc.String(), limit, *southern)
+	if len(seasons) == 0 {
+		return fmt.Errorf("no observations of %s recorded; add a record task or run weather history backfill", loc)
+	}
+	outlook := projectFrost(seasons, time.Now(), *southern)
+
+	if *asJSON {
+		data, err := json.MarshalIndent(struct {
+			Location  string        `json:"location"`
+			Threshold float64       `json:"threshold"`
+			Seasons   []FrostSeason `json:"seasons"`
+			Outlook   FrostOutlook  `json:"outlook"`
+		}{loc.String(), limit, seasons, outlook}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode frost statistics: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	temp := displayUnits.temp.symbol
+	lprintf("Frost statistics for %s (a frost is a daily minimum of %.1f%s or below)\n\n", loc, showTemp(limit), temp)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "SEASON\tDAYS\tFROST DAYS\tFIRST FROST\tLAST FROST\tLOWEST %s\n", temp)
+	unsure := false
+	for _, s := range seasons {
+		first, last := frostDate(s.FirstFrost, s.FirstSure), frostDate(s.LastFrost, s.LastSure)
+		unsure = unsure || strings.HasSuffix(first, "?") || strings.HasSuffix(last, "?")
+		fmt.Fprintln(tw, lsprintf("%s\t%d\t%d\t%s\t%s\t%.1f", s.Season, s.Days, s.FrostDays, first, last, showTemp(s.Lowest)))
+	}
+	tw.Flush()
+	if unsure {
+		fmt.Println("? the records stop at this frost, so there may have been an earlier first or later last frost.")
+	}
+
+	fmt.Println()
+	if outlook.Seasons == 0 {
+		fmt.Println("Not enough records for a frost outlook: it needs a season recorded from before its first frost or past its last.")
+		return nil
+	}
+	lprintf("Frost outlook, from %d season(s) of records:\n", outlook.Seasons)
+	if f := outlook.FirstFrost; !f[0].IsZero() {
+		lprintf("  First frost: %s at the earliest, typically %s (latest %s)\n", formatDate(f[0], "Jan 2"), formatDate(f[1], "Jan 2"), formatDate(f[2], "Jan 2"))
+	}
+	if l := outlook.LastFrost; !l[0].IsZero() {
+		lprintf("  Last frost: typically %s (earliest %s), none after %s in the records\n", formatDate(l[1], "Jan 2"), formatDate(l[0], "Jan 2"), formatDate(l[2], "Jan 2"))
+	}
+	if !outlook.FirstFrost[0].IsZero() && !outlook.LastFrost[0].IsZero() {
+		lprintf("  Frost risk: %s to %s; tender plants are safest outside it\n", formatDate(outlook.FirstFrost[0], "Jan 2"), formatDate(outlook.LastFrost[2], "Jan 2"))
+	}
+	if outlook.FrostFreeMedian > 0 {
+		lprintf("  Frost-free season: about %d days\n", outlook.FrostFreeMedian)
+	}
+	return nil
+}
+
+// frostDate formats a season's first or last frost for the table.
+func frostDate(t time.Time, sure bool) string {
+	switch {
+	case t.IsZero():
+		return "-"
+	case !sure:
+		return formatDate(t, "Jan 2") + "?"
+	}
+	return formatDate(t, "Jan 2")
+}
+
+// frostSeasons aggregates location's observations into daily minimums and
+// then into frost seasons, oldest first. A day is a frost when its minimum
+// is at or below threshold °C.
+func frostSeasons(observations []Observation, location string, threshold float64, southern bool) []FrostSeason {
+	lows := make(map[time.Time]float64)
+	for _, o := range observations {
+		if !strings.EqualFold(o.Location, location) {
+			continue
+		}
+		at := o.At.Local()
+		date := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.Local)
+		if low, ok := lows[date]; !ok || o.Temp < low {
+			lows[date] = o.Temp
+		}
+	}
+	dates := make([]time.Time, 0, len(lows))
+	for date := range lows {
+		dates = append(dates, date)
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	var seasons []FrostSeason
+	for _, date := range dates {
+		start := frostSeasonStart(date, southern)
+		if len(seasons) == 0 || !seasons[len(seasons)-1].Start.Equal(start) {
+			name := fmt.Sprint(start.Year())
+			if !southern {
+				name = fmt.Sprintf("%d/%02d", start.Year(), (start.Year()+1)%100)
+			}
+			seasons = append(seasons, FrostSeason{Season: name, Start: start, Lowest: math.Inf(1)})
+		}
+		s := &seasons[len(seasons)-1]
+		s.Days++
+		s.Lowest = min(s.Lowest, lows[date])
+		if lows[date] > threshold {
+			s.LastSure = !s.LastFrost.IsZero()
+			continue
+		}
+		s.FrostDays++
+		if s.FirstFrost.IsZero() {
+			s.FirstFrost, s.FirstSure = date, s.Days > 1
+		}
+		s.LastFrost, s.LastSure = date, false
+	}
+	return seasons
+}
+
+// projectFrost projects the sure first and last frosts of seasons onto the
+// frost season of now, or the next one for a window already past.
+func projectFrost(seasons []FrostSeason, now time.Time, southern bool) FrostOutlook {
+	start := frostSeasonStart(now, southern)
+	var firsts, lasts, frostFree []float64
+	for i, s := range seasons {
+		// Shifting by whole years keeps each date on its day of the
+		// calendar, whatever the leap days in between.
+		years := start.Year() - s.Start.Year()
+		if s.FirstSure {
+			firsts = append(firsts, daysAfter(start, s.FirstFrost.AddDate(years, 0, 0)))
+		}
+		if s.LastSure {
+			lasts = append(lasts, daysAfter(start, s.LastFrost.AddDate(years, 0, 0)))
+			// The frost-free days run to the next season's first frost,
+			// if it was recorded too.
+			if i+1 < len(seasons) && seasons[i+1].FirstSure && seasons[i+1].Start.Equal(s.Start.AddDate(1, 0, 0)) {
+				frostFree = append(frostFree, daysAfter(s.LastFrost, seasons[i+1].FirstFrost))
+			}
+		}
+	}
+	if len(firsts) == 0 && len(lasts) == 0 {
+		return FrostOutlook{}
+	}
+	project := func(days []float64) [3]time.Time {
+		var dates [3]time.Time
+		if len(days) == 0 {
+			return dates
+		}
+		sort.Float64s(days)
+		for i, p := range []float64{0, 50, 100} {
+			dates[i] = start.AddDate(0, 0, int(math.Round(percentile(days, p))))
+		}
+		if dates[2].Before(now) {
+			for i := range dates {
+				dates[i] = dates[i].AddDate(1, 0, 0)
+			}
+		}
+		return dates
+	}
+	outlook := FrostOutlook{Seasons: len(seasons), FirstFrost: project(firsts), LastFrost: project(lasts)}
+	if len(frostFree) > 0 {
+		sort.Float64s(frostFree)
+		outlook.FrostFreeMedian = int(math.Round(percentile(frostFree, 50)))
+	}
+	return outlook
+}
+
+// daysAfter returns the whole days from from to t, both local midnights.
+func daysAfter(from, t time.Time) float64 {
+	return math.Round(t.Sub(from).Hours() / 24)
+}
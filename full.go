@@ -0,0 +1,149 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// fullReport is everything `weather full` fetches for a location. Each
+// part has its own error, so the parts that were fetched can be shown
+// even when others failed.
+type fullReport struct {
+	current     *CurrentWeatherResponse
+	currentErr  error
+	forecast    *ForecastResponse
+	forecastErr error
+	air         *AirPollutionResponse
+	airErr      error
+	alerts      []WeatherAlert
+	alertErr    error
+}
+
+// failed lists the parts that couldn't be fetched.
+func (r *fullReport) failed() []string {
+	var failed []string
+	for _, part := range []struct {
+		name string
+		err  error
+	}{{"current weather", r.currentErr}, {"forecast", r.forecastErr}, {"air quality", r.airErr}, {"warnings", r.alertErr}} {
+		if part.err != nil {
+			failed = append(failed, part.name)
+		}
+	}
+	return failed
+}
+
+// fetchFullReport fetches the current weather, forecast, air quality and
+// official warnings for loc concurrently.
+func fetchFullReport(loc Location, apiKey string) *fullReport {
+	var report fullReport
+	var g errgroup.Group
+	g.Go(func() error {
+		report.current, report.currentErr = GetCurrentWeatherAt(loc, apiKey)
+		return nil
+	})
+	g.Go(func() error {
+		report.forecast, report.forecastErr = GetForecastAt(loc, apiKey)
+		return nil
+	})
+	// Air quality and warnings are looked up by coordinates.
+	g.Go(func() error {
+		lat, lon, err := loc.coordinates(apiKey)
+		if err != nil {
+			report.airErr, report.alertErr = err, err
+			return nil
+		}
+		var extras errgroup.Group
+		extras.Go(func() error {
+			report.air, report.airErr = GetAirPollution(lat, lon, apiKey)
+			return nil
+		})
+		extras.Go(func() error {
+			report.alerts, report.alertErr = GetWeatherAlerts(lat, lon, apiKey)
+			return nil
+		})
+		return extras.Wait()
+	})
+	g.Wait()
+	return &report
+}
+
+// printDailyOutlook prints one line per day of the forecast: the range of
+// temperatures, the commonest condition, the highest chance of rain and
+// the hours of sunshine.
+func printDailyOutlook(data *ForecastResponse) {
+	for _, d := range dailySummariesFrom(data) {
+		lprintf("  %s: %.*f–%.*f%s, %s, rain %.0f%%, sun %.1f h\n", formatDate(d.Date, "2006-01-02 (Mon)"),
+			decimals("temp", 0), showTemp(d.Low), decimals("temp", 0), showTemp(d.High), displayUnits.temp.symbol, d.conditionOrNA(), d.MaxPop*100, d.Sunshine)
+	}
+}
+
+// runFull implements `weather full`, a single report of the current
+// weather, air quality, official warnings and the days ahead.
+func runFull(args []string) error {
+	fs := flag.NewFlagSet("full", flag.ExitOnError)
+	locFlags := addLocationFlags(fs)
+	fs.Parse(args)
+
+	loc, hasFlagLoc, err := locFlags.location()
+	switch {
+	case err != nil:
+	case hasFlagLoc:
+	case fs.NArg() > 0:
+		loc, err = locFlags.resolveCity(strings.Join(fs.Args(), " "))
+	default:
+		return fmt.Errorf("usage: weather full <city|@favorite>")
+	}
+	if err != nil {
+		return err
+	}
+	usePreferences(loc)
+
+	report := fetchFullReport(loc, apiKeyFromEnv())
+
+	if report.currentErr != nil {
+		fmt.Printf("Current Weather for %s:\n  Not available: %v\n", loc, report.currentErr)
+	} else {
+		displayCurrentWeather(report.current)
+	}
+
+	fmt.Println("Air Quality:")
+	if report.airErr != nil {
+		fmt.Printf("  Not available: %v\n", report.airErr)
+	} else {
+		air := report.air.List[0]
+		fmt.Printf("  AQI: %d (%s)\n", air.Main.AQI, aqiLabel(air.Main.AQI))
+		fmt.Printf("  PM2.5: %.1f µg/m³, PM10: %.1f µg/m³\n", air.Components["pm2_5"], air.Components["pm10"])
+	}
+
+	fmt.Println("Official Warnings:")
+	switch {
+	case report.alertErr != nil:
+		fmt.Printf("  Not available: %v\n", report.alertErr)
+	case len(report.alerts) == 0:
+		fmt.Println("  None in force")
+	default:
+		for _, a := range report.alerts {
+			printWarning(alertFrom(a))
+		}
+	}
+
+	fmt.Println("Days Ahead:")
+	if report.forecastErr != nil {
+		fmt.Printf("  Not available: %v\n", report.forecastErr)
+	} else {
+		printDailyOutlook(report.forecast)
+	}
+	fmt.Printf("------------------------------------\nReport for %s at %s\n", loc, formatDate(time.Now(), "Mon Jan 2 15:04"))
+
+	// Warnings need a One Call subscription many keys lack, so only a
+	// missing current weather or forecast makes the report a failure.
+	if report.currentErr != nil || report.forecastErr != nil {
+		return fmt.Errorf("incomplete report for %s: no %s", loc, strings.Join(report.failed(), ", "))
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	directGeocodingURL  = "https://api.openweathermap.org/geo/1.0/direct"
+	zipGeocodingURL     = "https://api.openweathermap.org/geo/1.0/zip"
+	reverseGeocodingURL = "https://api.openweathermap.org/geo/1.0/reverse"
+)
+
+// GeoLocation is a single result from the OpenWeatherMap geocoding API.
+type GeoLocation struct {
+	Name       string            `json:"name"`
+	LocalNames map[string]string `json:"local_names"`
+	Lat        float64           `json:"lat"`
+	Lon        float64           `json:"lon"`
+	Country    string            `json:"country"`
+	State      string            `json:"state"`
+}
+
+// Geocode looks up places matching a "city[,state][,country]" query.
+func Geocode(query string, limit int, apiKey string) ([]GeoLocation, error) {
+	params := url.Values{"q": {query}, "limit": {strconv.Itoa(limit)}, "appid": {apiKey}}
+	var results []GeoLocation
+	if err := fetchWeatherData(directGeocodingURL+"?"+params.Encode(), &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GeocodeZip looks up the place for a "zip,country" postal code, e.g.
+// "10001,US". The country defaults to US when omitted.
+func GeocodeZip(zip string, apiKey string) (*GeoLocation, error) {
+	params := url.Values{"zip": {zip}, "appid": {apiKey}}
+	var result GeoLocation
+	if err := fetchWeatherData(zipGeocodingURL+"?"+params.Encode(), &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ReverseGeocode returns the named places nearest to the given coordinates.
+func ReverseGeocode(lat, lon float64, limit int, apiKey string) ([]GeoLocation, error) {
+	params := url.Values{
+		"lat":   {strconv.FormatFloat(lat, 'f', -1, 64)},
+		"lon":   {strconv.FormatFloat(lon, 'f', -1, 64)},
+		"limit": {strconv.Itoa(limit)},
+		"appid": {apiKey},
+	}
+	var results []GeoLocation
+	if err := fetchWeatherData(reverseGeocodingURL+"?"+params.Encode(), &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// filterByCountry keeps only results in the given country. The geocoder
+// treats the country in a query as a hint, so results are checked again.
+func filterByCountry(results []GeoLocation, country string) []GeoLocation {
+	if country == "" {
+		return results
+	}
+	var filtered []GeoLocation
+	for _, r := range results {
+		if strings.EqualFold(r.Country, country) {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
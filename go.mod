@@ -1,5 +1,26 @@
-module main/main.go
+module github.com/Mugambi645/weather-tool
 
-go 1.24.2
+go 1.26.0
 
-require github.com/joho/godotenv v1.5.1 // indirect
+require (
+	fyne.io/systray v1.12.2
+	github.com/expr-lang/expr v1.17.8
+	github.com/joho/godotenv v1.5.1
+	github.com/mdp/qrterminal/v3 v3.2.1
+	github.com/robfig/cron/v3 v3.0.1
+	github.com/wcharczuk/go-chart/v2 v2.1.2
+	go.starlark.net v0.0.0-20260908191801-89a6a09411d5
+	go.yaml.in/yaml/v3 v3.0.5
+	golang.org/x/sync v0.23.0
+	golang.org/x/term v0.41.0
+	golang.org/x/text v0.42.0
+	golang.org/x/time v0.16.0
+)
+
+require (
+	github.com/godbus/dbus/v5 v5.1.0 // indirect
+	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0 // indirect
+	golang.org/x/image v0.18.0 // indirect
+	golang.org/x/sys v0.42.0 // indirect
+	rsc.io/qr v0.2.0 // indirect
+)
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// observationFields maps the metric names offered to Grafana to the
+// observation value they read, rounded to any --precision.
+var observationFields = map[string]func(Observation) float64{
+	"temp":       func(o Observation) float64 { return roundField("temp", o.Temp) },
+	"feels_like": func(o Observation) float64 { return roundField("temp", o.FeelsLike) },
+	"humidity":   func(o Observation) float64 { return float64(o.Humidity) },
+	"pressure":   func(o Observation) float64 { return float64(o.Pressure) },
+	"wind_speed": func(o Observation) float64 { return roundField("wind", o.WindSpeed) },
+	"clouds":     func(o Observation) float64 { return float64(o.Clouds) },
+}
+
+// grafanaQuery is the body of a Simple JSON datasource /query request.
+type grafanaQuery struct {
+	Range struct {
+		From time.Time `json:"from"`
+		To   time.Time `json:"to"`
+	} `json:"range"`
+	Targets []struct {
+		Target string `json:"target"`
+		Type   string `json:"type"`
+	} `json:"targets"`
+	MaxDataPoints int `json:"maxDataPoints"`
+}
+
+// grafanaSeries is one time series answer: values paired with Unix
+// milliseconds.
+type grafanaSeries struct {
+	Target     string       `json:"target"`
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+type grafanaColumn struct {
+	Text string `json:"text"`
+	Type string `json:"type"`
+}
+
+// grafanaTable is one table answer.
+type grafanaTable struct {
+	Type    string          `json:"type"`
+	Columns []grafanaColumn `json:"columns"`
+	Rows    [][]any         `json:"rows"`
+}
+
+// handleGrafana registers the Simple JSON datasource endpoints under
+// /grafana, charting the observations recorded by the daemon's record
+// tasks. Metrics are named "LOCATION:FIELD".
+func handleGrafana(mux *http.ServeMux) {
+	mux.HandleFunc("GET /grafana", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("POST /grafana/search", func(w http.ResponseWriter, r *http.Request) {
+		observations, err := readObservations()
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err)
+			return
+		}
+		var body struct {
+			Target string `json:"target"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		var metrics []string
+		for _, loc := range observedLocations(observations) {
+			for _, field := range sortedKeys(observationFields) {
+				metric := loc + ":" + field
+				if strings.Contains(strings.ToLower(metric), strings.ToLower(body.Target)) {
+					metrics = append(metrics, metric)
+				}
+			}
+		}
+		writeJSON(w, http.StatusOK, metrics)
+	})
+	mux.HandleFunc("POST /grafana/query", func(w http.ResponseWriter, r *http.Request) {
+		var query grafanaQuery
+		if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+			writeJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid query: %w", err))
+			return
+		}
+		observations, err := readObservations()
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err)
+			return
+		}
+		inRange := slices.DeleteFunc(observations, func(o Observation) bool {
+			return o.At.Before(query.Range.From) || (!query.Range.To.IsZero() && o.At.After(query.Range.To))
+		})
+
+		results := make([]any, 0, len(query.Targets))
+		for _, t := range query.Targets {
+			if t.Type == "table" {
+				results = append(results, latestObservationsTable(inRange, t.Target))
+				continue
+			}
+			series, err := observationSeries(inRange, t.Target, query.MaxDataPoints)
+			if err != nil {
+				writeJSONError(w, http.StatusBadRequest, err)
+				return
+			}
+			results = append(results, series)
+		}
+		writeJSON(w, http.StatusOK, results)
+	})
+}
+
+// observationSeries returns the time series for a "LOCATION:FIELD" target,
+// thinned to at most maxPoints points when that is set.
+func observationSeries(observations []Observation, target string, maxPoints int) (grafanaSeries, error) {
+	i := strings.LastIndex(target, ":")
+	if i < 0 {
+		return grafanaSeries{}, fmt.Errorf("invalid target %q, use LOCATION:FIELD", target)
+	}
+	loc, field := target[:i], target[i+1:]
+	value, ok := observationFields[field]
+	if !ok {
+		return grafanaSeries{}, fmt.Errorf("unknown field %q in target %q, use one of: %s", field, target, strings.Join(sortedKeys(observationFields), ", "))
+	}
+
+	series := grafanaSeries{Target: target, Datapoints: [][2]float64{}}
+	for _, o := range observations {
+		if strings.EqualFold(o.Location, loc) {
+			series.Datapoints = append(series.Datapoints, [2]float64{value(o), float64(o.At.UnixMilli())})
+		}
+	}
+	if maxPoints > 0 && len(series.Datapoints) > maxPoints {
+		step := (len(series.Datapoints) + maxPoints - 1) / maxPoints
+		thinned := make([][2]float64, 0, maxPoints)
+		for j := 0; j < len(series.Datapoints); j += step {
+			thinned = append(thinned, series.Datapoints[j])
+		}
+		series.Datapoints = thinned
+	}
+	return series, nil
+}
+
+// latestObservationsTable returns the newest observation of each location
+// whose name contains filter, for Grafana's table and stat panels.
+func latestObservationsTable(observations []Observation, filter string) grafanaTable {
+	latest := make(map[string]Observation)
+	for _, o := range observations {
+		if !strings.Contains(strings.ToLower(o.Location), strings.ToLower(filter)) {
+			continue
+		}
+		if prev, ok := latest[o.Location]; !ok || o.At.After(prev.At) {
+			latest[o.Location] = o
+		}
+	}
+
+	fields := sortedKeys(observationFields)
+	table := grafanaTable{
+		Type:    "table",
+		Columns: []grafanaColumn{{Text: "Time", Type: "time"}, {Text: "location", Type: "string"}, {Text: "condition", Type: "string"}},
+		Rows:    [][]any{},
+	}
+	for _, field := range fields {
+		table.Columns = append(table.Columns, grafanaColumn{Text: field, Type: "number"})
+	}
+	for _, loc := range sortedKeys(latest) {
+		o := latest[loc]
+		row := []any{o.At.UnixMilli(), o.Location, o.Condition}
+		for _, field := range fields {
+			row = append(row, observationFields[field](o))
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table
+}
+
+// observedLocations returns the distinct location names in observations,
+// sorted.
+func observedLocations(observations []Observation) []string {
+	seen := make(map[string]bool)
+	for _, o := range observations {
+		seen[o.Location] = true
+	}
+	return sortedKeys(seen)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,85 @@
+package main
+
+import "math"
+
+// humidexMinTemp is the temperature in °C below which humidex isn't
+// reported, following Environment Canada.
+const humidexMinTemp = 20
+
+// dewPoint returns the dew point in °C from the temperature in °C and
+// relative humidity in %, using the Magnus formula.
+func dewPoint(temp, humidity float64) float64 {
+	const b, c = 17.62, 243.12
+	gamma := math.Log(max(humidity, 1)/100) + b*temp/(c+temp)
+	return c * gamma / (b - gamma)
+}
+
+// vaporPressure returns the saturation vapour pressure in hPa over water at
+// temp °C.
+func vaporPressure(temp float64) float64 {
+	return 6.112 * math.Exp(17.62*temp/(243.12+temp))
+}
+
+// humidex returns Environment Canada's humidex, how hot humid air feels,
+// from the temperature in °C and relative humidity in %.
+func humidex(temp, humidity float64) float64 {
+	return temp + 0.5555*(vaporPressure(dewPoint(temp, humidity))-10)
+}
+
+// humidexLevel describes a humidex value, or returns "" below the point of
+// discomfort.
+func humidexLevel(h float64) string {
+	switch {
+	case h >= 46:
+		return "dangerous"
+	case h >= 40:
+		return "great discomfort"
+	case h >= 30:
+		return "some discomfort"
+	}
+	return ""
+}
+
+// wetBulb returns the wet-bulb temperature in °C from the temperature in
+// °C, relative humidity in % and ground-level pressure in hPa: the
+// temperature at which evaporation brings the air to saturation, found by
+// bisection of the psychrometric equation. Sustained wet-bulb temperatures above about
+// 31 °C are dangerous even for healthy people at rest.
+func wetBulb(temp, humidity, pressure float64) float64 {
+	if pressure <= 0 {
+		pressure = 1013.25
+	}
+	actual := vaporPressure(temp) * humidity / 100
+	low, high := dewPoint(temp, humidity), temp
+	for range 50 {
+		tw := (low + high) / 2
+		gamma := 0.00066 * (1 + 0.00115*tw) * pressure
+		if vaporPressure(tw)-gamma*(temp-tw) > actual {
+			high = tw
+		} else {
+			low = tw
+		}
+	}
+	return (low + high) / 2
+}
+
+// wbgt approximates the wet-bulb globe temperature in °C from the
+// temperature in °C and relative humidity in %, with the Australian Bureau
+// of Meteorology's formula. It assumes moderate sunshine and light wind, so
+// treat it as a guide: full sun adds a few degrees.
+func wbgt(temp, humidity float64) float64 {
+	e := humidity / 100 * vaporPressure(temp)
+	return 0.567*temp + 0.393*e + 3.94
+}
+
+// windChill returns Environment Canada's wind chill index from the
+// temperature in °C and wind speed in m/s, or the temperature itself when
+// it is above 10 °C or the wind is under 5 km/h.
+func windChill(temp, wind float64) float64 {
+	kmh := wind * 3.6
+	if temp > 10 || kmh < 5 {
+		return temp
+	}
+	v := math.Pow(kmh, 0.16)
+	return 13.12 + 0.6215*temp - 11.37*v + 0.3965*temp*v
+}
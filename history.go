@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// maxHistory is how many recent queries are remembered.
+const maxHistory = 20
+
+// HistoryEntry is a previously run weather query.
+type HistoryEntry struct {
+	Location Location  `json:"location"`
+	Forecast bool      `json:"forecast,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+func (h HistoryEntry) describe() string {
+	kind := "current"
+	if h.Forecast {
+		kind = "forecast"
+	}
+	return fmt.Sprintf("%-8s %s", kind, h.Location)
+}
+
+func historyPath() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history.json"), nil
+}
+
+// loadHistory returns recent queries, most recent first.
+func loadHistory() ([]HistoryEntry, error) {
+	path, err := historyPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	var history []HistoryEntry
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return history, nil
+}
+
+// recordHistory adds a query to the front of the history, dropping any
+// earlier identical query.
+func recordHistory(loc Location, forecast bool) error {
+	history, err := loadHistory()
+	if err != nil {
+		return err
+	}
+	entry := HistoryEntry{Location: loc, Forecast: forecast, At: time.Now()}
+	updated := []HistoryEntry{entry}
+	for _, h := range history {
+		if h.Location != loc || h.Forecast != forecast {
+			updated = append(updated, h)
+		}
+	}
+	if len(updated) > maxHistory {
+		updated = updated[:maxHistory]
+	}
+
+	path, err := historyPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(updated, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode history: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// runLast implements `weather last`, repeating the most recent query.
+func runLast(args []string) error {
+	return repeatHistory("last", args, func(fs *flag.FlagSet) (int, error) {
+		if fs.NArg() > 0 {
+			return 0, fmt.Errorf("usage: weather last [flags]")
+		}
+		return 1, nil
+	})
+}
+
+// runRecent implements `weather recent [n]`: without an argument it lists
+// recent queries, with one it repeats the n-th.
+func runRecent(args []string) error {
+	return repeatHistory("recent", args, func(fs *flag.FlagSet) (int, error) {
+		if fs.NArg() == 0 {
+			return 0, nil
+		}
+		n, err := strconv.Atoi(fs.Arg(0))
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("usage: weather recent [flags] [number]")
+		}
+		return n, nil
+	})
+}
+
+// repeatHistory lists the history (when choose returns 0) or repeats the
+// chosen entry with the given display flags.
+func repeatHistory(name string, args []string, choose func(*flag.FlagSet) (int, error)) error {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	opts := addDisplayFlags(fs)
+	fs.Parse(args)
+
+	n, err := choose(fs)
+	if err != nil {
+		return err
+	}
+	history, err := loadHistory()
+	if err != nil {
+		return err
+	}
+	if len(history) == 0 {
+		return fmt.Errorf("no recent queries yet")
+	}
+
+	if n == 0 {
+		for i, h := range history {
+			fmt.Printf("%2d  %s  (%s)\n", i+1, h.describe(), h.At.Local().Format("Jan 2 15:04"))
+		}
+		fmt.Println("\nRepeat one with: weather recent <number>")
+		return nil
+	}
+	if n > len(history) {
+		return fmt.Errorf("only %d recent queries are remembered", len(history))
+	}
+	entry := history[n-1]
+	usePreferences(entry.Location)
+	return showWeather(entry.Location, entry.Forecast, opts, apiKeyFromEnv())
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Hook stages.
+const (
+	hookPreFetch  = "pre_fetch"
+	hookPostFetch = "post_fetch"
+	hookPreRender = "pre_render"
+)
+
+// HooksConfig is the "hooks" section of the config file: commands run at
+// each stage, in order. Each command is a program and its arguments.
+type HooksConfig struct {
+	PreFetch  [][]string `json:"pre_fetch,omitempty"`
+	PostFetch [][]string `json:"post_fetch,omitempty"`
+	PreRender [][]string `json:"pre_render,omitempty"`
+}
+
+func (h HooksConfig) stage(name string) [][]string {
+	switch name {
+	case hookPreFetch:
+		return h.PreFetch
+	case hookPostFetch:
+		return h.PostFetch
+	case hookPreRender:
+		return h.PreRender
+	}
+	return nil
+}
+
+// fetchRequest is what pre-fetch hooks receive and may rewrite.
+type fetchRequest struct {
+	Kind     string   `json:"kind"`
+	Location Location `json:"location"`
+}
+
+// runHooks passes value as JSON through the stage's hook commands. A hook
+// that prints JSON replaces value with it, so hooks can enrich or filter
+// the data; a hook that prints nothing leaves it unchanged, e.g. when it
+// only mirrors the data elsewhere. value must be a pointer. A failing hook
+// aborts the operation.
+func runHooks(stage, kind string, loc Location, value any) error {
+	for _, command := range config.Hooks.stage(stage) {
+		if len(command) == 0 {
+			continue
+		}
+		input, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s hook input: %w", stage, err)
+		}
+		output, err := runCommand(command, input, []string{
+			"WEATHER_HOOK=" + stage,
+			"WEATHER_KIND=" + kind,
+			"WEATHER_LOCATION=" + loc.String(),
+		})
+		if err != nil {
+			return fmt.Errorf("%s hook: %w", stage, err)
+		}
+		if len(bytes.TrimSpace(output)) == 0 {
+			continue
+		}
+		reflect.ValueOf(value).Elem().SetZero()
+		if err := json.Unmarshal(output, value); err != nil {
+			return fmt.Errorf("%s hook %s returned invalid JSON: %w", stage, command[0], err)
+		}
+	}
+	return nil
+}
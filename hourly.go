@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const hourlyForecastURL = "https://pro.openweathermap.org/data/2.5/forecast/hourly"
+
+// maxForecastHours is how far ahead the hourly forecast reaches.
+const maxForecastHours = 96
+
+// GetHourlyForecast fetches up to hours of the 4-day hourly forecast for a
+// location. It needs a Developer plan or higher; the response has the same
+// shape as the 3-hour forecast.
+func GetHourlyForecast(loc Location, hours int, apiKey string) (*ForecastResponse, error) {
+	params := loc.query(apiKey)
+	params.Set("cnt", strconv.Itoa(hours))
+	var data ForecastResponse
+	if err := fetchWeatherData(hourlyForecastURL+"?"+params.Encode(), &data); err != nil {
+		return nil, planError(err, "the hourly forecast")
+	}
+	return &data, nil
+}
+
+// runHourly implements `weather hourly`. It is only available once the
+// config file says the API key's plan includes the Pro endpoints.
+func runHourly(args []string) error {
+	fs := flag.NewFlagSet("hourly", flag.ExitOnError)
+	locFlags := addLocationFlags(fs)
+	hours := fs.Int("hours", 24, fmt.Sprintf("Number of hours to show, up to %d", maxForecastHours))
+	fs.Parse(args)
+
+	loc, hasFlagLoc, err := locFlags.location()
+	switch {
+	case err != nil:
+	case hasFlagLoc:
+	case fs.NArg() > 0:
+		loc, err = locFlags.resolveCity(strings.Join(fs.Args(), " "))
+	default:
+		return fmt.Errorf("usage: weather hourly [--hours N] <city|@favorite>")
+	}
+	if err != nil {
+		return err
+	}
+	usePreferences(loc)
+	if *hours < 1 || *hours > maxForecastHours {
+		return fmt.Errorf("--hours must be between 1 and %d", maxForecastHours)
+	}
+	if !config.Pro {
+		return fmt.Errorf("the hourly forecast needs a paid OpenWeatherMap plan; if yours includes it, set \"pro\": true in the config file (see `weather paths`), otherwise use `weather forecast` for 3-hour steps")
+	}
+
+	data, err := GetHourlyForecast(loc, *hours, apiKeyFromEnv())
+	if err != nil {
+		return fmt.Errorf("fetching hourly forecast for %s: %w", loc, err)
+	}
+	displayHourlyForecast(data)
+	return nil
+}
+
+// displayHourlyForecast prints one row per hour, with a heading per day.
+func displayHourlyForecast(data *ForecastResponse) {
+	fmt.Printf("Hourly Forecast for %s, %s:\n", data.City.Name, data.City.Country)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	dates, byDay := groupForecastByDay(data)
+	for _, date := range dates {
+		fmt.Fprintf(tw, "\n%s\n", date)
+		fmt.Fprintf(tw, "TIME\tTEMP %s\tFEELS %s\tCONDITIONS\tPOP\tWIND %s\n", displayUnits.temp.symbol, displayUnits.temp.symbol, displayUnits.speed.symbol)
+		for _, entry := range byDay[date] {
+			condition := "N/A"
+			if len(entry.Weather) > 0 {
+				condition = entry.Weather[0].Description
+			}
+			fmt.Fprint(tw, lsprintf("%s\t%.*f\t%.*f\t%s\t%.0f%%\t%.*f\n",
+				time.Unix(entry.Dt, 0).Local().Format("15:04"), decimals("temp", 1), showTemp(entry.Main.Temp), decimals("temp", 1), showTemp(entry.Main.FeelsLike),
+				condition, entry.Pop*100, decimals("wind", 1), showSpeed(entry.Wind.Speed)))
+		}
+	}
+	tw.Flush()
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ircFloodBurst and ircFloodInterval bound outgoing traffic: up to
+// ircFloodBurst lines may be sent at once, after which one line is released
+// every ircFloodInterval. This keeps the bot under typical server limits.
+const (
+	ircFloodBurst    = 4
+	ircFloodInterval = 2 * time.Second
+	ircUserCooldown  = 10 * time.Second
+)
+
+// ircMessageBytes is the most text sent in one PRIVMSG. IRC lines are at
+// most 512 bytes, and this leaves room for the command, the channel and the
+// sender prefix servers add when relaying the line.
+const ircMessageBytes = 400
+
+// ircBot is a minimal IRC client that answers weather triggers.
+type ircBot struct {
+	server   string
+	useTLS   bool
+	nick     string
+	password string
+	apiKey   string
+	// channels maps each joined channel to its default city ("" for none).
+	channels map[string]string
+
+	out      *ircQueue // the current connection's
+	lastSeen map[string]time.Time
+}
+
+// ircQueue holds the lines waiting to be sent on one connection. Lines sent
+// after it closed are dropped, so replies meant for a dead connection never
+// go out on the next one ahead of its registration.
+type ircQueue struct {
+	lines chan string
+	done  chan struct{}
+}
+
+func (q *ircQueue) send(line string) {
+	select {
+	case q.lines <- line:
+	case <-q.done:
+	}
+}
+
+// runIRC implements `weather irc`, joining channels and answering
+// `!weather [city]`, `!forecast [city]` and `!briefing [city]`.
+func runIRC(args []string) error {
+	fs := flag.NewFlagSet("irc", flag.ExitOnError)
+	server := fs.String("server", "irc.libera.chat:6697", "IRC server address (host:port)")
+	useTLS := fs.Bool("tls", true, "Connect using TLS")
+	nick := fs.String("nick", "weatherbot", "Bot nickname")
+	var channels stringList
+	fs.Var(&channels, "channel", "Channel to join, optionally with a default city: '#chan' or '#chan=Nairobi' (repeatable)")
+	fs.Parse(args)
+
+	if len(channels) == 0 {
+		return fmt.Errorf("please provide at least one channel using the --channel flag")
+	}
+
+	bot := &ircBot{
+		server:   *server,
+		useTLS:   *useTLS,
+		nick:     *nick,
+		password: os.Getenv("IRC_PASSWORD"),
+		apiKey:   apiKeyFromEnv(),
+		channels: make(map[string]string),
+		lastSeen: make(map[string]time.Time),
+	}
+	for _, c := range channels {
+		name, city, _ := strings.Cut(c, "=")
+		bot.channels[strings.ToLower(name)] = city
+	}
+
+	for {
+		err := bot.run()
+		fmt.Printf("Disconnected from %s: %v; reconnecting in 30s\n", bot.server, err)
+		time.Sleep(30 * time.Second)
+	}
+}
+
+// run holds a single connection open until it fails.
+func (b *ircBot) run() error {
+	var conn net.Conn
+	var err error
+	if b.useTLS {
+		conn, err = tls.Dial("tcp", b.server, nil)
+	} else {
+		conn, err = net.Dial("tcp", b.server)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close()
+
+	b.out = &ircQueue{lines: make(chan string, 64), done: make(chan struct{})}
+	defer close(b.out.done)
+	go b.out.writeLoop(conn)
+
+	if b.password != "" {
+		b.send("PASS " + b.password)
+	}
+	b.send("NICK " + b.nick)
+	b.send("USER " + b.nick + " 0 * :weather-tool bot")
+
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		b.handle(strings.TrimRight(line, "\r\n"))
+	}
+}
+
+// writeLoop sends queued lines, throttled with a token bucket.
+func (q *ircQueue) writeLoop(w io.Writer) {
+	tokens := ircFloodBurst
+	refill := time.NewTicker(ircFloodInterval)
+	defer refill.Stop()
+	for {
+		if tokens == 0 {
+			select {
+			case <-refill.C:
+				tokens++
+			case <-q.done:
+				return
+			}
+			continue
+		}
+		select {
+		case line := <-q.lines:
+			fmt.Fprintf(w, "%s\r\n", line)
+			tokens--
+		case <-refill.C:
+			if tokens < ircFloodBurst {
+				tokens++
+			}
+		case <-q.done:
+			return
+		}
+	}
+}
+
+func (b *ircBot) send(line string) {
+	b.out.send(line)
+}
+
+// handle processes a single line from the server.
+func (b *ircBot) handle(line string) {
+	prefix := ""
+	if strings.HasPrefix(line, ":") {
+		prefix, line, _ = strings.Cut(line[1:], " ")
+	}
+	command, params, _ := strings.Cut(line, " ")
+
+	switch command {
+	case "PING":
+		b.send("PONG " + params)
+	case "001": // RPL_WELCOME: registration complete
+		for channel := range b.channels {
+			b.send("JOIN " + channel)
+		}
+	case "433": // ERR_NICKNAMEINUSE
+		b.nick += "_"
+		b.send("NICK " + b.nick)
+	case "PRIVMSG":
+		target, text, _ := strings.Cut(params, " :")
+		sender, _, _ := strings.Cut(prefix, "!")
+		b.handleMessage(sender, strings.ToLower(target), strings.TrimSpace(text))
+	}
+}
+
+// handleMessage answers triggers in joined channels.
+func (b *ircBot) handleMessage(sender, channel, text string) {
+	defaultCity, joined := b.channels[channel]
+	if !joined {
+		return
+	}
+	trigger, city, _ := strings.Cut(text, " ")
+	if trigger != "!weather" && trigger != "!forecast" && trigger != "!briefing" {
+		return
+	}
+
+	// Per-user cooldown so a single user can't flood the channel through us.
+	if last, ok := b.lastSeen[sender]; ok && time.Since(last) < ircUserCooldown {
+		return
+	}
+	b.lastSeen[sender] = time.Now()
+
+	if city = strings.TrimSpace(city); city == "" {
+		city = defaultCity
+	}
+	if city == "" {
+		b.send(fmt.Sprintf("PRIVMSG %s :Usage: %s <city>", channel, trigger))
+		return
+	}
+
+	out := b.out
+	go func() {
+		var reply string
+		switch trigger {
+		case "!forecast":
+			reply = botForecastReply(city, b.apiKey, 4)
+		case "!briefing":
+			reply = botBriefingReply(city, b.apiKey)
+		default:
+			reply = botWeatherReply(city, b.apiKey)
+		}
+		for _, text := range splitIRCMessage(reply, ircMessageBytes) {
+			out.send(fmt.Sprintf("PRIVMSG %s :%s", channel, text))
+		}
+	}()
+}
+
+// splitIRCMessage splits text into lines of at most limit bytes, at spaces
+// where it can and never inside a UTF-8 character. Line breaks in text
+// start a new line, as IRC can't carry them.
+func splitIRCMessage(text string, limit int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		for len(paragraph) > limit {
+			cut := limit
+			for cut > 0 && !utf8.RuneStart(paragraph[cut]) {
+				cut--
+			}
+			if space := strings.LastIndexByte(paragraph[:cut], ' '); space > 0 {
+				cut = space
+			}
+			lines = append(lines, strings.TrimSpace(paragraph[:cut]))
+			paragraph = strings.TrimSpace(paragraph[cut:])
+		}
+		if paragraph != "" {
+			lines = append(lines, paragraph)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitIRCMessage(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  []string
+	}{
+		{"short", "Nairobi: 24°C", 400, []string{"Nairobi: 24°C"}},
+		{"at spaces", "one two three four", 9, []string{"one two", "three", "four"}},
+		{"line breaks", "Good morning.\nSunrise 6:31 am.\n", 400, []string{"Good morning.", "Sunrise 6:31 am."}},
+		{"long word", "abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"multi-byte characters", "°°°°", 5, []string{"°°", "°°"}},
+		{"empty", "", 400, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitIRCMessage(tt.text, tt.limit); !slices.Equal(got, tt.want) {
+				t.Errorf("splitIRCMessage = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	long := strings.Repeat("Rain likely after 3 pm, clearing by evening. ", 40)
+	for _, line := range splitIRCMessage(long, ircMessageBytes) {
+		if len(line) > ircMessageBytes || !utf8.ValidString(line) {
+			t.Errorf("splitIRCMessage line of %d bytes, valid UTF-8 %v", len(line), utf8.ValidString(line))
+		}
+	}
+}
+
+func TestIRCQueueDropsAfterClose(t *testing.T) {
+	q := &ircQueue{lines: make(chan string, 1), done: make(chan struct{})}
+	q.send("PRIVMSG #a :first")
+	close(q.done)
+	// The queue is full, so this only returns because the queue closed.
+	q.send("PRIVMSG #a :second")
+	if got := <-q.lines; got != "PRIVMSG #a :first" {
+		t.Errorf("queued %q, want the first line", got)
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// localeName selects the locale for numbers and dates. It is set by the
+// global --locale flag or the WEATHER_TOOL_LOCALE environment variable,
+// and falls back to "locale" in the config file, then LC_ALL and LANG.
+var localeName string
+
+// dateNames are a language's weekday (from Sunday) and month names, full
+// and abbreviated.
+type dateNames struct {
+	days, shortDays     [7]string
+	months, shortMonths [12]string
+}
+
+// localizedDates holds the date names for the languages the tool knows;
+// others get English names with their own number format.
+var localizedDates = map[language.Tag]dateNames{
+	language.German: {
+		[7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		[7]string{"So", "Mo", "Di", "Mi", "Do", "Fr", "Sa"},
+		[12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		[12]string{"Jan", "Feb", "Mär", "Apr", "Mai", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Dez"},
+	},
+	language.French: {
+		[7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		[7]string{"dim", "lun", "mar", "mer", "jeu", "ven", "sam"},
+		[12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		[12]string{"janv", "févr", "mars", "avr", "mai", "juin", "juil", "août", "sept", "oct", "nov", "déc"},
+	},
+	language.Spanish: {
+		[7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		[7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+		[12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		[12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+	},
+	language.Italian: {
+		[7]string{"domenica", "lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato"},
+		[7]string{"dom", "lun", "mar", "mer", "gio", "ven", "sab"},
+		[12]string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		[12]string{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+	},
+	language.Portuguese: {
+		[7]string{"domingo", "segunda-feira", "terça-feira", "quarta-feira", "quinta-feira", "sexta-feira", "sábado"},
+		[7]string{"dom", "seg", "ter", "qua", "qui", "sex", "sáb"},
+		[12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		[12]string{"jan", "fev", "mar", "abr", "mai", "jun", "jul", "ago", "set", "out", "nov", "dez"},
+	},
+	language.Dutch: {
+		[7]string{"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"},
+		[7]string{"zo", "ma", "di", "wo", "do", "vr", "za"},
+		[12]string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+		[12]string{"jan", "feb", "mrt", "apr", "mei", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+	},
+	language.Swahili: {
+		[7]string{"Jumapili", "Jumatatu", "Jumanne", "Jumatano", "Alhamisi", "Ijumaa", "Jumamosi"},
+		[7]string{"Jpi", "Jtt", "Jnn", "Jtn", "Alh", "Ijm", "Jms"},
+		[12]string{"Januari", "Februari", "Machi", "Aprili", "Mei", "Juni", "Julai", "Agosti", "Septemba", "Oktoba", "Novemba", "Desemba"},
+		[12]string{"Jan", "Feb", "Mac", "Apr", "Mei", "Jun", "Jul", "Ago", "Sep", "Okt", "Nov", "Des"},
+	},
+}
+
+// sundayFirstRegions and saturdayFirstRegions start the week on Sunday or
+// Saturday, following CLDR; everywhere else starts on Monday.
+var (
+	sundayFirstRegions = []string{
+		"AG", "AS", "BD", "BR", "BS", "BT", "BW", "BZ", "CA", "CO", "DM", "DO", "ET", "GT", "GU", "HK", "HN", "ID", "IL",
+		"IN", "JM", "JP", "KE", "KH", "KR", "LA", "MH", "MM", "MO", "MT", "MX", "MZ", "NI", "NP", "PA", "PE", "PH", "PK",
+		"PR", "PT", "PY", "SA", "SG", "SV", "TH", "TT", "TW", "UM", "US", "VE", "VI", "WS", "YE", "ZA", "ZW",
+	}
+	saturdayFirstRegions = []string{"AE", "AF", "BH", "DJ", "DZ", "EG", "IQ", "IR", "JO", "KW", "LY", "OM", "QA", "SD", "SY"}
+)
+
+// userLocale is the resolved locale; ok is false when none is set or it is
+// the C/POSIX locale, in which case output is left unlocalized.
+type userLocale struct {
+	tag     language.Tag
+	ok      bool
+	printer *message.Printer
+	names   *dateNames
+}
+
+// currentLocale resolves the locale once per run.
+var currentLocale = sync.OnceValue(func() userLocale {
+	name := localeName
+	for _, fallback := range []string{config.Locale, os.Getenv("LC_ALL"), os.Getenv("LANG")} {
+		if name == "" {
+			name = fallback
+		}
+	}
+	// POSIX locale names look like "de_DE.UTF-8@euro".
+	name, _, _ = strings.Cut(name, ".")
+	name, _, _ = strings.Cut(name, "@")
+	if name == "" || name == "C" || name == "POSIX" {
+		return userLocale{}
+	}
+	tag, err := language.Parse(strings.ReplaceAll(name, "_", "-"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring unknown locale %q\n", name)
+		return userLocale{}
+	}
+	l := userLocale{tag: tag, ok: true, printer: message.NewPrinter(tag)}
+	supported := []language.Tag{language.English}
+	for t := range localizedDates {
+		supported = append(supported, t)
+	}
+	if _, i, confidence := language.NewMatcher(supported).Match(tag); confidence >= language.High && i > 0 {
+		names := localizedDates[supported[i]]
+		l.names = &names
+	}
+	return l
+})
+
+// lprintf is fmt.Printf with numbers formatted for the user's locale, e.g.
+// with decimal commas.
+func lprintf(format string, args ...any) {
+	if l := currentLocale(); l.ok {
+		l.printer.Printf(format, args...)
+		return
+	}
+	fmt.Printf(format, args...)
+}
+
+// lsprintf is fmt.Sprintf with numbers formatted for the user's locale.
+func lsprintf(format string, args ...any) string {
+	if l := currentLocale(); l.ok {
+		return l.printer.Sprintf(format, args...)
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// formatDate is t.Format(layout) with weekday and month names in the
+// user's language.
+func formatDate(t time.Time, layout string) string {
+	s := t.Format(layout)
+	names := currentLocale().names
+	if names == nil {
+		return s
+	}
+	day, month := t.Weekday().String(), t.Month().String()
+	replacer := strings.NewReplacer(
+		day, names.days[t.Weekday()], day[:3], names.shortDays[t.Weekday()],
+		month, names.months[t.Month()-1], month[:3], names.shortMonths[t.Month()-1],
+	)
+	return replacer.Replace(s)
+}
+
+// firstWeekday is the day the week starts on in the user's region.
+func firstWeekday() time.Weekday {
+	l := currentLocale()
+	if !l.ok {
+		return time.Monday
+	}
+	region, _ := l.tag.Region()
+	switch {
+	case slices.Contains(sundayFirstRegions, region.String()):
+		return time.Sunday
+	case slices.Contains(saturdayFirstRegions, region.String()):
+		return time.Saturday
+	}
+	return time.Monday
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Mugambi645/weather-tool/pkg/weather"
+)
+
+// Location is what a weather query is about: a free-form city name passed
+// straight to the API, a "zip,country" postal code, an OpenWeatherMap city
+// ID, or coordinates (e.g. from a saved favorite).
+type Location struct {
+	Name      string  `json:"name,omitempty"`
+	Zip       string  `json:"zip,omitempty"`
+	ID        int     `json:"id,omitempty"`
+	Lat       float64 `json:"lat,omitempty"`
+	Lon       float64 `json:"lon,omitempty"`
+	HasCoords bool    `json:"has_coords,omitempty"`
+	// Favorite is the name of the favorite the location was resolved
+	// from, which selects its preferences in the config file.
+	Favorite string `json:"favorite,omitempty"`
+}
+
+// String returns a human-readable description of the location.
+func (l Location) String() string {
+	if l.Name != "" {
+		return l.Name
+	}
+	if l.Zip != "" {
+		return l.Zip
+	}
+	if l.ID != 0 {
+		return fmt.Sprintf("city ID %d", l.ID)
+	}
+	return fmt.Sprintf("%.4f,%.4f", l.Lat, l.Lon)
+}
+
+// query builds the API query parameters identifying the location.
+func (l Location) query(apiKey string) url.Values {
+	q := url.Values{"appid": {apiKey}, "units": {"metric"}}
+	if langName != "" {
+		q.Set("lang", langName)
+	}
+	switch {
+	case l.HasCoords:
+		q.Set("lat", strconv.FormatFloat(l.Lat, 'f', -1, 64))
+		q.Set("lon", strconv.FormatFloat(l.Lon, 'f', -1, 64))
+	case l.Zip != "":
+		q.Set("zip", l.Zip)
+	case l.ID != 0:
+		q.Set("id", strconv.Itoa(l.ID))
+	default:
+		q.Set("q", l.Name)
+	}
+	return q
+}
+
+// resolveLocation turns a location given on the command line into a
+// Location. "@name" refers to a saved favorite; anything else is a city.
+func resolveLocation(arg string) (Location, error) {
+	name, isFavorite := strings.CutPrefix(arg, "@")
+	if !isFavorite {
+		return Location{Name: arg}, nil
+	}
+
+	favorites, err := loadFavorites()
+	if err != nil {
+		return Location{}, err
+	}
+	fav, ok := favorites[name]
+	if !ok {
+		return Location{}, fmt.Errorf("no favorite named %q (see `weather fav list`)", name)
+	}
+	return fav.Location(name), nil
+}
+
+// resolveLocations is like resolveLocation but also expands "@group" into
+// every favorite in that group.
+func resolveLocations(arg string) ([]Location, error) {
+	if name, ok := strings.CutPrefix(arg, "@"); ok {
+		favorites, err := loadFavorites()
+		if err != nil {
+			return nil, err
+		}
+		if _, isFavorite := favorites[name]; !isFavorite {
+			if members := groupMembers(favorites, name); len(members) > 0 {
+				locations := make([]Location, len(members))
+				for i, member := range members {
+					locations[i] = favorites[member].Location(member)
+				}
+				return locations, nil
+			}
+		}
+	}
+	loc, err := resolveLocation(arg)
+	if err != nil {
+		return nil, err
+	}
+	return []Location{loc}, nil
+}
+
+// locationFlags are the flags that select a location other than by name:
+// --zip, --id and --lat/--lon.
+type locationFlags struct {
+	fs      *flag.FlagSet
+	state   *string
+	country *string
+	zip     *string
+	id      *int
+	lat     *float64
+	lon     *float64
+}
+
+// addLocationFlags registers the location flags on fs.
+func addLocationFlags(fs *flag.FlagSet) *locationFlags {
+	return &locationFlags{
+		fs:      fs,
+		state:   fs.String("state", "", "US state code to disambiguate the city (e.g., 'OR' for Portland, Oregon)"),
+		country: fs.String("country", "", "ISO 3166 country code restricting the city or postal code lookup (e.g., 'KE')"),
+		zip:     fs.String("zip", "", "Postal code and country instead of a city (e.g., '10001,US')"),
+		id:      fs.Int("id", 0, "OpenWeatherMap city ID instead of a city (e.g., 184745); see --verbose"),
+		lat:     fs.Float64("lat", 0, "Latitude instead of a city (use with --lon)"),
+		lon:     fs.Float64("lon", 0, "Longitude instead of a city (use with --lat)"),
+	}
+}
+
+// location returns the location selected by the flags, with ok false if
+// none of them were given.
+func (f *locationFlags) location() (loc Location, ok bool, err error) {
+	set := make(map[string]bool)
+	f.fs.Visit(func(fl *flag.Flag) { set[fl.Name] = true })
+
+	coords := set["lat"] || set["lon"]
+	if countSet(set["zip"], set["id"], coords) > 1 {
+		return Location{}, false, fmt.Errorf("please use only one of --zip, --id and --lat/--lon")
+	}
+	if set["state"] && (set["zip"] || set["id"] || coords) {
+		return Location{}, false, fmt.Errorf("--state only applies to city names")
+	}
+	if set["country"] && (set["id"] || coords) {
+		return Location{}, false, fmt.Errorf("--country only applies to city names and postal codes")
+	}
+	switch {
+	case coords:
+		if !set["lat"] || !set["lon"] {
+			return Location{}, false, fmt.Errorf("--lat and --lon must be used together")
+		}
+		if err := weather.ValidateCoordinates(*f.lat, *f.lon); err != nil {
+			return Location{}, false, err
+		}
+		return Location{Lat: *f.lat, Lon: *f.lon, HasCoords: true}, true, nil
+	case set["zip"]:
+		zip := *f.zip
+		if *f.country != "" && !strings.Contains(zip, ",") {
+			zip += "," + strings.ToUpper(*f.country)
+		}
+		return Location{Zip: zip}, true, nil
+	case set["id"]:
+		return Location{ID: *f.id}, true, nil
+	}
+	return Location{}, false, nil
+}
+
+// resolveCity resolves a city name or @favorite given alongside the flags,
+// qualifying plain city names with --state and --country.
+func (f *locationFlags) resolveCity(arg string) (Location, error) {
+	if *f.state == "" && *f.country == "" {
+		return resolveLocation(arg)
+	}
+	if strings.HasPrefix(arg, "@") {
+		return Location{}, fmt.Errorf("--state and --country can't be combined with a favorite")
+	}
+	return Location{Name: qualifyCity(arg, *f.state, *f.country)}, nil
+}
+
+// qualifyCity builds the "city[,state],country" query the API and geocoder
+// expect. Explicit state and country codes override those already in city;
+// a state without a country implies the US, since the API only honors US
+// states. For example "Portland" + "OR" becomes "Portland,OR,US" and
+// "Kisumu" + country "KE" becomes "Kisumu,KE".
+func qualifyCity(city, state, country string) string {
+	if city == "" || (state == "" && country == "") {
+		return city
+	}
+	parts := strings.Split(city, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	if state == "" && len(parts) == 3 {
+		state = parts[1]
+	}
+	if country == "" && len(parts) > 1 {
+		country = parts[len(parts)-1]
+	}
+	if country == "" {
+		country = "US"
+	}
+
+	query := parts[0]
+	if state != "" {
+		query += "," + strings.ToUpper(state)
+	}
+	return query + "," + strings.ToUpper(country)
+}
+
+// coordinates returns loc's latitude and longitude, asking the weather API
+// where loc isn't given as coordinates.
+func (l Location) coordinates(apiKey string) (lat, lon float64, err error) {
+	if l.HasCoords {
+		return l.Lat, l.Lon, nil
+	}
+	current, err := GetCurrentWeatherAt(l, apiKey)
+	if err != nil {
+		return 0, 0, fmt.Errorf("looking up %s: %w", l, err)
+	}
+	return current.Coord.Lat, current.Coord.Lon, nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+const mapTileURL = "https://tile.openweathermap.org/map/%s/%s/%s/%s.png"
+
+// mapLayers are the weather map tile layers, keyed by the names the tool
+// accepts, in the order they are listed.
+var mapLayers = []struct {
+	name, id, description string
+}{
+	{"clouds", "clouds_new", "Cloud cover"},
+	{"precipitation", "precipitation_new", "Precipitation intensity"},
+	{"pressure", "pressure_new", "Sea-level pressure"},
+	{"wind", "wind_new", "Wind speed"},
+	{"temp", "temp_new", "Air temperature"},
+}
+
+// maxMapZoom is the deepest zoom level the tile server renders.
+const maxMapZoom = 18
+
+// mapTileFor returns the tile x and y containing coordinates at zoom z, in
+// the Web Mercator tiling Leaflet and MapLibre use.
+func mapTileFor(lat, lon float64, z int) (x, y int) {
+	n := math.Exp2(float64(z))
+	latRad := lat * math.Pi / 180
+	x = int(math.Floor((lon + 180) / 360 * n))
+	y = int(math.Floor((1 - math.Log(math.Tan(latRad)+1/math.Cos(latRad))/math.Pi) / 2 * n))
+	last := int(n) - 1
+	return min(max(x, 0), last), min(max(y, 0), last)
+}
+
+// runMap implements `weather map layers|url`.
+func runMap(args []string) error {
+	usage := fmt.Errorf("usage: weather map layers | url --layer LAYER [--z ZOOM (--x X --y Y | --lat LAT --lon LON)]")
+	if len(args) == 0 {
+		return usage
+	}
+	switch args[0] {
+	case "layers":
+		if len(args) != 1 {
+			return usage
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "LAYER\tTILE LAYER\tSHOWS")
+		for _, l := range mapLayers {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", l.name, l.id, l.description)
+		}
+		return tw.Flush()
+	case "url":
+		return runMapURL(args[1:])
+	}
+	return usage
+}
+
+func runMapURL(args []string) error {
+	fs := flag.NewFlagSet("map url", flag.ExitOnError)
+	layer := fs.String("layer", "", "Layer to show (see `weather map layers`)")
+	z := fs.Int("z", 6, fmt.Sprintf("Zoom level, 0 to %d", maxMapZoom))
+	x := fs.Int("x", 0, "Tile column")
+	y := fs.Int("y", 0, "Tile row")
+	lat := fs.Float64("lat", 0, "Latitude to find the tile for, instead of --x/--y")
+	lon := fs.Float64("lon", 0, "Longitude to find the tile for, instead of --x/--y")
+	fs.Parse(args)
+
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if fs.NArg() > 0 || *layer == "" {
+		return fmt.Errorf("usage: weather map url --layer LAYER [--z ZOOM (--x X --y Y | --lat LAT --lon LON)]")
+	}
+	id := ""
+	var names []string
+	for _, l := range mapLayers {
+		if l.name == *layer || l.id == *layer {
+			id = l.id
+		}
+		names = append(names, l.name)
+	}
+	if id == "" {
+		return fmt.Errorf("unknown layer %q, use one of: %s", *layer, strings.Join(names, ", "))
+	}
+	if *z < 0 || *z > maxMapZoom {
+		return fmt.Errorf("--z must be between 0 and %d", maxMapZoom)
+	}
+	tile := set["x"] || set["y"]
+	coords := set["lat"] || set["lon"]
+	switch {
+	case tile && coords:
+		return fmt.Errorf("please use either --x/--y or --lat/--lon")
+	case tile && !(set["x"] && set["y"]):
+		return fmt.Errorf("--x and --y must be used together")
+	case coords && !(set["lat"] && set["lon"]):
+		return fmt.Errorf("--lat and --lon must be used together")
+	}
+
+	// Without a tile, print the {z}/{x}/{y} template slippy map libraries
+	// take.
+	zs, xs, ys := "{z}", "{x}", "{y}"
+	if coords {
+		*x, *y = mapTileFor(*lat, *lon, *z)
+	}
+	if tile || coords {
+		if last := 1<<*z - 1; *x < 0 || *x > last || *y < 0 || *y > last {
+			return fmt.Errorf("--x and --y must be between 0 and %d at zoom %d", last, *z)
+		}
+		zs, xs, ys = fmt.Sprint(*z), fmt.Sprint(*x), fmt.Sprint(*y)
+	}
+	fmt.Printf(mapTileURL+"?%s\n", id, zs, xs, ys, url.Values{"appid": {apiKeyFromEnv()}}.Encode())
+	return nil
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// MatrixClient is a minimal Matrix client-server API client, enough to post
+// messages to rooms and follow room timelines for bot commands.
+type MatrixClient struct {
+	Homeserver  string
+	AccessToken string
+	Rooms       []string
+
+	txnID atomic.Int64
+}
+
+// newMatrixClientFromEnv builds a MatrixClient from MATRIX_HOMESERVER,
+// MATRIX_ACCESS_TOKEN and MATRIX_ROOMS (comma-separated room IDs).
+func newMatrixClientFromEnv() (*MatrixClient, error) {
+	c := &MatrixClient{
+		Homeserver:  strings.TrimSuffix(os.Getenv("MATRIX_HOMESERVER"), "/"),
+		AccessToken: os.Getenv("MATRIX_ACCESS_TOKEN"),
+	}
+	c.txnID.Store(time.Now().UnixNano())
+	for _, room := range strings.Split(os.Getenv("MATRIX_ROOMS"), ",") {
+		if room = strings.TrimSpace(room); room != "" {
+			c.Rooms = append(c.Rooms, room)
+		}
+	}
+	if c.Homeserver == "" || c.AccessToken == "" || len(c.Rooms) == 0 {
+		return nil, fmt.Errorf("MATRIX_HOMESERVER, MATRIX_ACCESS_TOKEN and MATRIX_ROOMS must all be set")
+	}
+	return c, nil
+}
+
+func newMatrixNotifierFromEnv() (Notifier, error) {
+	return newMatrixClientFromEnv()
+}
+
+// Notify posts msg to every configured room.
+func (c *MatrixClient) Notify(msg Message) error {
+	text := msg.Title + ": " + msg.Body()
+	for _, room := range c.Rooms {
+		if err := c.Send(room, text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Send posts a plain-text message to a room.
+func (c *MatrixClient) Send(room, text string) error {
+	path := fmt.Sprintf("/_matrix/client/v3/rooms/%s/send/m.room.message/%d", url.PathEscape(room), c.txnID.Add(1))
+	content := map[string]string{"msgtype": "m.text", "body": text}
+	if err := c.do(http.MethodPut, path, content, nil); err != nil {
+		return fmt.Errorf("failed to send message to %s: %w", room, err)
+	}
+	return nil
+}
+
+// matrixSyncResponse is the subset of the /sync response the bot needs.
+type matrixSyncResponse struct {
+	NextBatch string `json:"next_batch"`
+	Rooms     struct {
+		Join map[string]struct {
+			Timeline struct {
+				Events []matrixEvent `json:"events"`
+			} `json:"timeline"`
+		} `json:"join"`
+	} `json:"rooms"`
+}
+
+type matrixEvent struct {
+	Type    string `json:"type"`
+	Sender  string `json:"sender"`
+	Content struct {
+		MsgType string `json:"msgtype"`
+		Body    string `json:"body"`
+	} `json:"content"`
+}
+
+// Sync long-polls the homeserver for new events since the given batch token.
+func (c *MatrixClient) Sync(since string, timeout time.Duration) (*matrixSyncResponse, error) {
+	query := url.Values{"timeout": {strconv.FormatInt(timeout.Milliseconds(), 10)}}
+	if since != "" {
+		query.Set("since", since)
+	}
+	var resp matrixSyncResponse
+	if err := c.do(http.MethodGet, "/_matrix/client/v3/sync?"+query.Encode(), nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to sync: %w", err)
+	}
+	return &resp, nil
+}
+
+// WhoAmI returns the user ID the access token belongs to.
+func (c *MatrixClient) WhoAmI() (string, error) {
+	var resp struct {
+		UserID string `json:"user_id"`
+	}
+	if err := c.do(http.MethodGet, "/_matrix/client/v3/account/whoami", nil, &resp); err != nil {
+		return "", fmt.Errorf("failed to look up bot user: %w", err)
+	}
+	return resp.UserID, nil
+}
+
+func (c *MatrixClient) do(method, path string, body, target interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reqBody = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequest(method, c.Homeserver+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.AccessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("Matrix request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+	if target == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to unmarshal JSON response: %w", err)
+	}
+	return nil
+}
+
+// runMatrix implements `weather matrix`, a long-running bot that posts a
+// daily summary and severe-weather alerts for a city to the configured
+// rooms, and optionally answers `!weather <city>` commands.
+func runMatrix(args []string) error {
+	fs := flag.NewFlagSet("matrix", flag.ExitOnError)
+	city := fs.String("city", "", "City for daily summaries and alerts")
+	summaryAt := fs.String("summary-at", "07:00", "Local time (HH:MM) to post the daily summary")
+	alertInterval := fs.Duration("alert-interval", 30*time.Minute, "How often to check for severe weather (0 disables alerts)")
+	respond := fs.Bool("commands", false, "Respond to !weather <city> commands in the configured rooms")
+	fs.Parse(args)
+
+	if *city == "" {
+		return fmt.Errorf("please provide a city name using the --city flag")
+	}
+	summaryTime, err := time.Parse("15:04", *summaryAt)
+	if err != nil {
+		return fmt.Errorf("invalid --summary-at %q, expected HH:MM", *summaryAt)
+	}
+	client, err := newMatrixClientFromEnv()
+	if err != nil {
+		return err
+	}
+	apiKey := apiKeyFromEnv()
+
+	if *respond {
+		go matrixCommandLoop(client, apiKey)
+	}
+
+	var alertTick <-chan time.Time
+	if *alertInterval > 0 {
+		ticker := time.NewTicker(*alertInterval)
+		defer ticker.Stop()
+		alertTick = ticker.C
+	}
+
+	fmt.Printf("Matrix bot running for %s in %d room(s).\n", *city, len(client.Rooms))
+	wasSevere := false
+	for {
+		summary := time.NewTimer(time.Until(nextDailyAt(time.Now(), summaryTime)))
+		select {
+		case <-summary.C:
+			data, err := GetCurrentWeather(*city, apiKey)
+			if err != nil {
+				fmt.Printf("Error fetching current weather for %s: %v\n", *city, err)
+				continue
+			}
+			if err := client.Notify(currentWeatherMessage(data)); err != nil {
+				fmt.Printf("Error posting daily summary: %v\n", err)
+			}
+		case <-alertTick:
+			summary.Stop()
+			data, err := GetCurrentWeather(*city, apiKey)
+			if err != nil {
+				fmt.Printf("Error fetching current weather for %s: %v\n", *city, err)
+				continue
+			}
+			msg := currentWeatherMessage(data)
+			if msg.Severe && !wasSevere {
+				if err := client.Notify(msg); err != nil {
+					fmt.Printf("Error posting alert: %v\n", err)
+				}
+			}
+			wasSevere = msg.Severe
+		}
+	}
+}
+
+// matrixCommandLoop follows the configured rooms and replies to
+// `!weather <city>` messages. History from before the bot started is skipped.
+func matrixCommandLoop(client *MatrixClient, apiKey string) {
+	self, err := client.WhoAmI()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	rooms := make(map[string]bool)
+	for _, room := range client.Rooms {
+		rooms[room] = true
+	}
+
+	since := ""
+	for {
+		resp, err := client.Sync(since, 30*time.Second)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			time.Sleep(10 * time.Second)
+			continue
+		}
+		initial := since == ""
+		since = resp.NextBatch
+		if initial {
+			continue
+		}
+
+		for roomID, room := range resp.Rooms.Join {
+			if !rooms[roomID] {
+				continue
+			}
+			for _, event := range room.Timeline.Events {
+				if event.Type != "m.room.message" || event.Sender == self {
+					continue
+				}
+				city, ok := strings.CutPrefix(strings.TrimSpace(event.Content.Body), "!weather")
+				if !ok {
+					continue
+				}
+				if city = strings.TrimSpace(city); city == "" {
+					client.Send(roomID, "Usage: !weather <city>")
+					continue
+				}
+				reply := botWeatherReply(city, apiKey)
+				if err := client.Send(roomID, reply); err != nil {
+					fmt.Printf("Error: %v\n", err)
+				}
+			}
+		}
+	}
+}
+
+// nextDailyAt returns the next time after now whose wall clock matches at.
+func nextDailyAt(now time.Time, at time.Time) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), at.Hour(), at.Minute(), 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
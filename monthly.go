@@ -0,0 +1,147 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const climateForecastURL = "https://pro.openweathermap.org/data/2.5/forecast/climate"
+
+// ClimateForecastResponse is the 30-day climatic forecast, one entry per
+// day.
+type ClimateForecastResponse struct {
+	City struct {
+		Name    string `json:"name"`
+		Country string `json:"country"`
+		Coord   Coord  `json:"coord"`
+	} `json:"city"`
+	List []ClimateForecastDay `json:"list"`
+}
+
+// ClimateForecastDay is one day of the climatic forecast. Rain and Snow are
+// in mm and absent on dry days.
+type ClimateForecastDay struct {
+	Dt   int64 `json:"dt"`
+	Temp struct {
+		Day   float64 `json:"day"`
+		Min   float64 `json:"min"`
+		Max   float64 `json:"max"`
+		Night float64 `json:"night"`
+	} `json:"temp"`
+	Pressure float64   `json:"pressure"`
+	Humidity int       `json:"humidity"`
+	Weather  []Weather `json:"weather"`
+	Speed    float64   `json:"speed"`
+	Clouds   int       `json:"clouds"`
+	Rain     float64   `json:"rain"`
+	Snow     float64   `json:"snow"`
+}
+
+// GetClimateForecast fetches the 30-day climatic forecast for a location.
+// It needs a Developer plan or higher.
+func GetClimateForecast(loc Location, apiKey string) (*ClimateForecastResponse, error) {
+	params := loc.query(apiKey)
+	params.Set("cnt", "30")
+	var data ClimateForecastResponse
+	if err := fetchWeatherData(climateForecastURL+"?"+params.Encode(), &data); err != nil {
+		return nil, planError(err, "the 30-day climatic forecast")
+	}
+	return &data, nil
+}
+
+// planError explains a 401 from a Pro endpoint, which is how the API
+// reports that the key's plan doesn't include it.
+func planError(err error, feature string) error {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("your API key's plan doesn't include %s, which needs a paid OpenWeatherMap plan (see https://openweathermap.org/price): %w", feature, err)
+	}
+	return err
+}
+
+// runMonthly implements `weather monthly`, the month-ahead outlook.
+func runMonthly(args []string) error {
+	fs := flag.NewFlagSet("monthly", flag.ExitOnError)
+	locFlags := addLocationFlags(fs)
+	fs.Parse(args)
+
+	loc, hasFlagLoc, err := locFlags.location()
+	switch {
+	case err != nil:
+	case hasFlagLoc:
+	case fs.NArg() > 0:
+		loc, err = locFlags.resolveCity(strings.Join(fs.Args(), " "))
+	default:
+		return fmt.Errorf("usage: weather monthly <city|@favorite>")
+	}
+	if err != nil {
+		return err
+	}
+	usePreferences(loc)
+
+	data, err := GetClimateForecast(loc, apiKeyFromEnv())
+	if err != nil {
+		return fmt.Errorf("fetching the climatic forecast for %s: %w", loc, err)
+	}
+	if len(data.List) == 0 {
+		return fmt.Errorf("no climatic forecast returned for %s", loc)
+	}
+	displayClimateForecast(data)
+	return nil
+}
+
+// displayClimateForecast prints a weekly outlook followed by the daily
+// detail.
+func displayClimateForecast(data *ClimateForecastResponse) {
+	fmt.Printf("30-Day Climatic Forecast for %s, %s:\n\n", data.City.Name, data.City.Country)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	temp := displayUnits.temp.symbol
+	fmt.Fprintf(tw, "WEEK\tMEAN %s\tMIN–MAX %s\tRAIN mm\tWET DAYS\n", temp, temp)
+	// Weeks start on the locale's first day of the week, so the first and
+	// last may be shorter.
+	for start := 0; start < len(data.List); {
+		end := start + 1
+		for end < len(data.List) && time.Unix(data.List[end].Dt, 0).Local().Weekday() != firstWeekday() {
+			end++
+		}
+		week := data.List[start:end]
+		start = end
+		var sum, precipitation float64
+		low, high, wet := week[0].Temp.Min, week[0].Temp.Max, 0
+		for _, d := range week {
+			sum += d.Temp.Day
+			low, high = min(low, d.Temp.Min), max(high, d.Temp.Max)
+			precipitation += d.Rain + d.Snow
+			if d.Rain+d.Snow >= rainDayThreshold {
+				wet++
+			}
+		}
+		fmt.Fprint(tw, lsprintf("%s – %s\t%.*f\t%.*f–%.*f\t%.0f\t%d/%d\n",
+			formatDate(time.Unix(week[0].Dt, 0).Local(), "Jan 2"), formatDate(time.Unix(week[len(week)-1].Dt, 0).Local(), "Jan 2"),
+			decimals("temp", 1), showTemp(sum/float64(len(week))), decimals("temp", 0), showTemp(low), decimals("temp", 0), showTemp(high),
+			precipitation, wet, len(week)))
+	}
+	tw.Flush()
+
+	fmt.Println()
+	fmt.Fprintf(tw, "DATE\tMIN–MAX %s\tRAIN mm\tCONDITIONS\n", temp)
+	for _, d := range data.List {
+		condition := "N/A"
+		if len(d.Weather) > 0 {
+			condition = d.Weather[0].Description
+		}
+		fmt.Fprint(tw, lsprintf("%s\t%.*f–%.*f\t%.1f\t%s\n",
+			formatDate(time.Unix(d.Dt, 0).Local(), "Mon Jan 2"), decimals("temp", 0), showTemp(d.Temp.Min), decimals("temp", 0), showTemp(d.Temp.Max), d.Rain+d.Snow, condition))
+	}
+	tw.Flush()
+
+	fmt.Println("\nNote: beyond the first week or so this is a statistical outlook, not a forecast of specific")
+	fmt.Println("weather. Read it as warmer/cooler or wetter/drier than usual, not as day-by-day detail.")
+}
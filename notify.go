@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// Message is a notification delivered through one or more channels.
+// Parts are ordered by importance so channels with tight length limits
+// can keep the leading parts and drop the rest.
+type Message struct {
+	Title  string
+	Parts  []string
+	Severe bool
+	// Severity is the alert severity for alerts and warnings, "warning",
+	// "severe" or "extreme", and empty otherwise.
+	Severity string
+}
+
+// Body joins all message parts into a single line.
+func (m Message) Body() string {
+	return strings.Join(m.Parts, ", ")
+}
+
+// Notifier delivers messages through a single notification channel.
+type Notifier interface {
+	Notify(msg Message) error
+}
+
+// notifiers maps channel names accepted by --channel to constructors that
+// read their settings from the environment or the config file.
+var notifiers = map[string]func() (Notifier, error){
+	"email":    newEmailNotifierFromConfig,
+	"matrix":   newMatrixNotifierFromEnv,
+	"pushover": newPushoverNotifierFromEnv,
+	"sms":      newTwilioNotifierFromEnv,
+}
+
+// isSevere reports whether the current conditions warrant a severe-weather
+// alert: thunderstorms, heavy rain or snow, squalls and tornadoes, or
+// gale-force winds.
+func isSevere(data *CurrentWeatherResponse) bool {
+	return severeCondition(data) || data.Wind.Speed >= defaultAlertThresholds.SevereWind
+}
+
+// severeCondition reports whether any of the current conditions is a
+// thunderstorm, heavy rain or snow, a squall or a tornado.
+func severeCondition(data *CurrentWeatherResponse) bool {
+	for _, w := range data.Weather {
+		switch {
+		case w.ID >= 200 && w.ID < 300: // Thunderstorm
+			return true
+		case w.ID == 502, w.ID == 503, w.ID == 504, w.ID == 511, w.ID == 522: // Heavy or freezing rain
+			return true
+		case w.ID == 602, w.ID == 622: // Heavy snow
+			return true
+		case w.ID == 771, w.ID == 781: // Squalls, tornado
+			return true
+		}
+	}
+	return false
+}
+
+// currentWeatherMessage summarizes current conditions as a notification.
+func currentWeatherMessage(data *CurrentWeatherResponse) Message {
+	condition := "N/A"
+	if len(data.Weather) > 0 {
+		condition = data.Weather[0].Description
+	}
+	msg := Message{
+		Title: lsprintf("%s, %s", data.Name, data.Sys.Country),
+		Parts: []string{
+			lsprintf("%.*f%s %s", decimals("temp", 0), showTemp(data.Main.Temp), displayUnits.temp.symbol, condition),
+			lsprintf("feels like %.*f%s", decimals("temp", 0), showTemp(data.Main.FeelsLike), displayUnits.temp.symbol),
+			lsprintf("wind %.*f %s", decimals("wind", 1), showSpeed(data.Wind.Speed), displayUnits.speed.symbol),
+			lsprintf("humidity %d%%", data.Main.Humidity),
+			lsprintf("pressure %d hPa", data.Main.Pressure),
+		},
+		Severe: isSevere(data),
+	}
+	if msg.Severe {
+		msg.Title = "SEVERE WEATHER: " + msg.Title
+	}
+	return msg
+}
+
+// runNotify implements `weather notify`, sending a summary of the current
+// weather through the selected channels.
+func runNotify(args []string) error {
+	fs := flag.NewFlagSet("notify", flag.ExitOnError)
+	city := fs.String("city", "", "City to report on")
+	severeOnly := fs.Bool("severe-only", false, "Only send a notification when conditions are severe")
+	var channels stringList
+	fs.Var(&channels, "channel", "Notification channel to use (repeatable): email, matrix, pushover, sms, or an exec channel from the config file")
+	fs.Parse(args)
+
+	if *city == "" {
+		return fmt.Errorf("please provide a city name using the --city flag")
+	}
+	if len(channels) == 0 {
+		return fmt.Errorf("please choose at least one notification channel using the --channel flag")
+	}
+
+	targets, err := newNotifiers(channels)
+	if err != nil {
+		return err
+	}
+
+	data, err := GetCurrentWeather(*city, apiKeyFromEnv())
+	if err != nil {
+		return fmt.Errorf("fetching current weather for %s: %w", *city, err)
+	}
+	msg := currentWeatherMessage(data)
+	if *severeOnly && !msg.Severe {
+		fmt.Printf("No severe weather in %s; nothing sent.\n", *city)
+		return nil
+	}
+
+	if err := sendAll(channels, targets, msg); err != nil {
+		return err
+	}
+	fmt.Printf("Sent %s weather notification via %s.\n", *city, channels.String())
+	return nil
+}
+
+// newNotifiers configures the named notification channels.
+func newNotifiers(channels []string) ([]Notifier, error) {
+	var targets []Notifier
+	for _, name := range channels {
+		var n Notifier
+		var err error
+		if newNotifier, ok := notifiers[name]; ok {
+			n, err = newNotifier()
+		} else if command, ok := config.ExecChannels[name]; ok {
+			n, err = newExecNotifier(command)
+		} else {
+			return nil, fmt.Errorf("unknown notification channel %q", name)
+		}
+		if err == nil {
+			n, err = withQuietHours(name, n)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("configuring %s channel: %w", name, err)
+		}
+		targets = append(targets, n)
+	}
+	return targets, nil
+}
+
+// sendAll delivers msg through every target, even after one fails, and
+// returns the failures joined; channels holds the matching channel names
+// for error messages.
+func sendAll(channels []string, targets []Notifier, msg Message) error {
+	var errs error
+	for i, n := range targets {
+		if err := n.Notify(msg); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("sending via %s: %w", channels[i], err))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSendAll(t *testing.T) {
+	msg := Message{Title: "Nairobi"}
+	up, down, last := &recordingNotifier{}, &recordingNotifier{fail: "Nairobi"}, &recordingNotifier{}
+	err := sendAll([]string{"sms", "matrix", "email"}, []Notifier{up, down, last}, msg)
+	if err == nil || !strings.Contains(err.Error(), "sending via matrix: channel down") {
+		t.Errorf("sendAll error = %v, want the matrix failure", err)
+	}
+	if len(up.sent) != 1 || len(last.sent) != 1 {
+		t.Errorf("sent %d and %d messages around the failing channel, want 1 each", len(up.sent), len(last.sent))
+	}
+
+	// Every failure is reported.
+	err = sendAll([]string{"sms", "matrix"}, []Notifier{&recordingNotifier{fail: "N"}, &recordingNotifier{fail: "N"}}, msg)
+	if err == nil || !strings.Contains(err.Error(), "via sms") || !strings.Contains(err.Error(), "via matrix") {
+		t.Errorf("sendAll error = %v, want both failures", err)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const oneCallURL = "https://api.openweathermap.org/data/3.0/onecall"
+
+// OneCallResponse is the parts of the One Call API 3.0 response the tool
+// uses.
+type OneCallResponse struct {
+	Lat      float64                 `json:"lat"`
+	Lon      float64                 `json:"lon"`
+	Timezone string                  `json:"timezone"`
+	Minutely []MinutelyPrecipitation `json:"minutely"`
+	Alerts   []WeatherAlert          `json:"alerts"`
+}
+
+// MinutelyPrecipitation is the forecast precipitation for one minute, in
+// mm/h.
+type MinutelyPrecipitation struct {
+	Dt            int64   `json:"dt"`
+	Precipitation float64 `json:"precipitation"`
+}
+
+// GetOneCall fetches the One Call API 3.0 data for coordinates, leaving out
+// the parts named in exclude (current, minutely, hourly, daily, alerts).
+// It needs a One Call API 3.0 subscription.
+func GetOneCall(lat, lon float64, exclude []string, apiKey string) (*OneCallResponse, error) {
+	loc := Location{Lat: lat, Lon: lon, HasCoords: true}
+	params := loc.query(apiKey)
+	if len(exclude) > 0 {
+		params.Set("exclude", strings.Join(exclude, ","))
+	}
+	var data OneCallResponse
+	if err := fetchWeatherData(oneCallURL+"?"+params.Encode(), &data); err != nil {
+		return nil, planError(err, "the One Call API 3.0")
+	}
+	return &data, nil
+}
+
+// precipitationLevels are the upper bounds in mm/h of the intensities
+// drawn by nowcastStrip: none, light, moderate, heavy and violent.
+var precipitationLevels = []struct {
+	below float64
+	char  rune
+}{{0.01, '·'}, {0.5, '▁'}, {2.5, '▃'}, {10, '▅'}, {50, '▇'}}
+
+// nowcastStrip draws one character per minute, taller for heavier rain.
+func nowcastStrip(minutes []MinutelyPrecipitation) string {
+	var b strings.Builder
+	for _, m := range minutes {
+		char := '█'
+		for _, level := range precipitationLevels {
+			if m.Precipitation < level.below {
+				char = level.char
+				break
+			}
+		}
+		b.WriteRune(char)
+	}
+	return b.String()
+}
+
+// nowcastSummary describes when rain starts or stops in the coming hour,
+// e.g. "Rain starting in ~22 min (:37), stopping by :55".
+func nowcastSummary(minutes []MinutelyPrecipitation, now time.Time) string {
+	wet := func(m MinutelyPrecipitation) bool { return m.Precipitation >= precipitationLevels[0].below }
+	next := func(from int, want bool) int {
+		for i := from; i < len(minutes); i++ {
+			if wet(minutes[i]) == want {
+				return i
+			}
+		}
+		return -1
+	}
+	in := func(i int) string {
+		at := time.Unix(minutes[i].Dt, 0).Local()
+		return fmt.Sprintf("~%d min (:%02d)", max(int(at.Sub(now).Round(time.Minute).Minutes()), 1), at.Minute())
+	}
+
+	if wet(minutes[0]) {
+		stop := next(0, false)
+		if stop < 0 {
+			return "Rain continuing for at least the next hour"
+		}
+		summary := "Rain stopping in " + in(stop)
+		if again := next(stop, true); again >= 0 {
+			summary += ", starting again in " + in(again)
+		}
+		return summary
+	}
+	start := next(0, true)
+	if start < 0 {
+		return "No rain expected in the next hour"
+	}
+	summary := "Rain starting in " + in(start)
+	if stop := next(start, false); stop >= 0 {
+		summary += fmt.Sprintf(", stopping by :%02d", time.Unix(minutes[stop].Dt, 0).Local().Minute())
+	}
+	return summary
+}
+
+// runNowcast implements `weather nowcast`, the next hour's precipitation
+// minute by minute.
+func runNowcast(args []string) error {
+	fs := flag.NewFlagSet("nowcast", flag.ExitOnError)
+	locFlags := addLocationFlags(fs)
+	fs.Parse(args)
+
+	loc, hasFlagLoc, err := locFlags.location()
+	switch {
+	case err != nil:
+	case hasFlagLoc:
+	case fs.NArg() > 0:
+		loc, err = locFlags.resolveCity(strings.Join(fs.Args(), " "))
+	default:
+		return fmt.Errorf("usage: weather nowcast <city|@favorite>")
+	}
+	if err != nil {
+		return err
+	}
+	usePreferences(loc)
+
+	apiKey := apiKeyFromEnv()
+	lat, lon, err := loc.coordinates(apiKey)
+	if err != nil {
+		return err
+	}
+	data, err := GetOneCall(lat, lon, []string{"current", "hourly", "daily", "alerts"}, apiKey)
+	if err != nil {
+		return fmt.Errorf("fetching nowcast for %s: %w", loc, err)
+	}
+	if len(data.Minutely) == 0 {
+		return fmt.Errorf("no minute-by-minute forecast is available for %s", loc)
+	}
+
+	now := time.Now()
+	fmt.Printf("Next hour at %s: %s.\n\n", loc, nowcastSummary(data.Minutely, now))
+	fmt.Println(nowcastStrip(data.Minutely))
+	axis := []rune(strings.Repeat(" ", len(data.Minutely)))
+	for i := range data.Minutely {
+		if i%15 == 0 && i+5 <= len(axis) {
+			label := []rune(time.Unix(data.Minutely[i].Dt, 0).Local().Format("15:04"))
+			copy(axis[i:], label)
+		}
+	}
+	fmt.Println(strings.TrimRight(string(axis), " "))
+	var peak float64
+	for _, m := range data.Minutely {
+		peak = max(peak, m.Precipitation)
+	}
+	if peak > 0 {
+		fmt.Printf("\nHeaviest: %.1f mm/h\n", peak)
+	}
+	return nil
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// outputFormat pairs the current-weather and forecast display functions for
+// one --output value.
+type outputFormat struct {
+	current  func(*CurrentWeatherResponse)
+	forecast func(*ForecastResponse)
+}
+
+var outputFormats = map[string]outputFormat{
+	"text":   {displayCurrentWeather, displayForecast},
+	"alfred": {displayCurrentWeatherAlfred, displayForecastAlfred},
+	"i3":     {displayCurrentWeatherI3, displayForecastI3},
+	"conky":  {displayCurrentWeatherConky, displayForecastConky},
+	"plain":  {displayCurrentWeatherPlain, displayForecastPlain},
+	"line":   {displayCurrentWeatherLine, displayForecastLine},
+	"json": {
+		inDisplayUnits(convertCurrentUnits, printJSON[*CurrentWeatherResponse]),
+		inDisplayUnits(convertForecastUnits, printJSON[*ForecastResponse]),
+	},
+	"yaml": {
+		inDisplayUnits(convertCurrentUnits, printYAML[*CurrentWeatherResponse]),
+		inDisplayUnits(convertForecastUnits, printYAML[*ForecastResponse]),
+	},
+}
+
+// outputFormatNames lists the accepted --output values for help text.
+func outputFormatNames() string {
+	names := make([]string, 0, len(outputFormats))
+	for name := range outputFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// displayOptions holds the output flags shared by the classic flag interface
+// and the location subcommands.
+type displayOptions struct {
+	output    *string
+	schema    *string
+	plain     *bool
+	copy      *bool
+	verbose   *bool
+	script    *string
+	feelsLike *string
+	cacheTTL  *time.Duration
+	noCache   *bool
+	daily     *bool
+}
+
+// addDisplayFlags registers the output flags on fs.
+func addDisplayFlags(fs *flag.FlagSet) displayOptions {
+	return displayOptions{
+		output:  fs.String("output", "", "Output format: "+outputFormatNames()+" (default text, or \"output\" from the config file)"),
+		schema:  fs.String("schema", "", "Versioned document shape for --output json and yaml: "+outputSchemaNames()+" (default the OpenWeatherMap API's own)"),
+		plain:   fs.Bool("plain", false, "Screen-reader friendly output: short sentences, no symbols or decoration (same as --output plain)"),
+		copy:    fs.Bool("copy", false, "Also copy the printed output to the clipboard (combine with --output line for a one-line summary)"),
+		verbose: fs.Bool("verbose", false, "Print details about the resolved location (city ID, coordinates) to stderr"),
+		script:  fs.String("script", "", "Starlark file defining current(data) and forecast(data) to render the output instead of --output"),
+		feelsLike: fs.String("feels-like-algo", "", "Feels-like temperature to show: api (the API's own), aat (Australian apparent temperature) "+
+			"or nws (US heat index and wind chill); default \"feels_like_algo\" from the config file, or api"),
+		cacheTTL: fs.Duration("cache-ttl", defaultCacheTTL, "Reuse weather fetched less than this long ago from the cache directory; 0 disables the cache"),
+		noCache:  fs.Bool("no-cache", false, "Fetch fresh weather, ignoring and not updating the cache"),
+		daily:    fs.Bool("daily", false, "Show the forecast as one summary per day instead of 3-hour steps (implies --forecast)"),
+	}
+}
+
+// maxParallelFetches bounds how many locations showWeatherFor fetches at
+// once, to stay friendly to the API's rate limits.
+const maxParallelFetches = 4
+
+// weatherView is how showWeatherFor presents the weather, resolved from
+// displayOptions.
+type weatherView struct {
+	name      string
+	output    outputFormat
+	feelsLike func(temp, humidity, wind float64) float64
+	script    *viewScript
+	ttl       time.Duration
+	verbose   bool
+	daily     bool
+}
+
+// view resolves opts against the config file.
+func (opts displayOptions) view() (weatherView, error) {
+	v := weatherView{name: *opts.output, ttl: *opts.cacheTTL, verbose: *opts.verbose, daily: *opts.daily}
+	if v.name == "" {
+		v.name = config.Output
+	}
+	if v.name == "" {
+		v.name = "text"
+	}
+	if *opts.plain {
+		v.name = "plain"
+	}
+	var ok bool
+	if v.output, ok = outputFormats[v.name]; !ok {
+		return v, fmt.Errorf("unknown output format %q, use one of: %s", v.name, outputFormatNames())
+	}
+	var err error
+	if *opts.schema != "" {
+		if v.output, err = schemaOutput(v.name, *opts.schema); err != nil {
+			return v, err
+		}
+	}
+	if v.daily {
+		daily, ok := dailyForecastFormats[v.name]
+		switch {
+		case !ok:
+			return v, fmt.Errorf("--daily needs --output text, plain, json or yaml, not %s", v.name)
+		case *opts.schema != "":
+			return v, fmt.Errorf("--daily can't be combined with --schema")
+		case *opts.script != "":
+			return v, fmt.Errorf("--daily can't be combined with --script")
+		}
+		v.output.forecast = daily
+	}
+	if v.feelsLike, err = feelsLikeAlgorithm(*opts.feelsLike); err != nil {
+		return v, err
+	}
+	if *opts.script != "" {
+		if v.script, err = loadScript(*opts.script); err != nil {
+			return v, err
+		}
+	}
+	if *opts.noCache {
+		v.ttl = 0
+	}
+	return v, nil
+}
+
+// showWeather fetches the current weather or forecast for loc and displays
+// it according to opts.
+func showWeather(loc Location, forecast bool, opts displayOptions, apiKey string) error {
+	return showWeatherFor([]Location{loc}, forecast, opts, apiKey)
+}
+
+// showWeatherFor fetches the weather for each of locs, up to
+// maxParallelFetches at a time, then displays them in order. A location
+// that fails doesn't stop the others; their errors are returned together
+// once the rest have been shown.
+func showWeatherFor(locs []Location, forecast bool, opts displayOptions, apiKey string) error {
+	v, err := opts.view()
+	if err != nil {
+		return err
+	}
+	forecast = forecast || v.daily
+	displays := make([]func(), len(locs))
+	errs := make([]error, len(locs))
+	var g errgroup.Group
+	g.SetLimit(maxParallelFetches)
+	for i, loc := range locs {
+		g.Go(func() error {
+			displays[i], errs[i] = v.prepare(loc, forecast, apiKey)
+			return nil
+		})
+	}
+	g.Wait()
+
+	shown := 0
+	display := func() {
+		for _, d := range displays {
+			if d == nil {
+				continue
+			}
+			if shown > 0 {
+				switch v.name {
+				case "text", "plain":
+					fmt.Println()
+				case "yaml":
+					fmt.Println("---")
+				}
+			}
+			d()
+			shown++
+		}
+	}
+	for i, loc := range locs {
+		// History is a convenience; failing to record it isn't worth an
+		// error.
+		if errs[i] == nil {
+			recordHistory(loc, forecast)
+		}
+	}
+	if !*opts.copy {
+		display()
+	} else if printed, err := teeStdout(display); err != nil {
+		return err
+	} else if err := copyToClipboard(printed); err != nil {
+		return err
+	}
+
+	if len(locs) == 1 {
+		return errs[0]
+	}
+	var failed []error
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d locations failed:\n%w", len(failed), len(locs), errors.Join(failed...))
+	}
+	return nil
+}
+
+// prepare fetches the current weather or forecast for loc and returns the
+// func displaying it.
+func (v weatherView) prepare(loc Location, forecast bool, apiKey string) (func(), error) {
+	// Raw coordinates get a human place name from reverse geocoding; the
+	// weather API's own name for them is often just the nearest station.
+	// It is looked up on a cache miss and cached with the data.
+	nearby := func() *GeoLocation {
+		if !loc.HasCoords || loc.Name != "" {
+			return nil
+		}
+		if places, err := ReverseGeocode(loc.Lat, loc.Lon, 1, apiKey); err == nil && len(places) > 0 {
+			return &places[0]
+		}
+		return nil
+	}
+
+	start := time.Now()
+	var fetchedAt time.Time
+	var err error
+
+	var display func()
+	if forecast {
+		forecastData := &ForecastResponse{}
+		fetchedAt, err = fetchCachedFunc("forecast", loc, v.ttl, forecastData, func() error {
+			if err := fetchWithHooks("forecast", loc, apiKey, forecastData); err != nil {
+				return err
+			}
+			if place := nearby(); place != nil {
+				forecastData.City.Name, forecastData.City.Country = "near "+place.Name, place.Country
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("fetching forecast for %s: %w", loc, withSuggestions(err, loc, apiKey))
+		}
+		for i := range forecastData.List {
+			applyFeelsLike(v.feelsLike, &forecastData.List[i].Main, forecastData.List[i].Wind.Speed)
+		}
+		if v.verbose {
+			printResolvedLocation(forecastData.City.Name, forecastData.City.Country, forecastData.City.ID, forecastData.City.Coord)
+		}
+		if err := runHooks(hookPreRender, "forecast", loc, forecastData); err != nil {
+			return nil, err
+		}
+		display = func() { v.output.forecast(forecastData) }
+		if v.script != nil {
+			text, err := v.script.render("forecast", forecastData)
+			if err != nil {
+				return nil, err
+			}
+			display = func() { fmt.Print(text) }
+		}
+	} else {
+		weatherData := &CurrentWeatherResponse{}
+		fetchedAt, err = fetchCachedFunc("current", loc, v.ttl, weatherData, func() error {
+			if err := fetchWithHooks("current", loc, apiKey, weatherData); err != nil {
+				return err
+			}
+			if place := nearby(); place != nil {
+				weatherData.Name, weatherData.Sys.Country = "near "+place.Name, place.Country
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("fetching current weather for %s: %w", loc, withSuggestions(err, loc, apiKey))
+		}
+		applyFeelsLike(v.feelsLike, &weatherData.Main, weatherData.Wind.Speed)
+		if v.verbose {
+			printResolvedLocation(weatherData.Name, weatherData.Sys.Country, weatherData.ID, weatherData.Coord)
+		}
+		if err := runHooks(hookPreRender, "current", loc, weatherData); err != nil {
+			return nil, err
+		}
+		display = func() { v.output.current(weatherData) }
+		if v.script != nil {
+			text, err := v.script.render("current", weatherData)
+			if err != nil {
+				return nil, err
+			}
+			display = func() { fmt.Print(text) }
+		}
+	}
+
+	// Say when the text outputs show cached data; the others carry the
+	// data's own timestamps.
+	if fetchedAt.Before(start) && v.script == nil && (v.name == "text" || v.name == "plain") {
+		shown := display
+		display = func() {
+			shown()
+			fmt.Println(dataAge(fetchedAt))
+		}
+	}
+	return display, nil
+}
+
+// printResolvedLocation reports which place the API resolved a query to, so
+// users can pin its city ID or coordinates.
+func printResolvedLocation(name, country string, id int, coord Coord) {
+	fmt.Fprintf(os.Stderr, "Resolved location: %s, %s (city ID %d, lat %.4f, lon %.4f)\n", name, country, id, coord.Lat, coord.Lon)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// appName names the tool's directory inside each platform directory.
+const appName = "weather-tool"
+
+// profile selects an entirely separate set of user data (config, favorites,
+// history, cache) under each platform directory. It is set by the global
+// --profile flag or the WEATHER_TOOL_PROFILE environment variable; empty
+// means the default set.
+var profile string
+
+// configFile overrides the config file location. It is set by the global
+// --config flag or the WEATHER_TOOL_CONFIG environment variable.
+var configFile string
+
+// Platform directory kinds.
+const (
+	dirConfig = "config" // settings and favorites
+	dirCache  = "cache"  // disposable data
+	dirState  = "state"  // history and other data worth keeping
+	dirLogs   = "logs"   // log files
+)
+
+// platformBase returns the base directory for a kind of data, following the
+// XDG base directory spec on Linux and other Unixes, Application Support /
+// Caches / Logs on macOS, and AppData on Windows.
+func platformBase(kind string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate home directory: %w", err)
+	}
+
+	switch runtime.GOOS {
+	case "darwin":
+		library := filepath.Join(home, "Library")
+		switch kind {
+		case dirCache:
+			return filepath.Join(library, "Caches"), nil
+		case dirLogs:
+			return filepath.Join(library, "Logs"), nil
+		default:
+			return filepath.Join(library, "Application Support"), nil
+		}
+
+	case "windows":
+		if kind == dirConfig {
+			return envOr("APPDATA", filepath.Join(home, "AppData", "Roaming")), nil
+		}
+		local := envOr("LOCALAPPDATA", filepath.Join(home, "AppData", "Local"))
+		return local, nil
+
+	default:
+		switch kind {
+		case dirCache:
+			return envOr("XDG_CACHE_HOME", filepath.Join(home, ".cache")), nil
+		case dirConfig:
+			return envOr("XDG_CONFIG_HOME", filepath.Join(home, ".config")), nil
+		default:
+			return envOr("XDG_STATE_HOME", filepath.Join(home, ".local", "state")), nil
+		}
+	}
+}
+
+// appDir returns the tool's directory for a kind of data in the active
+// profile, creating it if needed.
+func appDir(kind string) (string, error) {
+	base, err := platformBase(kind)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(base, appName)
+	// On Windows cache, state and logs share LocalAppData, and on macOS config
+	// and state share Application Support, so keep them apart by kind.
+	switch {
+	case runtime.GOOS == "windows" && kind != dirConfig:
+		dir = filepath.Join(dir, kind)
+	case runtime.GOOS == "darwin" && kind == dirState:
+		dir = filepath.Join(dir, kind)
+	case runtime.GOOS != "darwin" && runtime.GOOS != "windows" && kind == dirLogs:
+		dir = filepath.Join(dir, kind)
+	}
+	if profile != "" {
+		dir = filepath.Join(dir, "profiles", profile)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create %s directory: %w", kind, err)
+	}
+	return dir, nil
+}
+
+// configDir returns the directory holding settings and favorites.
+func configDir() (string, error) { return appDir(dirConfig) }
+
+// cacheDir returns the directory for disposable cached data.
+func cacheDir() (string, error) { return appDir(dirCache) }
+
+// stateDir returns the directory for history and similar state.
+func stateDir() (string, error) { return appDir(dirState) }
+
+// logDir returns the directory for log files.
+func logDir() (string, error) { return appDir(dirLogs) }
+
+// configPath returns the config file in use: --config / WEATHER_TOOL_CONFIG
+// if given, otherwise config.json in the config directory.
+func configPath() (string, error) {
+	if configFile != "" {
+		return configFile, nil
+	}
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.json"), nil
+}
+
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// extractGlobalFlags removes global flags (--profile, --config,
+// --provider, --locale, --units and --lang) from the
+// front of the command line and applies them, returning the remaining
+// arguments.
+func extractGlobalFlags(args []string) ([]string, error) {
+	profile = os.Getenv("WEATHER_TOOL_PROFILE")
+	configFile = os.Getenv("WEATHER_TOOL_CONFIG")
+	provider = os.Getenv("WEATHER_TOOL_PROVIDER")
+	localeName = os.Getenv("WEATHER_TOOL_LOCALE")
+	unitsName = os.Getenv("WEATHER_TOOL_UNITS")
+	langName = os.Getenv("WEATHER_TOOL_LANG")
+	precisionSpec = os.Getenv("WEATHER_TOOL_PRECISION")
+	globals := map[string]*string{
+		"profile": &profile, "config": &configFile, "provider": &provider,
+		"locale": &localeName, "units": &unitsName, "lang": &langName,
+		"precision": &precisionSpec,
+	}
+
+	for len(args) > 0 {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(args[0], "-"), "=")
+		target, ok := globals[name]
+		if !ok || !strings.HasPrefix(args[0], "-") {
+			return args, nil
+		}
+		if !hasValue {
+			if len(args) < 2 {
+				return nil, fmt.Errorf("--%s requires a value", name)
+			}
+			value, args = args[1], args[1:]
+		}
+		*target = value
+		args = args[1:]
+	}
+	return args, nil
+}
+
+// runPaths implements `weather paths`, showing where the tool keeps its
+// files.
+func runPaths(args []string) error {
+	config, err := configPath()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Config file: %s\n", config)
+	for _, d := range []struct {
+		label string
+		dir   func() (string, error)
+	}{
+		{"Config dir", configDir},
+		{"Cache dir", cacheDir},
+		{"State dir", stateDir},
+		{"Log dir", logDir},
+		{"Plugins dir", pluginsDir},
+	} {
+		path, err := d.dir()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%-12s %s\n", d.label+":", path)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// seaLevelDensity is the ISA air density at sea level, in kg/m³.
+const seaLevelDensity = 1.225
+
+// airDensity returns the density of moist air in kg/m³ from the
+// temperature in °C, relative humidity in % and station pressure in hPa,
+// as the sum of the dry air and water vapour partial densities.
+func airDensity(temp, humidity, pressure float64) float64 {
+	const rDry, rVapour = 287.058, 461.495 // J/(kg·K)
+	vapour := humidity / 100 * vaporPressure(temp) * 100
+	dry := pressure*100 - vapour
+	kelvin := temp + kelvinOffset
+	return dry/(rDry*kelvin) + vapour/(rVapour*kelvin)
+}
+
+// pressureAltitude returns the ISA altitude in metres at which the
+// standard pressure is pressure hPa.
+func pressureAltitude(pressure float64) float64 {
+	return 44330.8 * (1 - math.Pow(pressure/1013.25, 0.190263))
+}
+
+// densityAltitude returns the ISA altitude in metres at which the standard
+// air density is density kg/m³.
+func densityAltitude(density float64) float64 {
+	return 44330.8 * (1 - math.Pow(density/seaLevelDensity, 0.234969))
+}
+
+// stationPressureAt estimates the pressure at elevation metres from the
+// sea-level pressure, with the ISA barometric formula.
+func stationPressureAt(seaLevel, elevation float64) float64 {
+	return seaLevel * math.Pow(1-2.25577e-5*elevation, 5.25588)
+}
+
+// aerobicPower returns the fraction of sea-level aerobic power (VO2max)
+// available at altitude metres, from Bassett et al. (1999), for athletes
+// acclimatized to the altitude or not.
+func aerobicPower(altitude float64, acclimatized bool) float64 {
+	km := max(altitude, 0) / 1000
+	if acclimatized {
+		return min((-1.12*km*km-1.90*km+99.9)/100, 1)
+	}
+	return min((0.178*km*km*km-1.43*km*km-4.07*km+100)/100, 1)
+}
+
+// runningDragShare is the share of the energy cost of running at race pace
+// spent on air resistance at sea level (Pugh, 1971).
+const runningDragShare = 0.04
+
+// performanceFactors are how much faster (above 1) or slower the same
+// effort is than at sea level in standard air.
+type performanceFactors struct {
+	density, relativeDensity float64
+	power                    float64 // fraction of sea-level aerobic power
+	cycling, running         float64 // speed ratios on the flat
+}
+
+// performanceAt computes the factors for air of density kg/m³ at a
+// pressure altitude. Cycling on the flat is dominated by drag, so its
+// speed goes with the cube root of power over density; running only
+// spends runningDragShare of its energy on drag.
+func performanceAt(density, altitude float64, acclimatized bool) performanceFactors {
+	f := performanceFactors{density: density, relativeDensity: density / seaLevelDensity}
+	f.power = aerobicPower(altitude, acclimatized)
+	f.cycling = math.Cbrt(f.power / f.relativeDensity)
+	f.running = f.power / (1 - runningDragShare*(1-f.relativeDensity))
+	return f
+}
+
+// indexCommands are the `weather index` subcommands.
+var indexCommands = map[string]func(args []string) error{
+	"performance": runIndexPerformance,
+}
+
+// runIndex implements `weather index <name>`.
+func runIndex(args []string) error {
+	names := make([]string, 0, len(indexCommands))
+	for name := range indexCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(args) == 0 || indexCommands[args[0]] == nil {
+		return fmt.Errorf("usage: weather index %s [flags] <city|@favorite>", strings.Join(names, "|"))
+	}
+	return indexCommands[args[0]](args[1:])
+}
+
+// runIndexPerformance implements `weather index performance`, the air
+// density at a location and what it means for cyclists and runners.
+func runIndexPerformance(args []string) error {
+	fs := flag.NewFlagSet("index performance", flag.ExitOnError)
+	locFlags := addLocationFlags(fs)
+	elevation := fs.Float64("elevation", math.NaN(), "Elevation in metres, to estimate the station pressure when the API only reports sea-level pressure")
+	acclimatized := fs.Bool("acclimatized", false, "Assume the athlete is acclimatized to the altitude")
+	pace := fs.String("pace", "", "A pace held here, as M:SS per km or mile, to convert to its sea-level equivalent")
+	power := fs.Float64("power", 0, "A power in watts held here, to convert to its sea-level equivalent")
+	fs.Parse(args)
+
+	loc, hasFlagLoc, err := locFlags.location()
+	switch {
+	case err != nil:
+	case hasFlagLoc:
+	case fs.NArg() > 0:
+		loc, err = locFlags.resolveCity(strings.Join(fs.Args(), " "))
+	case config.DefaultCity != "":
+		loc, err = resolveLocation(config.DefaultCity)
+	default:
+		return fmt.Errorf("usage: weather index performance [--elevation M] [--acclimatized] [--pace M:SS] [--power W] <city|@favorite>")
+	}
+	if err != nil {
+		return err
+	}
+	usePreferences(loc)
+	var paceTime time.Duration
+	if *pace != "" {
+		if paceTime, err = parsePace(*pace); err != nil {
+			return err
+		}
+	}
+
+	current, err := GetCurrentWeatherAt(loc, apiKeyFromEnv())
+	if err != nil {
+		return fmt.Errorf("fetching current weather for %s: %w", loc, err)
+	}
+	pressure, source := float64(current.Main.GrndLevel), "reported"
+	switch {
+	case pressure > 0:
+	case !math.IsNaN(*elevation):
+		pressure, source = stationPressureAt(float64(current.Main.Pressure), *elevation), lsprintf("estimated for %.0f m", *elevation)
+	default:
+		pressure, source = float64(current.Main.Pressure), "sea level; pass --elevation for a better estimate"
+	}
+	density := airDensity(current.Main.Temp, float64(current.Main.Humidity), pressure)
+	altitude := pressureAltitude(pressure)
+	f := performanceAt(density, altitude, *acclimatized)
+
+	acclimatization := "not acclimatized"
+	if *acclimatized {
+		acclimatization = "acclimatized"
+	}
+	lprintf("Performance conditions for %s, %s:\n", current.Name, current.Sys.Country)
+	lprintf("  Air: %.1f%s, %d%% humidity, %.0f hPa (%s)\n", showTemp(current.Main.Temp), displayUnits.temp.symbol, current.Main.Humidity, pressure, source)
+	lprintf("  Air density: %.3f kg/m³ (%.0f%% of sea-level standard)\n", density, f.relativeDensity*100)
+	lprintf("  Pressure altitude: %.0f m, density altitude: %.0f m\n", altitude, densityAltitude(density))
+	lprintf("  Aerobic power: %.0f%% of sea level (%s)\n", f.power*100, acclimatization)
+	lprintf("  Cycling: drag %s, the same effort is %s on the flat\n", percentChange(f.relativeDensity, "lower", "higher"), percentChange(f.cycling, "slower", "faster"))
+	lprintf("  Running: the same effort is %s\n", percentChange(f.running, "slower", "faster"))
+	if paceTime > 0 {
+		equivalent := time.Duration(float64(paceTime) * f.running)
+		lprintf("  Pace: %s here is like %s at sea level\n", formatPace(paceTime), formatPace(equivalent))
+	}
+	if *power > 0 {
+		lprintf("  Power: %.0f W here is like %.0f W at sea level\n", *power, *power/f.power)
+	}
+	return nil
+}
+
+// percentChange describes ratio as a percentage change from 1, e.g. "8%
+// faster".
+func percentChange(ratio float64, less, more string) string {
+	change := (ratio - 1) * 100
+	switch {
+	case math.Abs(change) < 0.5:
+		return "about the same as at sea level"
+	case change < 0:
+		return lsprintf("%.0f%% %s", -change, less)
+	}
+	return lsprintf("%.0f%% %s", change, more)
+}
+
+// parsePace parses a pace such as "4:35".
+func parsePace(s string) (time.Duration, error) {
+	minutes, seconds, ok := strings.Cut(s, ":")
+	m, err1 := strconv.Atoi(minutes)
+	sec, err2 := strconv.Atoi(seconds)
+	if !ok || err1 != nil || err2 != nil || m < 0 || sec < 0 || sec > 59 || m+sec == 0 {
+		return 0, fmt.Errorf("invalid --pace %q, use M:SS, e.g. 4:35", s)
+	}
+	return time.Duration(m)*time.Minute + time.Duration(sec)*time.Second, nil
+}
+
+// formatPace formats a pace as M:SS.
+func formatPace(d time.Duration) string {
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%d:%02d", d/time.Minute, d%time.Minute/time.Second)
+}
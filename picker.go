@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// pickerVisibleItems is the number of candidates shown at once.
+const pickerVisibleItems = 10
+
+// pickerSearchDelay is how long typing must pause before the picker runs a
+// live geocoding search for the current query.
+const pickerSearchDelay = 300 * time.Millisecond
+
+// pickerItem is a selectable candidate in the interactive picker.
+type pickerItem struct {
+	Label    string
+	Location Location
+}
+
+// canPick reports whether the interactive picker can be shown, i.e. both
+// input and the picker's output (stderr) are terminals.
+func canPick() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stderr.Fd()))
+}
+
+// pickLocation opens an interactive fuzzy finder over saved favorites and
+// recent queries, plus live geocoding results for what has been typed, and returns the location
+// selected with Enter.
+func pickLocation(apiKey string) (Location, error) {
+	favorites, err := loadFavorites()
+	if err != nil {
+		return Location{}, err
+	}
+	var local []pickerItem
+	for _, name := range sortedFavoriteNames(favorites) {
+		fav := favorites[name]
+		local = append(local, pickerItem{Label: "@" + name + "  " + fav.Place, Location: fav.Location(name)})
+	}
+	history, err := loadHistory()
+	if err != nil {
+		return Location{}, err
+	}
+	seen := make(map[Location]bool)
+	for _, h := range history {
+		if !seen[h.Location] {
+			seen[h.Location] = true
+			local = append(local, pickerItem{Label: "recent  " + h.Location.String(), Location: h.Location})
+		}
+	}
+
+	state, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return Location{}, fmt.Errorf("failed to open interactive picker: %w", err)
+	}
+	defer term.Restore(int(os.Stdin.Fd()), state)
+
+	keys := make(chan []byte)
+	go func() {
+		for {
+			buf := make([]byte, 16)
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				close(keys)
+				return
+			}
+			keys <- buf[:n]
+		}
+	}()
+
+	type searchResult struct {
+		query string
+		items []pickerItem
+	}
+	results := make(chan searchResult)
+
+	var (
+		query    []rune
+		selected int
+		remote   []pickerItem
+		debounce <-chan time.Time
+		drawn    int
+	)
+	for {
+		items := filterPickerItems(local, string(query))
+		items = append(items, remote...)
+		if selected >= len(items) {
+			selected = max(len(items)-1, 0)
+		}
+		drawn = drawPicker(string(query), items, selected, drawn)
+
+		select {
+		case key, ok := <-keys:
+			if !ok {
+				return Location{}, fmt.Errorf("input closed")
+			}
+			switch {
+			case key[0] == '\r' || key[0] == '\n':
+				clearPicker(drawn)
+				if len(items) == 0 {
+					if len(query) == 0 {
+						return Location{}, fmt.Errorf("no location selected")
+					}
+					return Location{Name: string(query)}, nil
+				}
+				return items[selected].Location, nil
+			case key[0] == 3 || (key[0] == 27 && len(key) == 1): // Ctrl-C, Esc
+				clearPicker(drawn)
+				return Location{}, fmt.Errorf("no location selected")
+			case string(key) == "\x1b[A" || key[0] == 16: // Up, Ctrl-P
+				selected = max(selected-1, 0)
+			case string(key) == "\x1b[B" || key[0] == 14: // Down, Ctrl-N
+				selected = min(selected+1, max(len(items)-1, 0))
+			case key[0] == 127 || key[0] == 8: // Backspace
+				if len(query) > 0 {
+					query = query[:len(query)-1]
+					selected, remote = 0, nil
+					debounce = time.After(pickerSearchDelay)
+				}
+			case key[0] >= 32 && key[0] != 127:
+				for len(key) > 0 {
+					r, size := utf8.DecodeRune(key)
+					if unicode.IsPrint(r) {
+						query = append(query, r)
+					}
+					key = key[size:]
+				}
+				selected, remote = 0, nil
+				debounce = time.After(pickerSearchDelay)
+			}
+
+		case <-debounce:
+			debounce = nil
+			if q := strings.TrimSpace(string(query)); len([]rune(q)) >= 3 {
+				go func() {
+					geo, err := Geocode(q, 5, apiKey)
+					if err != nil {
+						return
+					}
+					var found []pickerItem
+					for _, g := range geo {
+						found = append(found, pickerItem{
+							Label:    geoPlaceName(g),
+							Location: Location{Name: geoPlaceName(g), Lat: g.Lat, Lon: g.Lon, HasCoords: true},
+						})
+					}
+					results <- searchResult{query: q, items: found}
+				}()
+			}
+
+		case r := <-results:
+			if r.query == strings.TrimSpace(string(query)) {
+				remote = r.items
+			}
+		}
+	}
+}
+
+// filterPickerItems returns the items fuzzily matching query, best first.
+func filterPickerItems(items []pickerItem, query string) []pickerItem {
+	type scored struct {
+		item  pickerItem
+		score int
+	}
+	var matches []scored
+	for _, item := range items {
+		if score, ok := fuzzyScore(query, item.Label); ok {
+			matches = append(matches, scored{item, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+
+	filtered := make([]pickerItem, len(matches))
+	for i, m := range matches {
+		filtered[i] = m.item
+	}
+	return filtered
+}
+
+// fuzzyScore reports whether every character of pattern appears in text in
+// order (case-insensitively) and scores the match, favoring consecutive
+// characters and matches near the start of text.
+func fuzzyScore(pattern, text string) (int, bool) {
+	p := []rune(strings.ToLower(pattern))
+	t := []rune(strings.ToLower(text))
+	score, pi, last := 0, 0, -1
+	for ti := 0; ti < len(t) && pi < len(p); ti++ {
+		if t[ti] != p[pi] {
+			continue
+		}
+		score += 10
+		if last == ti-1 {
+			score += 15
+		}
+		if ti < 5 {
+			score += 5 - ti
+		}
+		last = ti
+		pi++
+	}
+	return score, pi == len(p)
+}
+
+// drawPicker redraws the picker on stderr, replacing the previous drawing
+// of prevLines lines, and returns how many lines it drew.
+func drawPicker(query string, items []pickerItem, selected, prevLines int) int {
+	var b strings.Builder
+	if prevLines > 0 {
+		fmt.Fprintf(&b, "\x1b[%dA", prevLines)
+	}
+	b.WriteString("\r\x1b[J")
+
+	start := 0
+	if selected >= pickerVisibleItems {
+		start = selected - pickerVisibleItems + 1
+	}
+	lines := 0
+	for i := start; i < len(items) && i < start+pickerVisibleItems; i++ {
+		if i == selected {
+			fmt.Fprintf(&b, "\x1b[7m> %s\x1b[0m\r\n", items[i].Label)
+		} else {
+			fmt.Fprintf(&b, "  %s\r\n", items[i].Label)
+		}
+		lines++
+	}
+	if len(items) == 0 && query != "" {
+		b.WriteString("  (no matches yet; Enter searches for the text as typed)\r\n")
+		lines++
+	}
+	fmt.Fprintf(&b, "Location: %s", query)
+	fmt.Fprint(os.Stderr, b.String())
+	return lines
+}
+
+// clearPicker erases the picker drawing before normal output resumes.
+func clearPicker(lines int) {
+	if lines > 0 {
+		fmt.Fprintf(os.Stderr, "\x1b[%dA", lines)
+	}
+	fmt.Fprint(os.Stderr, "\r\x1b[J")
+}
@@ -0,0 +1,227 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is OpenWeatherMap's data API.
+const DefaultBaseURL = "https://api.openweathermap.org/data/2.5"
+
+// DefaultTimeout is the per-request Timeout NewClient sets.
+const DefaultTimeout = 10 * time.Second
+
+// Client fetches weather data from OpenWeatherMap. Empty fields fall back
+// to the defaults NewClient sets, so a Client only needs an APIKey.
+type Client struct {
+	// APIKey is the OpenWeatherMap API key sent with every request.
+	APIKey string
+	// BaseURL is the API root, without a trailing slash; point it at a
+	// proxy or test server to avoid the real API.
+	BaseURL string
+	// HTTPClient makes the requests; it defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// Timeout limits each request, retries each getting their own, on top
+	// of any deadline of the context passed in; zero means no limit.
+	// NewClient sets DefaultTimeout.
+	Timeout time.Duration
+	// Units are the units CurrentWeather, Forecast and their ByCoords
+	// variants ask for: "metric" (the default: °C, m/s), "imperial" (°F,
+	// mph) or "standard" (K, m/s).
+	Units string
+	// Lang is the language of condition descriptions, e.g. "de" or "sw";
+	// empty leaves them in English.
+	Lang string
+	// Retry is the retry policy for failed requests; nil means
+	// DefaultRetryPolicy.
+	Retry *RetryPolicy
+}
+
+// NewClient returns a client for the public API using apiKey.
+func NewClient(apiKey string) *Client {
+	return &Client{APIKey: apiKey, BaseURL: DefaultBaseURL, HTTPClient: http.DefaultClient, Timeout: DefaultTimeout}
+}
+
+// CurrentWeather fetches the current weather for a city name such as
+// "Nairobi" or "Portland,OR,US", in the client's units.
+func (c *Client) CurrentWeather(ctx context.Context, city string) (*CurrentWeatherResponse, error) {
+	var data CurrentWeatherResponse
+	if err := c.Fetch(ctx, "weather", url.Values{"q": {city}, "units": {c.units()}}, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// CurrentWeatherByCoords fetches the current weather at lat, lon, in the
+// client's units.
+func (c *Client) CurrentWeatherByCoords(ctx context.Context, lat, lon float64) (*CurrentWeatherResponse, error) {
+	params, err := c.coordParams(lat, lon)
+	if err != nil {
+		return nil, err
+	}
+	var data CurrentWeatherResponse
+	if err := c.Fetch(ctx, "weather", params, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// Forecast fetches the 5-day / 3-hour forecast for a city name, in the
+// client's units.
+func (c *Client) Forecast(ctx context.Context, city string) (*ForecastResponse, error) {
+	var data ForecastResponse
+	if err := c.Fetch(ctx, "forecast", url.Values{"q": {city}, "units": {c.units()}}, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// ForecastByCoords fetches the 5-day / 3-hour forecast at lat, lon, in the
+// client's units.
+func (c *Client) ForecastByCoords(ctx context.Context, lat, lon float64) (*ForecastResponse, error) {
+	params, err := c.coordParams(lat, lon)
+	if err != nil {
+		return nil, err
+	}
+	var data ForecastResponse
+	if err := c.Fetch(ctx, "forecast", params, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// ValidateCoordinates checks that lat and lon are a point on the globe.
+func ValidateCoordinates(lat, lon float64) error {
+	if math.IsNaN(lat) || lat < -90 || lat > 90 {
+		return fmt.Errorf("invalid latitude %v, must be between -90 and 90", lat)
+	}
+	if math.IsNaN(lon) || lon < -180 || lon > 180 {
+		return fmt.Errorf("invalid longitude %v, must be between -180 and 180", lon)
+	}
+	return nil
+}
+
+func (c *Client) coordParams(lat, lon float64) (url.Values, error) {
+	if err := ValidateCoordinates(lat, lon); err != nil {
+		return nil, err
+	}
+	return url.Values{
+		"lat":   {strconv.FormatFloat(lat, 'f', -1, 64)},
+		"lon":   {strconv.FormatFloat(lon, 'f', -1, 64)},
+		"units": {c.units()},
+	}, nil
+}
+
+func (c *Client) units() string {
+	if c.Units == "" {
+		return "metric"
+	}
+	return c.Units
+}
+
+// Fetch requests endpoint (e.g. "weather" or "forecast") under BaseURL with
+// params, adding the API key and language unless params has them, and
+// decodes the JSON answer into target.
+func (c *Client) Fetch(ctx context.Context, endpoint string, params url.Values, target any) error {
+	if !params.Has("appid") || (c.Lang != "" && !params.Has("lang")) {
+		params = maps.Clone(params)
+		if params == nil {
+			params = url.Values{}
+		}
+		if !params.Has("appid") {
+			params.Set("appid", c.APIKey)
+		}
+		if c.Lang != "" && !params.Has("lang") {
+			params.Set("lang", c.Lang)
+		}
+	}
+	base := c.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	return c.GetJSON(ctx, strings.TrimSuffix(base, "/")+"/"+endpoint+"?"+params.Encode(), target)
+}
+
+// GetJSON requests rawURL, which may be any OpenWeatherMap endpoint with
+// its parameters, and decodes the JSON answer into target. Network errors,
+// 429 and 5xx responses are retried according to the client's Retry
+// policy, honouring Retry-After. A non-200 status is returned as an
+// *APIError, and a 429 that outlasts the retries as a *RateLimitError. The
+// query string, and so the API key, is kept out of errors.
+func (c *Client) GetJSON(ctx context.Context, rawURL string, target any) error {
+	policy := DefaultRetryPolicy
+	if c.Retry != nil {
+		policy = *c.Retry
+	}
+	for attempt := 1; ; attempt++ {
+		retryAfter, err := c.getJSON(ctx, rawURL, target)
+		if err == nil || ctx.Err() != nil || !retryable(err) {
+			return err
+		}
+		wait, ok := policy.delay(attempt, retryAfter)
+		if attempt >= policy.Attempts || !ok {
+			var apiErr *APIError
+			if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests {
+				return &RateLimitError{RetryAfter: retryAfter, Err: apiErr}
+			}
+			return err
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// getJSON makes one attempt for GetJSON, returning the Retry-After the
+// server asked for, if any.
+func (c *Client) getJSON(ctx context.Context, rawURL string, target any) (time.Duration, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return 0, &decodeError{fmt.Errorf("failed to build request: %w", err)}
+	}
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL, _, _ = strings.Cut(urlErr.URL, "?")
+		}
+		return 0, fmt.Errorf("failed to make HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()), &APIError{StatusCode: resp.StatusCode, Body: string(bodyBytes)}
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if err := json.Unmarshal(body, target); err != nil {
+		return 0, &decodeError{fmt.Errorf("failed to unmarshal JSON response: %w", err)}
+	}
+	return 0, nil
+}
@@ -0,0 +1,156 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testServer answers every request with body and sends the request's path
+// and query on the returned channel.
+func testServer(t *testing.T, status int, body string) (*httptest.Server, <-chan *url.URL) {
+	t.Helper()
+	requests := make(chan *url.URL, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r.URL
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, requests
+}
+
+func TestClientRequests(t *testing.T) {
+	tests := []struct {
+		name     string
+		client   Client
+		call     func(c *Client) error
+		wantPath string
+		want     url.Values
+	}{
+		{
+			"current by city",
+			Client{APIKey: "key"},
+			func(c *Client) error { _, err := c.CurrentWeather(context.Background(), "Nairobi"); return err },
+			"/weather",
+			url.Values{"q": {"Nairobi"}, "units": {"metric"}, "appid": {"key"}},
+		},
+		{
+			"forecast by coords",
+			Client{APIKey: "key", Units: "imperial", Lang: "sw"},
+			func(c *Client) error {
+				_, err := c.ForecastByCoords(context.Background(), -1.29, 36.82)
+				return err
+			},
+			"/forecast",
+			url.Values{"lat": {"-1.29"}, "lon": {"36.82"}, "units": {"imperial"}, "appid": {"key"}, "lang": {"sw"}},
+		},
+		{
+			"params win",
+			Client{APIKey: "key", Lang: "sw"},
+			func(c *Client) error {
+				return c.Fetch(context.Background(), "weather", url.Values{"id": {"184745"}, "appid": {"other"}, "lang": {"de"}}, &struct{}{})
+			},
+			"/weather",
+			url.Values{"id": {"184745"}, "appid": {"other"}, "lang": {"de"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := testServer(t, http.StatusOK, `{}`)
+			c := tt.client
+			c.BaseURL = srv.URL + "/"
+			if err := tt.call(&c); err != nil {
+				t.Fatal(err)
+			}
+			got := <-requests
+			if got.Path != tt.wantPath {
+				t.Errorf("path = %s, want %s", got.Path, tt.wantPath)
+			}
+			if got.Query().Encode() != tt.want.Encode() {
+				t.Errorf("query = %s, want %s", got.Query().Encode(), tt.want.Encode())
+			}
+		})
+	}
+}
+
+func TestClientDecodes(t *testing.T) {
+	srv, _ := testServer(t, http.StatusOK, `{"name": "Nairobi", "main": {"temp": 24.3}, "weather": [{"main": "Clouds"}]}`)
+	c := &Client{APIKey: "key", BaseURL: srv.URL}
+	data, err := c.CurrentWeather(context.Background(), "Nairobi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Name != "Nairobi" || data.Main.Temp != 24.3 || len(data.Weather) != 1 || data.Weather[0].Main != "Clouds" {
+		t.Errorf("decoded %+v", data)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	noRetry := &RetryPolicy{Attempts: 1}
+	t.Run("api error", func(t *testing.T) {
+		srv, _ := testServer(t, http.StatusNotFound, `{"cod":"404","message":"city not found"}`)
+		c := &Client{APIKey: "secret", BaseURL: srv.URL, Retry: noRetry}
+		_, err := c.CurrentWeather(context.Background(), "Nairobbi")
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || !strings.Contains(apiErr.Body, "city not found") {
+			t.Errorf("error = %v, want a 404 APIError", err)
+		}
+	})
+	t.Run("bad json", func(t *testing.T) {
+		srv, requests := testServer(t, http.StatusOK, `{"name":`)
+		c := &Client{APIKey: "secret", BaseURL: srv.URL}
+		if _, err := c.CurrentWeather(context.Background(), "Nairobi"); err == nil || !strings.Contains(err.Error(), "unmarshal") {
+			t.Errorf("error = %v, want a JSON error", err)
+		}
+		if len(requests) != 1 {
+			t.Errorf("%d requests for a bad answer, want 1", len(requests))
+		}
+	})
+	t.Run("key kept out of network errors", func(t *testing.T) {
+		srv, _ := testServer(t, http.StatusOK, `{}`)
+		srv.Close()
+		c := &Client{APIKey: "secret", BaseURL: srv.URL, Retry: noRetry}
+		_, err := c.CurrentWeather(context.Background(), "Nairobi")
+		if err == nil || strings.Contains(err.Error(), "secret") {
+			t.Errorf("error = %v, want one without the key", err)
+		}
+	})
+	t.Run("timeout", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		}))
+		defer srv.Close()
+		c := &Client{APIKey: "secret", BaseURL: srv.URL, Timeout: 50 * time.Millisecond, Retry: noRetry}
+		start := time.Now()
+		_, err := c.CurrentWeather(context.Background(), "Nairobi")
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("error = %v, want a deadline error", err)
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("request took %s despite the timeout", elapsed)
+		}
+	})
+	t.Run("invalid coordinates", func(t *testing.T) {
+		c := &Client{APIKey: "secret", BaseURL: "http://127.0.0.1:0"}
+		if _, err := c.CurrentWeatherByCoords(context.Background(), 91, 0); err == nil || !strings.Contains(err.Error(), "latitude") {
+			t.Errorf("error = %v, want an invalid latitude", err)
+		}
+	})
+}
+
+func TestNewClient(t *testing.T) {
+	c := NewClient("key")
+	if c.APIKey != "key" || c.BaseURL != DefaultBaseURL || c.HTTPClient == nil || c.Timeout != DefaultTimeout {
+		t.Errorf("NewClient = %+v", c)
+	}
+}
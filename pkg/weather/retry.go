@@ -0,0 +1,112 @@
+package weather
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy says how a Client retries requests that failed in a way that
+// may pass: network errors, 429 Too Many Requests and 5xx responses.
+type RetryPolicy struct {
+	// Attempts is the most requests made for one call, the first
+	// included; 1 or less never retries.
+	Attempts int
+	// BaseDelay is the wait before the first retry, doubling for each
+	// one after.
+	BaseDelay time.Duration
+	// MaxDelay caps the wait between attempts; zero leaves the backoff
+	// capped at maxBackoff and accepts any Retry-After. A Retry-After
+	// asking for longer than MaxDelay ends the retries instead.
+	MaxDelay time.Duration
+	// Jitter spreads each wait randomly by up to this fraction either
+	// way, e.g. 0.2 for ±20%, so clients that failed together don't
+	// retry together.
+	Jitter float64
+}
+
+// maxBackoff caps the doubling wait of a policy without a MaxDelay, so
+// that many attempts neither overflow nor wait for days.
+const maxBackoff = time.Hour
+
+// DefaultRetryPolicy is the policy of clients whose Retry is nil.
+var DefaultRetryPolicy = RetryPolicy{Attempts: 3, BaseDelay: 500 * time.Millisecond, MaxDelay: 30 * time.Second, Jitter: 0.2}
+
+// RateLimitError is returned when the API still answers 429 Too Many
+// Requests after the retries: the key's per-minute or monthly quota is used
+// up. It wraps the *APIError, so errors.As finds either.
+type RateLimitError struct {
+	// RetryAfter is how long the API asked to wait, or zero if it didn't
+	// say.
+	RetryAfter time.Duration
+	Err        *APIError
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("rate limited by the API, retry in %s: %v", e.RetryAfter.Round(time.Second), e.Err)
+	}
+	return fmt.Sprintf("rate limited by the API: %v", e.Err)
+}
+
+func (e *RateLimitError) Unwrap() error { return e.Err }
+
+// decodeError is a request that couldn't be built or a response that
+// couldn't be decoded, which asking again won't fix.
+type decodeError struct{ err error }
+
+func (e *decodeError) Error() string { return e.err.Error() }
+func (e *decodeError) Unwrap() error { return e.err }
+
+// retryable reports whether a request that failed with err may succeed if
+// made again.
+func retryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+	var decodeErr *decodeError
+	return !errors.As(err, &decodeErr)
+}
+
+// delay returns the wait before retry number n (from 1), preferring the
+// server's retryAfter, and false if the policy won't wait that long.
+func (p RetryPolicy) delay(n int, retryAfter time.Duration) (time.Duration, bool) {
+	if retryAfter > 0 {
+		return retryAfter, p.MaxDelay <= 0 || retryAfter <= p.MaxDelay
+	}
+	ceiling := p.MaxDelay
+	if ceiling <= 0 {
+		ceiling = maxBackoff
+	}
+	d := min(p.BaseDelay, ceiling)
+	for range n - 1 {
+		if d > ceiling/2 {
+			d = ceiling
+			break
+		}
+		d *= 2
+	}
+	if p.Jitter > 0 {
+		d = time.Duration(float64(d) * (1 + p.Jitter*(2*rand.Float64()-1)))
+	}
+	return d, true
+}
+
+// parseRetryAfter reads a Retry-After header, in seconds or as an HTTP
+// date, returning zero if it's missing or malformed.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(header); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
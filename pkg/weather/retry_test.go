@@ -0,0 +1,149 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     RetryPolicy
+		n          int
+		retryAfter time.Duration
+		want       time.Duration
+		wantOK     bool
+	}{
+		{"first retry", RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute}, 1, 0, time.Second, true},
+		{"doubling", RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute}, 4, 0, 8 * time.Second, true},
+		{"capped", RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute}, 10, 0, time.Minute, true},
+		{"capped past overflow", RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute}, 100, 0, time.Minute, true},
+		{"no cap", RetryPolicy{BaseDelay: time.Second}, 5, 0, 16 * time.Second, true},
+		{"no cap past overflow", RetryPolicy{BaseDelay: 500 * time.Millisecond}, 70, 0, maxBackoff, true},
+		{"base above cap", RetryPolicy{BaseDelay: time.Hour, MaxDelay: time.Minute}, 1, 0, time.Minute, true},
+		{"retry-after", RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute}, 1, 20 * time.Second, 20 * time.Second, true},
+		{"retry-after too long", RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute}, 1, time.Hour, time.Hour, false},
+		{"retry-after without cap", RetryPolicy{BaseDelay: time.Second}, 1, 3 * time.Hour, 3 * time.Hour, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.policy.delay(tt.n, tt.retryAfter)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("delay(%d, %s) = %s, %v; want %s, %v", tt.n, tt.retryAfter, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDelayJitter(t *testing.T) {
+	p := RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute, Jitter: 0.2}
+	for range 100 {
+		if d, _ := p.delay(2, 0); d < 1600*time.Millisecond || d > 2400*time.Millisecond {
+			t.Fatalf("delay with 20%% jitter = %s, want within 2s ± 20%%", d)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, time.March, 2, 6, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{"0", 0},
+		{"-5", 0},
+		{"soon", 0},
+		{"Mon, 02 Mar 2026 06:01:30 GMT", 90 * time.Second},
+		{"Mon, 02 Mar 2026 05:59:00 GMT", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.header, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"network", errors.New("connection refused"), true},
+		{"too many requests", &APIError{StatusCode: http.StatusTooManyRequests}, true},
+		{"server error", fmt.Errorf("fetching: %w", &APIError{StatusCode: http.StatusBadGateway}), true},
+		{"not found", &APIError{StatusCode: http.StatusNotFound}, false},
+		{"bad key", &APIError{StatusCode: http.StatusUnauthorized}, false},
+		{"bad json", &decodeError{errors.New("unexpected end of JSON input")}, false},
+	}
+	for _, tt := range tests {
+		if got := retryable(tt.err); got != tt.want {
+			t.Errorf("%s: retryable = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGetJSONRetries(t *testing.T) {
+	type answer struct {
+		status     int
+		retryAfter string
+	}
+	tests := []struct {
+		name          string
+		answers       []answer // the last one repeats
+		wantRequests  int
+		wantErr       bool
+		wantRateLimit time.Duration // RetryAfter of the RateLimitError; -1 for none
+	}{
+		{"ok", []answer{{200, ""}}, 1, false, -1},
+		{"server error then ok", []answer{{503, ""}, {200, ""}}, 2, false, -1},
+		{"rate limited", []answer{{429, ""}}, 3, true, 0},
+		{"retry-after too long", []answer{{429, "3600"}}, 1, true, time.Hour},
+		{"not found", []answer{{404, ""}}, 1, true, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(requests.Add(1))
+				a := tt.answers[min(n, len(tt.answers))-1]
+				if a.retryAfter != "" {
+					w.Header().Set("Retry-After", a.retryAfter)
+				}
+				w.WriteHeader(a.status)
+				fmt.Fprint(w, `{"name": "Nairobi"}`)
+			}))
+			defer srv.Close()
+
+			c := &Client{BaseURL: srv.URL, Retry: &RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Minute}}
+			var data CurrentWeatherResponse
+			err := c.Fetch(context.Background(), "weather", nil, &data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Fetch error = %v, want error %v", err, tt.wantErr)
+			}
+			if n := int(requests.Load()); n != tt.wantRequests {
+				t.Errorf("%d requests, want %d", n, tt.wantRequests)
+			}
+			var rateErr *RateLimitError
+			switch {
+			case tt.wantRateLimit < 0 && errors.As(err, &rateErr):
+				t.Errorf("got a RateLimitError: %v", err)
+			case tt.wantRateLimit >= 0 && !errors.As(err, &rateErr):
+				t.Errorf("error = %v, want a RateLimitError", err)
+			case tt.wantRateLimit >= 0 && rateErr.RetryAfter != tt.wantRateLimit:
+				t.Errorf("RetryAfter = %s, want %s", rateErr.RetryAfter, tt.wantRateLimit)
+			}
+			if !tt.wantErr && data.Name != "Nairobi" {
+				t.Errorf("decoded name %q, want Nairobi", data.Name)
+			}
+		})
+	}
+}
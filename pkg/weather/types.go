@@ -0,0 +1,142 @@
+// Package weather is a client for the OpenWeatherMap current weather and
+// 5-day / 3-hour forecast APIs, as used by the weather-tool CLI.
+//
+//	client := weather.NewClient(os.Getenv("OPENWEATHER_API_KEY"))
+//	current, err := client.CurrentWeather(ctx, "Nairobi")
+package weather
+
+import "fmt"
+
+// Weather describes a weather condition (id, group, description, icon)
+type Weather struct {
+	ID          int    `json:"id"`
+	Main        string `json:"main"`
+	Description string `json:"description"`
+	Icon        string `json:"icon"`
+}
+
+// Main describes the main weather parameters (temperature, humidity, pressure)
+type Main struct {
+	Temp      float64 `json:"temp"`
+	FeelsLike float64 `json:"feels_like"`
+	TempMin   float64 `json:"temp_min"`
+	TempMax   float64 `json:"temp_max"`
+	Pressure  int     `json:"pressure"`
+	Humidity  int     `json:"humidity"`
+	// SeaLevel and GrndLevel are the pressure reduced to sea level and at
+	// ground level, in hPa; the API leaves them out for some stations.
+	SeaLevel  int `json:"sea_level,omitempty"`
+	GrndLevel int `json:"grnd_level,omitempty"`
+}
+
+// StationPressure returns the pressure at ground level, for calculations
+// that depend on altitude, falling back to the reported pressure.
+func (m Main) StationPressure() float64 {
+	if m.GrndLevel > 0 {
+		return float64(m.GrndLevel)
+	}
+	return float64(m.Pressure)
+}
+
+// Wind describes wind speed and direction
+type Wind struct {
+	Speed float64 `json:"speed"`
+	Deg   int     `json:"deg"`
+	Gust  float64 `json:"gust"`
+}
+
+// Clouds describes cloudiness
+type Clouds struct {
+	All int `json:"all"`
+}
+
+// Rain describes rainfall volume in mm
+type Rain struct {
+	OneHour float64 `json:"1h"`
+}
+
+// Rain3h describes rain or snow volume in mm over a forecast entry's 3 hours
+type Rain3h struct {
+	ThreeHours float64 `json:"3h"`
+}
+
+// Sys describes sunrise and sunset times (for current weather)
+type Sys struct {
+	Type    int    `json:"type"`
+	ID      int    `json:"id"`
+	Country string `json:"country"`
+	Sunrise int64  `json:"sunrise"`
+	Sunset  int64  `json:"sunset"`
+}
+
+// Coord describes geographical coordinates
+type Coord struct {
+	Lon float64 `json:"lon"`
+	Lat float64 `json:"lat"`
+}
+
+// CurrentWeatherResponse is the top-level struct for current weather API response
+type CurrentWeatherResponse struct {
+	Coord      Coord     `json:"coord"`
+	Weather    []Weather `json:"weather"`
+	Base       string    `json:"base"`
+	Main       Main      `json:"main"`
+	Visibility int       `json:"visibility"`
+	Wind       Wind      `json:"wind"`
+	Clouds     Clouds    `json:"clouds"`
+	Rain       Rain      `json:"rain"`
+	Dt         int64     `json:"dt"` // Time of data calculation, Unix, UTC
+	Sys        Sys       `json:"sys"`
+	Timezone   int       `json:"timezone"`
+	ID         int       `json:"id"`
+	Name       string    `json:"name"`
+	Cod        int       `json:"cod"`
+}
+
+// City describes the city information in the forecast response
+type City struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	Coord      Coord  `json:"coord"`
+	Country    string `json:"country"`
+	Population int    `json:"population"`
+	Timezone   int    `json:"timezone"`
+	Sunrise    int64  `json:"sunrise"`
+	Sunset     int64  `json:"sunset"`
+}
+
+// ForecastListEntry describes a single 3-hour forecast entry
+type ForecastListEntry struct {
+	Dt         int64     `json:"dt"` // Time of data calculation, Unix, UTC
+	Main       Main      `json:"main"`
+	Weather    []Weather `json:"weather"`
+	Clouds     Clouds    `json:"clouds"`
+	Wind       Wind      `json:"wind"`
+	Visibility int       `json:"visibility"`
+	Pop        float64   `json:"pop"` // Probability of precipitation
+	Rain       Rain3h    `json:"rain"`
+	Snow       Rain3h    `json:"snow"`
+	Sys        struct {
+		Pod string `json:"pod"` // Part of the day (d = day, n = night)
+	} `json:"sys"`
+	DtTxt string `json:"dt_txt"` // Date and time in UTC
+}
+
+// ForecastResponse is the top-level struct for 5-day / 3-hour forecast API response
+type ForecastResponse struct {
+	Cod     string              `json:"cod"`
+	Message float64             `json:"message"`
+	Cnt     int                 `json:"cnt"`
+	List    []ForecastListEntry `json:"list"`
+	City    City                `json:"city"`
+}
+
+// APIError is returned when the API answers with a non-200 status.
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API request failed with status %d: %s", e.StatusCode, e.Body)
+}
@@ -0,0 +1,40 @@
+package main
+
+import "time"
+
+// The plain output format is meant for screen readers and braille
+// displays: one short sentence per line, units spelled out, and no box
+// drawing, symbols, emoji or color.
+
+func displayCurrentWeatherPlain(data *CurrentWeatherResponse) {
+	lprintf("Current weather for %s %s.\n", data.Name, data.Sys.Country)
+	if len(data.Weather) > 0 {
+		lprintf("Conditions are %s.\n", data.Weather[0].Description)
+	}
+	lprintf("Temperature %.0f %s, feels like %.0f.\n", showTemp(data.Main.Temp), displayUnits.tempWords, showTemp(data.Main.FeelsLike))
+	lprintf("Humidity %d percent.\n", data.Main.Humidity)
+	lprintf("Wind %.0f %s.\n", showSpeed(data.Wind.Speed), displayUnits.speedWords)
+	lprintf("Pressure %d hectopascals.\n", data.Main.Pressure)
+	lprintf("Cloud cover %d percent.\n", data.Clouds.All)
+	lprintf("Sunrise at %s.\n", time.Unix(data.Sys.Sunrise, 0).Local().Format("3 04 PM"))
+	lprintf("Sunset at %s.\n", time.Unix(data.Sys.Sunset, 0).Local().Format("3 04 PM"))
+}
+
+func displayForecastPlain(data *ForecastResponse) {
+	lprintf("Forecast for %s %s in 3 hour steps.\n", data.City.Name, data.City.Country)
+
+	day := ""
+	for _, entry := range data.List {
+		at := time.Unix(entry.Dt, 0).Local()
+		if label := formatDate(at, "Monday 2 January"); label != day {
+			day = label
+			lprintf("\n%s.\n", day)
+		}
+		condition := "no conditions reported"
+		if len(entry.Weather) > 0 {
+			condition = entry.Weather[0].Description
+		}
+		lprintf("At %s, %.0f degrees, %s, wind %.0f %s, %.0f percent chance of rain.\n",
+			at.Format("3 PM"), showTemp(entry.Main.Temp), condition, showSpeed(entry.Wind.Speed), displayUnits.speedWords, entry.Pop*100)
+	}
+}
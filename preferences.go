@@ -0,0 +1,58 @@
+package main
+
+import "strings"
+
+// LocationPreferences are config file settings for one favorite, used
+// instead of the global ones whenever that favorite is queried, e.g.
+// imperial units for "@us-office":
+//
+//	{"locations": {"us-office": {"units": "imperial", "alerts": {"severe_wind": 13.9}}}}
+type LocationPreferences struct {
+	// Units and Lang replace "units" and "lang" for commands about this
+	// one location. --units and --lang still win.
+	Units string `json:"units,omitempty"`
+	Lang  string `json:"lang,omitempty"`
+	// Alerts replaces the wind speeds at which alerts without a
+	// condition consider the weather severe or extreme.
+	Alerts AlertThresholds `json:"alerts"`
+}
+
+// AlertThresholds are the wind speeds, in m/s like alert conditions, from
+// which the weather is severe or extreme; zero keeps the default.
+type AlertThresholds struct {
+	SevereWind  float64 `json:"severe_wind,omitempty"`
+	ExtremeWind float64 `json:"extreme_wind,omitempty"`
+}
+
+// defaultAlertThresholds are Beaufort 8 (gale) and Beaufort 10 (storm).
+var defaultAlertThresholds = AlertThresholds{SevereWind: 17.2, ExtremeWind: 24.5}
+
+// alertThresholds returns the alert thresholds for loc: its favorite's
+// preferences, falling back to the defaults.
+func alertThresholds(loc Location) AlertThresholds {
+	t := config.Locations[loc.Favorite].Alerts
+	if t.SevereWind == 0 {
+		t.SevereWind = defaultAlertThresholds.SevereWind
+	}
+	if t.ExtremeWind == 0 {
+		t.ExtremeWind = defaultAlertThresholds.ExtremeWind
+	}
+	return t
+}
+
+// usePreferences switches the display units and the language to those set
+// for loc's favorite, if any. Commands about a single location call it once
+// the location is resolved; commands covering several keep the global
+// settings, as do --units and --lang.
+func usePreferences(loc Location) {
+	prefs, ok := config.Locations[loc.Favorite]
+	if !ok || loc.Favorite == "" {
+		return
+	}
+	if prefs.Units != "" && !unitsFromFlag {
+		displayUnits = unitSystems[strings.ToLower(prefs.Units)]
+	}
+	if prefs.Lang != "" && !langFromFlag {
+		langName = prefs.Lang
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import "testing"
+
+// withConfig runs f with c as the loaded config and restores the previous
+// config, units and language.
+func withConfig(t *testing.T, c Config, f func()) {
+	t.Helper()
+	saved, units, lang := config, displayUnits, langName
+	config = c
+	defer func() { config, displayUnits, langName = saved, units, lang }()
+	f()
+}
+
+func TestAlertSeverityThresholds(t *testing.T) {
+	c := Config{Locations: map[string]LocationPreferences{
+		"coast": {Alerts: AlertThresholds{SevereWind: 20, ExtremeWind: 30}},
+		"hills": {Alerts: AlertThresholds{SevereWind: 10}},
+	}}
+	tests := []struct {
+		favorite string
+		wind     float64
+		want     string
+	}{
+		{"", 12, severityNone},
+		{"", 18, severitySevere},
+		{"", 25, severityExtreme},
+		{"coast", 18, severityNone},
+		{"coast", 25, severitySevere},
+		{"coast", 30, severityExtreme},
+		{"hills", 12, severitySevere},
+		{"hills", 25, severityExtreme},
+	}
+	withConfig(t, c, func() {
+		for _, tt := range tests {
+			var data CurrentWeatherResponse
+			data.Wind.Speed = tt.wind
+			if got := alertSeverity(Location{Name: "x", Favorite: tt.favorite}, &data); got != tt.want {
+				t.Errorf("alertSeverity(@%s, wind %v) = %q, want %q", tt.favorite, tt.wind, got, tt.want)
+			}
+		}
+	})
+}
+
+func TestUsePreferences(t *testing.T) {
+	c := Config{Locations: map[string]LocationPreferences{
+		"us-office": {Units: "imperial", Lang: "es"},
+	}}
+	tests := []struct {
+		name                        string
+		favorite                    string
+		unitsFromFlag, langFromFlag bool
+		wantTemp                    string
+		wantLang                    string
+	}{
+		{"favorite", "us-office", false, false, "°F", "es"},
+		{"other favorite", "nairobi", false, false, "°C", "en"},
+		{"not a favorite", "", false, false, "°C", "en"},
+		{"flags win", "us-office", true, true, "°C", "en"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withConfig(t, c, func() {
+				displayUnits, langName = unitSystems["metric"], "en"
+				unitsFromFlag, langFromFlag = tt.unitsFromFlag, tt.langFromFlag
+				defer func() { unitsFromFlag, langFromFlag = false, false }()
+				usePreferences(Location{Name: "x", Favorite: tt.favorite})
+				if displayUnits.temp.symbol != tt.wantTemp || langName != tt.wantLang {
+					t.Errorf("usePreferences = %s, %s; want %s, %s", displayUnits.temp.symbol, langName, tt.wantTemp, tt.wantLang)
+				}
+			})
+		})
+	}
+}
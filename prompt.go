@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// promptShells are the --shell values of `weather prompt`.
+var promptShells = []string{"zsh", "bash", "fish"}
+
+// promptSymbols are the narrow symbols a prompt segment shows for each
+// condition; emoji would be two columns wide and throw off the cursor in
+// some terminals.
+var promptSymbols = map[string]string{
+	"Clear":        "☀",
+	"Clouds":       "☁",
+	"Rain":         "☂",
+	"Drizzle":      "☂",
+	"Thunderstorm": "ϟ",
+	"Snow":         "❄",
+	"Mist":         "≡",
+	"Fog":          "≡",
+	"Haze":         "≡",
+}
+
+// promptRefreshBackoff is how long a background refresh is given before
+// another prompt may start one.
+const promptRefreshBackoff = time.Minute
+
+// runPrompt implements `weather prompt`, a shell prompt segment read only
+// from the response cache, so drawing a prompt never waits on the network.
+func runPrompt(args []string) error {
+	fs := flag.NewFlagSet("prompt", flag.ExitOnError)
+	locFlags := addLocationFlags(fs)
+	shell := fs.String("shell", "", "Shell to write the segment for: "+strings.Join(promptShells, ", ")+" (default a plain line)")
+	initSnippet := fs.Bool("init", false, "Print the snippet adding the segment to --shell's prompt, for its rc file")
+	maxAge := fs.Duration("max-age", time.Hour, "Show nothing when the cached weather is older than this")
+	refresh := fs.Bool("refresh", false, "When the cached weather is more than 10 minutes old, fetch it in the background for the next prompt")
+	update := fs.Bool("update", false, "Fetch the weather into the cache and print nothing, as --refresh does in the background")
+	fs.Parse(args)
+
+	if *shell != "" && !slices.Contains(promptShells, *shell) {
+		return fmt.Errorf("unknown --shell %q, use one of: %s", *shell, strings.Join(promptShells, ", "))
+	}
+	loc, hasFlagLoc, err := locFlags.location()
+	switch {
+	case err != nil:
+	case hasFlagLoc:
+	case fs.NArg() > 0:
+		loc, err = locFlags.resolveCity(strings.Join(fs.Args(), " "))
+	case config.DefaultCity != "":
+		loc, err = resolveLocation(config.DefaultCity)
+	default:
+		return fmt.Errorf("usage: weather prompt [--shell zsh|bash|fish] [--init] [--refresh] <city|@favorite>")
+	}
+	if err != nil {
+		return err
+	}
+	usePreferences(loc)
+	// The location arguments, to pass on to the snippet and the
+	// background refresh.
+	var locArgs []string
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name != "shell" && f.Name != "init" && f.Name != "max-age" && f.Name != "refresh" && f.Name != "update" {
+			locArgs = append(locArgs, "--"+f.Name+"="+f.Value.String())
+		}
+	})
+	locArgs = append(locArgs, fs.Args()...)
+
+	switch {
+	case *initSnippet:
+		return printPromptInit(*shell, locArgs)
+	case *update:
+		var data CurrentWeatherResponse
+		_, err := fetchCached("current", loc, apiKeyFromEnv(), defaultCacheTTL, &data)
+		return err
+	}
+
+	path, err := diskCachePath("current", loc)
+	if err != nil {
+		return err
+	}
+	var entry diskCacheEntry
+	var data CurrentWeatherResponse
+	raw, err := os.ReadFile(path)
+	cached := err == nil && json.Unmarshal(raw, &entry) == nil && json.Unmarshal(entry.Data, &data) == nil
+	age := time.Since(entry.FetchedAt)
+	if *refresh && (!cached || age > defaultCacheTTL) {
+		refreshPromptCache(path, locArgs)
+	}
+	if !cached || age > *maxAge {
+		return nil
+	}
+
+	segment := lsprintf("%.*f%s", decimals("temp", 0), showTemp(data.Main.Temp), displayUnits.temp.symbol)
+	if len(data.Weather) > 0 && promptSymbols[data.Weather[0].Main] != "" {
+		segment = promptSymbols[data.Weather[0].Main] + " " + segment
+	}
+	switch *shell {
+	case "":
+		fmt.Println(segment)
+	case "zsh":
+		// zsh expands % sequences in the prompt after command
+		// substitution.
+		fmt.Print(strings.ReplaceAll(segment, "%", "%%"))
+	default:
+		fmt.Print(segment)
+	}
+	return nil
+}
+
+// refreshPromptCache starts `weather prompt --update` in the background,
+// unless another prompt started one in the last promptRefreshBackoff. The
+// marker file next to the cache entry records when one was started.
+func refreshPromptCache(path string, locArgs []string) {
+	marker := path + ".refreshing"
+	if info, err := os.Stat(marker); err == nil && time.Since(info.ModTime()) < promptRefreshBackoff {
+		return
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return
+	}
+	if os.MkdirAll(filepath.Dir(path), 0o755) != nil || os.WriteFile(marker, nil, 0o644) != nil {
+		return
+	}
+	// Leaving stdout and stderr nil connects them to the null device, so
+	// the shell's command substitution doesn't wait for the refresh.
+	cmd := exec.Command(exe, append(append(globalArgs(), "prompt", "--update"), locArgs...)...)
+	if cmd.Start() == nil {
+		cmd.Process.Release()
+	}
+}
+
+// globalArgs returns the global flags in effect, for running `weather`
+// again with the same settings.
+func globalArgs() []string {
+	var args []string
+	for _, g := range []struct{ name, value string }{
+		{"profile", profile}, {"config", configFile}, {"provider", provider},
+		{"locale", localeName}, {"units", unitsName}, {"lang", langName}, {"precision", precisionSpec},
+	} {
+		if g.value != "" {
+			args = append(args, "--"+g.name, g.value)
+		}
+	}
+	return args
+}
+
+// printPromptInit prints the rc file snippet adding the prompt segment for
+// locArgs to shell's prompt.
+func printPromptInit(shell string, locArgs []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "weather"
+	}
+	command := shellQuote(exe) + " " + strings.Join(append(globalArgsQuoted(), "prompt", "--shell", shell, "--refresh"), " ")
+	for _, arg := range locArgs {
+		command += " " + shellQuote(arg)
+	}
+	// Warnings, like the one for a missing .env file, don't belong in a
+	// prompt.
+	command += " 2>/dev/null"
+	switch shell {
+	case "zsh":
+		fmt.Printf(`# Weather in the right prompt: add to ~/.zshrc
+weather_prompt() { %s }
+setopt prompt_subst
+RPROMPT='$(weather_prompt)'"${RPROMPT:+ $RPROMPT}"
+`, command+";")
+	case "bash":
+		fmt.Printf(`# Weather at the start of the prompt: add to ~/.bashrc
+weather_prompt() { %s }
+PS1='$(weather_prompt) '"$PS1"
+`, command+";")
+	case "fish":
+		fmt.Printf(`# Weather in the right prompt: save as ~/.config/fish/functions/fish_right_prompt.fish
+function fish_right_prompt
+    %s
+end
+`, command)
+	default:
+		return fmt.Errorf("--init needs --shell zsh, bash or fish")
+	}
+	return nil
+}
+
+// globalArgsQuoted is globalArgs quoted for a shell.
+func globalArgsQuoted() []string {
+	args := globalArgs()
+	for i, arg := range args {
+		args[i] = shellQuote(arg)
+	}
+	return args
+}
+
+// shellQuote quotes s for zsh, bash and fish alike.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
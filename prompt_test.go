@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", "''"},
+		{"Nairobi", "'Nairobi'"},
+		{"--city=Nairobi, KE", "'--city=Nairobi, KE'"},
+		{"Ain't", `'Ain'\''t'`},
+		{"$HOME `id` \"x\" \\n *", "'$HOME `id` \"x\" \\n *'"},
+		{"''", `''\'''\'''`},
+	}
+	for _, tt := range tests {
+		if got := shellQuote(tt.in); got != tt.want {
+			t.Errorf("shellQuote(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+
+	// The shells must read each quoted word back unchanged.
+	for _, shell := range []string{"sh", "bash", "zsh", "fish"} {
+		path, err := exec.LookPath(shell)
+		if err != nil {
+			continue
+		}
+		for _, tt := range tests {
+			out, err := exec.Command(path, "-c", "printf %s "+shellQuote(tt.in)).Output()
+			if err != nil {
+				t.Errorf("%s: printf %s: %v", shell, shellQuote(tt.in), err)
+				continue
+			}
+			if string(out) != tt.in {
+				t.Errorf("%s read %s as %q, want %q", shell, shellQuote(tt.in), out, tt.in)
+			}
+		}
+	}
+}
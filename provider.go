@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// defaultProvider is the built-in OpenWeatherMap client.
+const defaultProvider = "openweathermap"
+
+// pluginPrefix is the file name prefix of provider plugins in the plugins
+// directory; weather-provider-met provides --provider met.
+const pluginPrefix = "weather-provider-"
+
+// provider selects where weather data comes from. It is set by the global
+// --provider flag or the WEATHER_TOOL_PROVIDER environment variable, and
+// falls back to "provider" in the config file.
+var provider string
+
+// selectedProvider returns the provider in use, the first of the
+// fallback chain if there is one.
+func selectedProvider() string {
+	switch {
+	case provider != "":
+		return provider
+	case len(config.Providers) > 0:
+		return providerChain()[0]
+	case config.Provider != "":
+		return config.Provider
+	}
+	return defaultProvider
+}
+
+// pluginRequest is the JSON document written to a provider plugin's stdin.
+// The plugin answers on stdout with the same JSON as the corresponding
+// OpenWeatherMap endpoint (metric units): the current weather API for
+// "current" and the 5 day / 3 hour forecast API for "forecast".
+type pluginRequest struct {
+	Kind     string   `json:"kind"`
+	Location Location `json:"location"`
+	Units    string   `json:"units"`
+}
+
+// pluginsDir returns the directory searched for provider plugins.
+func pluginsDir() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "plugins"), nil
+}
+
+// providerPlugins lists the installed provider plugins by provider name.
+func providerPlugins() (map[string]string, error) {
+	dir, err := pluginsDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugins directory: %w", err)
+	}
+	plugins := make(map[string]string)
+	for _, entry := range entries {
+		name, ok := strings.CutPrefix(entry.Name(), pluginPrefix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		if runtime.GOOS == "windows" {
+			name = strings.TrimSuffix(name, ".exe")
+		}
+		plugins[name] = filepath.Join(dir, entry.Name())
+	}
+	return plugins, nil
+}
+
+// fetchFromPlugin asks the named provider plugin for kind ("current" or
+// "forecast") data about loc and decodes its answer into target.
+func fetchFromPlugin(name, kind string, loc Location, target interface{}) error {
+	plugins, err := providerPlugins()
+	if err != nil {
+		return err
+	}
+	path, ok := plugins[name]
+	if !ok {
+		dir, _ := pluginsDir()
+		return fmt.Errorf("unknown provider %q: no %s%s plugin in %s", name, pluginPrefix, name, dir)
+	}
+
+	request, err := json.Marshal(pluginRequest{Kind: kind, Location: loc, Units: "metric"})
+	if err != nil {
+		return fmt.Errorf("failed to encode plugin request: %w", err)
+	}
+	stdout, err := runCommand([]string{path}, request, nil)
+	if err != nil {
+		return fmt.Errorf("provider %s: %w", name, err)
+	}
+	if err := json.Unmarshal(stdout, target); err != nil {
+		return fmt.Errorf("provider %s returned invalid JSON: %w", name, err)
+	}
+	if err := checkPluginResponse(target); err != nil {
+		return fmt.Errorf("provider %s returned an incomplete response: %w", name, err)
+	}
+	return nil
+}
+
+// checkPluginResponse checks that a plugin's decoded answer has what the
+// built-in provider always returns: the conditions of the current weather,
+// and forecast entries each with their conditions.
+func checkPluginResponse(target interface{}) error {
+	switch data := target.(type) {
+	case *CurrentWeatherResponse:
+		if len(data.Weather) == 0 {
+			return fmt.Errorf("no weather conditions")
+		}
+	case *ForecastResponse:
+		if len(data.List) == 0 {
+			return fmt.Errorf("no forecast entries")
+		}
+		for i, entry := range data.List {
+			if len(entry.Weather) == 0 {
+				return fmt.Errorf("no weather conditions in forecast entry %d", i)
+			}
+		}
+	}
+	return nil
+}
+
+// runProviders implements `weather providers`, listing the built-in
+// provider and any installed plugins.
+func runProviders(args []string) error {
+	plugins, err := providerPlugins()
+	if err != nil {
+		return err
+	}
+	names := []string{defaultProvider}
+	for name := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	selected := selectedProvider()
+	for _, name := range names {
+		marker := " "
+		if name == selected {
+			marker = "*"
+		}
+		where := "built in"
+		if path, ok := plugins[name]; ok {
+			where = path
+		}
+		fmt.Printf("%s %-16s %s\n", marker, name, where)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+)
+
+const pushoverMessagesURL = "https://api.pushover.net/1/messages.json"
+
+// Pushover priorities, see https://pushover.net/api#priority.
+const (
+	pushoverLowest    = -2
+	pushoverEmergency = 2
+)
+
+// PushoverNotifier sends push notifications through the Pushover API.
+// Severe messages use SeverePriority; when that is emergency priority,
+// Pushover re-alerts every Retry seconds until acknowledged or Expire
+// seconds have passed.
+type PushoverNotifier struct {
+	Token          string
+	User           string
+	Priority       int
+	SeverePriority int
+	Retry          int
+	Expire         int
+}
+
+// newPushoverNotifierFromEnv builds a PushoverNotifier from PUSHOVER_TOKEN and
+// PUSHOVER_USER, with optional PUSHOVER_PRIORITY (default 0),
+// PUSHOVER_SEVERE_PRIORITY (default 2), PUSHOVER_RETRY (default 60) and
+// PUSHOVER_EXPIRE (default 3600).
+func newPushoverNotifierFromEnv() (Notifier, error) {
+	n := &PushoverNotifier{
+		Token:          os.Getenv("PUSHOVER_TOKEN"),
+		User:           os.Getenv("PUSHOVER_USER"),
+		Priority:       0,
+		SeverePriority: pushoverEmergency,
+		Retry:          60,
+		Expire:         3600,
+	}
+	if n.Token == "" || n.User == "" {
+		return nil, fmt.Errorf("PUSHOVER_TOKEN and PUSHOVER_USER must both be set")
+	}
+
+	for name, target := range map[string]*int{
+		"PUSHOVER_PRIORITY":        &n.Priority,
+		"PUSHOVER_SEVERE_PRIORITY": &n.SeverePriority,
+		"PUSHOVER_RETRY":           &n.Retry,
+		"PUSHOVER_EXPIRE":          &n.Expire,
+	} {
+		if value := os.Getenv(name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q: %w", name, value, err)
+			}
+			*target = parsed
+		}
+	}
+
+	for _, p := range []int{n.Priority, n.SeverePriority} {
+		if p < pushoverLowest || p > pushoverEmergency {
+			return nil, fmt.Errorf("Pushover priority %d is out of range (-2 to 2)", p)
+		}
+	}
+	if n.Retry < 30 {
+		return nil, fmt.Errorf("PUSHOVER_RETRY must be at least 30 seconds")
+	}
+	if n.Expire > 10800 {
+		return nil, fmt.Errorf("PUSHOVER_EXPIRE must be at most 10800 seconds")
+	}
+	return n, nil
+}
+
+// Notify sends msg as a single Pushover notification.
+func (n *PushoverNotifier) Notify(msg Message) error {
+	priority := n.Priority
+	if msg.Severe {
+		priority = n.SeverePriority
+	}
+
+	form := url.Values{
+		"token":    {n.Token},
+		"user":     {n.User},
+		"title":    {msg.Title},
+		"message":  {msg.Body()},
+		"priority": {strconv.Itoa(priority)},
+	}
+	if priority == pushoverEmergency {
+		form.Set("retry", strconv.Itoa(n.Retry))
+		form.Set("expire", strconv.Itoa(n.Expire))
+	}
+
+	resp, err := http.PostForm(pushoverMessagesURL, form)
+	if err != nil {
+		return fmt.Errorf("failed to make HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("Pushover request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// quietWindow is a daily time range, possibly wrapping past midnight, in
+// minutes since local midnight.
+type quietWindow struct {
+	start, end int
+}
+
+// parseQuietWindow parses a range such as "22:00-07:00".
+func parseQuietWindow(s string) (quietWindow, error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return quietWindow{}, fmt.Errorf("invalid quiet hours %q, use e.g. 22:00-07:00", s)
+	}
+	var w quietWindow
+	for i, part := range []string{from, to} {
+		t, err := time.Parse("15:04", strings.TrimSpace(part))
+		if err != nil {
+			return quietWindow{}, fmt.Errorf("invalid quiet hours %q, use e.g. 22:00-07:00", s)
+		}
+		minutes := t.Hour()*60 + t.Minute()
+		if i == 0 {
+			w.start = minutes
+		} else {
+			w.end = minutes
+		}
+	}
+	return w, nil
+}
+
+// contains reports whether t falls inside the window.
+func (w quietWindow) contains(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	if w.start <= w.end {
+		return m >= w.start && m < w.end
+	}
+	return m >= w.start || m < w.end
+}
+
+// quietNotifier holds back non-severe messages during a channel's quiet
+// hours and delivers them as one batch with the first message after.
+type quietNotifier struct {
+	channel string
+	window  quietWindow
+	next    Notifier
+}
+
+// Notify holds msg during quiet hours unless it is severe, which goes
+// out at once while the held messages keep waiting. After quiet hours the
+// held batch is sent first; msg is sent even if that fails.
+func (q *quietNotifier) Notify(msg Message) error {
+	if !q.window.contains(time.Now()) {
+		return errors.Join(q.flush(), q.next.Notify(msg))
+	}
+	if msg.Severe {
+		return q.next.Notify(msg)
+	}
+	return enqueueQuiet(q.channel, msg, time.Now())
+}
+
+// holds reports whether msg would be held back right now.
+func (q *quietNotifier) holds(msg Message) bool {
+	return !msg.Severe && q.window.contains(time.Now())
+}
+
+// flush sends any messages queued during quiet hours as a single batch.
+func (q *quietNotifier) flush() error {
+	quietQueueMu.Lock()
+	defer quietQueueMu.Unlock()
+	queue, err := loadQuietQueue()
+	if err != nil {
+		return err
+	}
+	queued := queue[q.channel]
+	if len(queued) == 0 {
+		return nil
+	}
+	batch := Message{Title: fmt.Sprintf("%d notifications held during quiet hours", len(queued))}
+	for _, m := range queued {
+		batch.Parts = append(batch.Parts, fmt.Sprintf("%s %s: %s", m.At.Local().Format("15:04"), m.Title, m.Body))
+	}
+	if err := q.next.Notify(batch); err != nil {
+		return err
+	}
+	delete(queue, q.channel)
+	return saveQuietQueue(queue)
+}
+
+// withQuietHours wraps n if the channel has quiet hours configured.
+func withQuietHours(channel string, n Notifier) (Notifier, error) {
+	spec, ok := config.QuietHours[channel]
+	if !ok {
+		return n, nil
+	}
+	window, err := parseQuietWindow(spec)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", channel, err)
+	}
+	return &quietNotifier{channel: channel, window: window, next: n}, nil
+}
+
+// flushQuietQueues delivers held messages for every channel whose quiet
+// hours have ended, so the morning batch doesn't wait for the next message.
+func flushQuietQueues() error {
+	quietQueueMu.Lock()
+	queue, err := loadQuietQueue()
+	quietQueueMu.Unlock()
+	if err != nil {
+		return err
+	}
+	channels := make([]string, 0, len(queue))
+	for channel := range queue {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+
+	var errs error
+	for _, channel := range channels {
+		targets, err := newNotifiers([]string{channel})
+		if err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+		q, ok := targets[0].(*quietNotifier)
+		if !ok || q.window.contains(time.Now()) {
+			continue
+		}
+		if err := q.flush(); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("sending via %s: %w", channel, err))
+		}
+	}
+	return errs
+}
+
+// quietQueueMu serializes access to the quiet-hours queue file.
+var quietQueueMu sync.Mutex
+
+func quietQueuePath() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "quiet-queue.json"), nil
+}
+
+// loadQuietQueue returns the held messages keyed by channel.
+func loadQuietQueue() (map[string][]queuedMessage, error) {
+	path, err := quietQueuePath()
+	if err != nil {
+		return nil, err
+	}
+	return readMessageQueue(path, "quiet-hours queue")
+}
+
+func saveQuietQueue(queue map[string][]queuedMessage) error {
+	path, err := quietQueuePath()
+	if err != nil {
+		return err
+	}
+	return writeMessageQueue(path, "quiet-hours queue", queue)
+}
+
+func enqueueQuiet(channel string, msg Message, at time.Time) error {
+	quietQueueMu.Lock()
+	defer quietQueueMu.Unlock()
+	queue, err := loadQuietQueue()
+	if err != nil {
+		return err
+	}
+	queue[channel] = append(queue[channel], queuedMessage{At: at, Title: msg.Title, Body: msg.Body()})
+	return saveQuietQueue(queue)
+}
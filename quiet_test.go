@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingNotifier collects the titles it is sent, failing for titles
+// starting with fail.
+type recordingNotifier struct {
+	fail string
+	sent []string
+}
+
+func (r *recordingNotifier) Notify(msg Message) error {
+	if r.fail != "" && strings.HasPrefix(msg.Title, r.fail) {
+		return errors.New("channel down")
+	}
+	r.sent = append(r.sent, msg.Title)
+	return nil
+}
+
+func TestQuietNotifier(t *testing.T) {
+	// The windows are chosen so the test doesn't depend on the time of day.
+	allDay, never := quietWindow{0, 24 * 60}, quietWindow{0, 0}
+	tests := []struct {
+		name    string
+		window  quietWindow
+		held    []string
+		msg     Message
+		fail    string
+		want    []string
+		wantErr bool
+		// wantHeld is how many messages are still queued afterwards.
+		wantHeld int
+	}{
+		{"held", allDay, nil, Message{Title: "Nairobi"}, "", nil, false, 1},
+		{"severe during quiet hours", allDay, []string{"Nairobi"}, Message{Title: "Storm", Severe: true}, "", []string{"Storm"}, false, 1},
+		{"after quiet hours", never, []string{"Nairobi", "Mombasa"}, Message{Title: "Kisumu"}, "", []string{"2 notifications held during quiet hours", "Kisumu"}, false, 0},
+		{"failed batch", never, []string{"Nairobi"}, Message{Title: "Kisumu"}, "1 notifications", []string{"Kisumu"}, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_STATE_HOME", t.TempDir())
+			for _, title := range tt.held {
+				if err := enqueueQuiet("sms", Message{Title: title}, time.Now()); err != nil {
+					t.Fatal(err)
+				}
+			}
+			next := &recordingNotifier{fail: tt.fail}
+			q := &quietNotifier{channel: "sms", window: tt.window, next: next}
+			if err := q.Notify(tt.msg); (err != nil) != tt.wantErr {
+				t.Errorf("Notify error = %v, want error %v", err, tt.wantErr)
+			}
+			if strings.Join(next.sent, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("sent %q, want %q", next.sent, tt.want)
+			}
+			queue, err := loadQuietQueue()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := len(queue["sms"]); n != tt.wantHeld {
+				t.Errorf("%d messages held, want %d", n, tt.wantHeld)
+			}
+		})
+	}
+}
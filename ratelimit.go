@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// idleClientTimeout is how long a client's rate limiter is kept after its
+// last request.
+const idleClientTimeout = 10 * time.Minute
+
+// newRateLimiter allows perMinute requests a minute, in bursts of up to
+// that many, or returns nil for no limit.
+func newRateLimiter(perMinute int) *rate.Limiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(float64(perMinute)/60), perMinute)
+}
+
+// clientLimiter is one client's rate limiter.
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// rateLimits enforces a per-client limit and a global limit across all
+// clients. Clients are identified by API key name when authenticated and
+// by IP address otherwise.
+type rateLimits struct {
+	global      *rate.Limiter
+	perClient   int
+	tokenLimits map[string]int
+
+	mu        sync.Mutex
+	clients   map[string]*clientLimiter
+	lastPrune time.Time
+}
+
+func newRateLimits(cfg ServerConfig) *rateLimits {
+	l := &rateLimits{
+		global:      newRateLimiter(cfg.GlobalRateLimit),
+		perClient:   cfg.RateLimit,
+		tokenLimits: make(map[string]int),
+		clients:     make(map[string]*clientLimiter),
+	}
+	for _, t := range cfg.Tokens {
+		if t.RateLimit > 0 {
+			l.tokenLimits[t.Name] = t.RateLimit
+		}
+	}
+	return l
+}
+
+// clientLimiter returns the limiter for the request's client, or nil if
+// it is unlimited.
+func (l *rateLimits) clientLimiter(r *http.Request) *rate.Limiter {
+	id, perMinute := "", l.perClient
+	if client, ok := r.Context().Value(clientKey{}).(*apiClient); ok {
+		id = "key:" + client.name
+		if limit, ok := l.tokenLimits[client.name]; ok {
+			perMinute = limit
+		}
+	} else {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		id = "ip:" + host
+	}
+	if perMinute <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.lastPrune) > idleClientTimeout {
+		for k, c := range l.clients {
+			if now.Sub(c.lastSeen) > idleClientTimeout {
+				delete(l.clients, k)
+			}
+		}
+		l.lastPrune = now
+	}
+	c, ok := l.clients[id]
+	if !ok {
+		c = &clientLimiter{limiter: newRateLimiter(perMinute)}
+		l.clients[id] = c
+	}
+	c.lastSeen = now
+	return c.limiter
+}
+
+// middleware answers 429 with Retry-After when either the client's or the
+// global limit is exhausted. A request refused by one limit doesn't count
+// against the other.
+func (l *rateLimits) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		var reservations []*rate.Reservation
+		for _, limiter := range []*rate.Limiter{l.clientLimiter(r), l.global} {
+			if limiter == nil {
+				continue
+			}
+			res := limiter.ReserveN(now, 1)
+			if delay := res.DelayFrom(now); delay > 0 {
+				res.CancelAt(now)
+				for _, earlier := range reservations {
+					earlier.CancelAt(now)
+				}
+				writeTooManyRequests(w, delay)
+				return
+			}
+			reservations = append(reservations, res)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeTooManyRequests answers 429 with a Retry-After in whole seconds.
+func writeTooManyRequests(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	writeJSONError(w, http.StatusTooManyRequests, fmt.Errorf("rate limit exceeded, retry in %s", wait.Round(time.Second)))
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRateLimits(t *testing.T) {
+	type request struct {
+		client     string // "ip:ADDR" or "key:NAME"
+		wantStatus int
+	}
+	tests := []struct {
+		name     string
+		cfg      ServerConfig
+		requests []request
+	}{
+		{
+			"unlimited",
+			ServerConfig{},
+			[]request{{"ip:10.0.0.1", 200}, {"ip:10.0.0.1", 200}, {"ip:10.0.0.1", 200}},
+		},
+		{
+			"per client",
+			ServerConfig{RateLimit: 2},
+			[]request{{"ip:10.0.0.1", 200}, {"ip:10.0.0.1", 200}, {"ip:10.0.0.1", 429}, {"ip:10.0.0.2", 200}},
+		},
+		{
+			// A request refused by its client's limit leaves the global
+			// budget alone, and one refused globally leaves the client's.
+			"global",
+			ServerConfig{RateLimit: 2, GlobalRateLimit: 3},
+			[]request{
+				{"ip:10.0.0.1", 200}, {"ip:10.0.0.1", 200}, {"ip:10.0.0.1", 429},
+				{"ip:10.0.0.2", 200}, {"ip:10.0.0.2", 429}, {"ip:10.0.0.3", 429},
+			},
+		},
+		{
+			"per key",
+			ServerConfig{RateLimit: 1, Tokens: []ServerToken{{Name: "dashboard", RateLimit: 3}}},
+			[]request{
+				{"key:dashboard", 200}, {"key:dashboard", 200}, {"key:dashboard", 200}, {"key:dashboard", 429},
+				{"key:other", 200}, {"key:other", 429}, {"ip:10.0.0.1", 200}, {"ip:10.0.0.1", 429},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newRateLimits(tt.cfg).middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			for i, req := range tt.requests {
+				r := httptest.NewRequest(http.MethodGet, "/weather", nil)
+				if name, ok := strings.CutPrefix(req.client, "key:"); ok {
+					r = r.WithContext(context.WithValue(r.Context(), clientKey{}, &apiClient{name: name}))
+				} else {
+					r.RemoteAddr = strings.TrimPrefix(req.client, "ip:") + ":40000"
+				}
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, r)
+				if w.Code != req.wantStatus {
+					t.Errorf("request %d from %s: status %d, want %d", i+1, req.client, w.Code, req.wantStatus)
+				}
+				if w.Code == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+					t.Errorf("request %d from %s: 429 without Retry-After", i+1, req.client)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Cache results reported in the server's X-Cache header.
+const (
+	cacheHit   = "HIT"   // fresh cached response
+	cacheStale = "STALE" // stale response served while refreshing
+	cacheMiss  = "MISS"  // fetched for this request
+)
+
+// cacheKey identifies one upstream response. Responses fetched with a
+// caller's own API key are kept apart by tenant.
+type cacheKey struct {
+	kind   string
+	loc    Location
+	tenant string
+}
+
+type cacheEntry struct {
+	value   any
+	fetched time.Time
+}
+
+// responseCache keeps upstream responses in memory. Within ttl a cached
+// response is served as is; for stale longer it is still served while a
+// single background fetch refreshes it. Concurrent misses for the same key
+// share one upstream request. Errors are not cached.
+type responseCache struct {
+	ttl, stale time.Duration
+
+	mu      sync.Mutex
+	entries map[cacheKey]cacheEntry
+	flights singleflight.Group
+}
+
+func newResponseCache(ttl, stale time.Duration) *responseCache {
+	return &responseCache{ttl: ttl, stale: stale, entries: make(map[cacheKey]cacheEntry)}
+}
+
+// get returns the cached value for key, calling fetch as needed, and which
+// of cacheHit, cacheStale or cacheMiss applied.
+func (c *responseCache) get(key cacheKey, fetch func() (any, error)) (any, string, error) {
+	if c.ttl <= 0 {
+		value, err := fetch()
+		return value, cacheMiss, err
+	}
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	age := time.Since(entry.fetched)
+	switch {
+	case ok && age < c.ttl:
+		return entry.value, cacheHit, nil
+	case ok && age < c.ttl+c.stale:
+		c.flights.DoChan(c.flightName(key), func() (any, error) { return c.refresh(key, fetch) })
+		return entry.value, cacheStale, nil
+	}
+
+	value, err, _ := c.flights.Do(c.flightName(key), func() (any, error) { return c.refresh(key, fetch) })
+	return value, cacheMiss, err
+}
+
+// refresh fetches a fresh value for key and stores it, dropping entries
+// too old to be served.
+func (c *responseCache) refresh(key cacheKey, fetch func() (any, error)) (any, error) {
+	value, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = cacheEntry{value: value, fetched: now}
+	for k, e := range c.entries {
+		if now.Sub(e.fetched) >= c.ttl+c.stale {
+			delete(c.entries, k)
+		}
+	}
+	return value, nil
+}
+
+func (c *responseCache) flightName(key cacheKey) string {
+	return fmt.Sprintf("%s|%s|%+v", key.tenant, key.kind, key.loc)
+}
+
+// cachedResponse describes one cache entry for the admin API.
+type cachedResponse struct {
+	Kind     string    `json:"kind"`
+	Location string    `json:"location"`
+	Tenant   bool      `json:"tenant,omitempty"`
+	Fetched  time.Time `json:"fetched"`
+	Stale    bool      `json:"stale,omitempty"`
+}
+
+// list returns the cached responses, oldest first.
+func (c *responseCache) list() []cachedResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries := make([]cachedResponse, 0, len(c.entries))
+	for k, e := range c.entries {
+		entries = append(entries, cachedResponse{
+			Kind:     k.kind,
+			Location: k.loc.String(),
+			Tenant:   k.tenant != "",
+			Fetched:  e.fetched,
+			Stale:    time.Since(e.fetched) >= c.ttl,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Fetched.Before(entries[j].Fetched) })
+	return entries
+}
+
+// purge drops the entries for loc, of every kind and tenant, or all
+// entries when loc is nil, and returns how many were dropped.
+func (c *responseCache) purge(loc *Location) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for k := range c.entries {
+		if loc == nil || k.loc == *loc {
+			delete(c.entries, k)
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestResponseCacheGet(t *testing.T) {
+	key := cacheKey{kind: "current", loc: Location{Name: "Nairobi"}}
+	tests := []struct {
+		name       string
+		ttl, stale time.Duration
+		age        time.Duration // of the cached entry; negative for none
+		wantValue  string
+		wantResult string
+	}{
+		{"miss", time.Minute, time.Minute, -1, "fresh", cacheMiss},
+		{"hit", time.Minute, time.Minute, 30 * time.Second, "cached", cacheHit},
+		{"stale", time.Minute, time.Minute, 90 * time.Second, "cached", cacheStale},
+		{"too old", time.Minute, time.Minute, 3 * time.Minute, "fresh", cacheMiss},
+		{"no stale window", time.Minute, 0, 90 * time.Second, "fresh", cacheMiss},
+		{"disabled", 0, time.Minute, time.Second, "fresh", cacheMiss},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newResponseCache(tt.ttl, tt.stale)
+			if tt.age >= 0 {
+				c.entries[key] = cacheEntry{value: "cached", fetched: time.Now().Add(-tt.age)}
+			}
+			refreshed := make(chan struct{})
+			value, result, err := c.get(key, func() (any, error) {
+				defer close(refreshed)
+				return "fresh", nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if value != tt.wantValue || result != tt.wantResult {
+				t.Errorf("get = %v, %s; want %s, %s", value, result, tt.wantValue, tt.wantResult)
+			}
+			if result == cacheHit {
+				return
+			}
+			// Misses fetch right away and stale entries in the background;
+			// either way the fresh value is stored, unless caching is off.
+			select {
+			case <-refreshed:
+			case <-time.After(time.Second):
+				t.Fatal("no fetch")
+			}
+			if tt.ttl <= 0 {
+				return
+			}
+			// The fetched value is stored just after fetch returns.
+			for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+				value, result, _ := c.get(key, nil)
+				if value == "fresh" && result == cacheHit {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("after the fetch, get = %v, %s; want fresh, %s", value, result, cacheHit)
+				}
+			}
+		})
+	}
+}
+
+func TestResponseCacheKeepsTenantsApart(t *testing.T) {
+	c := newResponseCache(time.Minute, time.Minute)
+	loc := Location{Name: "Nairobi"}
+	for _, tenant := range []string{"", "alice", "bob"} {
+		value, result, _ := c.get(cacheKey{kind: "current", loc: loc, tenant: tenant}, func() (any, error) { return tenant, nil })
+		if value != tenant || result != cacheMiss {
+			t.Errorf("tenant %q: get = %v, %s; want its own miss", tenant, value, result)
+		}
+	}
+	if n := c.purge(&loc); n != 3 {
+		t.Errorf("purge dropped %d entries, want 3", n)
+	}
+}
+
+func TestResponseCacheDoesNotCacheErrors(t *testing.T) {
+	c := newResponseCache(time.Minute, time.Minute)
+	key := cacheKey{kind: "current", loc: Location{Name: "Nairobi"}}
+	failure := errors.New("upstream down")
+	if _, _, err := c.get(key, func() (any, error) { return nil, failure }); !errors.Is(err, failure) {
+		t.Fatalf("get error = %v, want %v", err, failure)
+	}
+	value, result, err := c.get(key, func() (any, error) { return "fresh", nil })
+	if err != nil || value != "fresh" || result != cacheMiss {
+		t.Errorf("after an error, get = %v, %s, %v; want fresh, %s", value, result, err, cacheMiss)
+	}
+}
+
+func TestResponseCacheCoalescesMisses(t *testing.T) {
+	c := newResponseCache(time.Minute, time.Minute)
+	key := cacheKey{kind: "forecast", loc: Location{Name: "Nairobi"}}
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	fetch := func() (any, error) {
+		fetches.Add(1)
+		<-release
+		return "fresh", nil
+	}
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Go(func() {
+			if value, _, err := c.get(key, fetch); err != nil || value != "fresh" {
+				t.Errorf("get = %v, %v", value, err)
+			}
+		})
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("%d fetches for concurrent misses, want 1", n)
+	}
+}
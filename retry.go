@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Mugambi645/weather-tool/pkg/weather"
+)
+
+// RateLimitError is weather.RateLimitError: the API key's quota is used up.
+type RateLimitError = weather.RateLimitError
+
+// exitRateLimited is the exit status when the API's rate limit stopped a
+// command, EX_TEMPFAIL from sysexits.h, so scripts can tell it from other
+// failures and try again later.
+const exitRateLimited = 75
+
+// RetryConfig overrides weather.DefaultRetryPolicy for OpenWeatherMap
+// requests. Unset fields keep the default.
+type RetryConfig struct {
+	// Attempts is the most requests made for one fetch; 1 disables
+	// retries.
+	Attempts int `json:"attempts,omitempty"`
+	// BaseDelay is the wait before the first retry, doubling after each,
+	// e.g. "500ms".
+	BaseDelay string `json:"base_delay,omitempty"`
+	// MaxDelay caps the wait, e.g. "30s"; a Retry-After asking for longer
+	// fails the fetch instead.
+	MaxDelay string `json:"max_delay,omitempty"`
+	// Jitter spreads each wait by up to this fraction either way.
+	Jitter *float64 `json:"jitter,omitempty"`
+	// Timeout limits each attempt, e.g. "20s"; it defaults to
+	// weather.DefaultTimeout and "0s" removes the limit.
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// applyRetryConfig sets the OpenWeatherMap client's retry policy and
+// timeout from the config file.
+func applyRetryConfig() error {
+	c := config.Retry
+	policy := weather.DefaultRetryPolicy
+	if c.Attempts != 0 {
+		policy.Attempts = c.Attempts
+	}
+	owmClient.Timeout = weather.DefaultTimeout
+	for _, d := range []struct {
+		name, value string
+		target      *time.Duration
+	}{
+		{"base_delay", c.BaseDelay, &policy.BaseDelay},
+		{"max_delay", c.MaxDelay, &policy.MaxDelay},
+		{"timeout", c.Timeout, &owmClient.Timeout},
+	} {
+		if d.value == "" {
+			continue
+		}
+		value, err := time.ParseDuration(d.value)
+		if err != nil || value < 0 {
+			return fmt.Errorf("invalid retry %s %q in the config file, use a duration such as 2s", d.name, d.value)
+		}
+		*d.target = value
+	}
+	if c.Jitter != nil {
+		if *c.Jitter < 0 || *c.Jitter > 1 {
+			return fmt.Errorf("invalid retry jitter %v in the config file, use 0 to 1", *c.Jitter)
+		}
+		policy.Jitter = *c.Jitter
+	}
+	owmClient.Retry = &policy
+	return nil
+}
+
+// exitCode is the exit status for a command that failed with err.
+func exitCode(err error) int {
+	var rateErr *RateLimitError
+	if errors.As(err, &rateErr) {
+		return exitRateLimited
+	}
+	return 1
+}
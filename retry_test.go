@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Mugambi645/weather-tool/pkg/weather"
+)
+
+func TestExitCode(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3600")
+		http.Error(w, `{"cod": 429, "message": "quota exceeded"}`, http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+	saved := owmClient
+	owmClient = &weather.Client{BaseURL: srv.URL, Retry: &weather.DefaultRetryPolicy}
+	defer func() { owmClient = saved }()
+
+	var data CurrentWeatherResponse
+	rateErr := fetchFrom(defaultProvider, "current", Location{Name: "Nairobi"}, "key", &data)
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"rate limited", rateErr, exitRateLimited},
+		{"wrapped", fmt.Errorf("fetching current weather for Nairobi: %w", rateErr), exitRateLimited},
+		{"other", errors.New("city not found"), 1},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("%s: exitCode(%v) = %d, want %d", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
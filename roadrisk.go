@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Mugambi645/weather-tool/pkg/weather"
+)
+
+const roadRiskURL = "https://api.openweathermap.org/data/2.5/roadrisk"
+
+// roadStates names the Road Risk API's road surface state codes.
+var roadStates = []string{
+	"no report", "dry", "moist", "moist, treated", "wet", "wet, treated",
+	"ice", "frost", "snow", "snow/ice watch", "snow/ice warning",
+	"wet above freezing", "wet below freezing", "absent", "unknown",
+}
+
+// RoutePoint is a point along a route and when it will be passed.
+type RoutePoint struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+	Dt  int64   `json:"dt"`
+}
+
+// RoadRisk is the Road Risk API's weather, road state and alerts for one
+// route point. Temperatures are in Kelvin.
+type RoadRisk struct {
+	Dt      int64      `json:"dt"`
+	Coord   [2]float64 `json:"coord"` // lat, lon
+	Weather struct {
+		Temp                   float64 `json:"temp"`
+		WindSpeed              float64 `json:"wind_speed"`
+		PrecipitationIntensity float64 `json:"precipitation_intensity"`
+		DewPoint               float64 `json:"dew_point"`
+	} `json:"weather"`
+	Road struct {
+		State int     `json:"state"`
+		Temp  float64 `json:"temp"`
+	} `json:"road"`
+	Alerts []struct {
+		SenderName string `json:"sender_name"`
+		Event      string `json:"event"`
+		EventLevel int    `json:"event_level"`
+	} `json:"alerts"`
+}
+
+// GetRoadRisk fetches road conditions along a route. It needs a key with
+// access to the Road Risk API.
+func GetRoadRisk(track []RoutePoint, apiKey string) ([]RoadRisk, error) {
+	var risks []RoadRisk
+	body := struct {
+		Track []RoutePoint `json:"track"`
+	}{track}
+	if err := sendJSON(http.MethodPost, roadRiskURL+"?"+url.Values{"appid": {apiKey}}.Encode(), body, &risks); err != nil {
+		return nil, planError(err, "the Road Risk API")
+	}
+	return risks, nil
+}
+
+// decodePolyline decodes an encoded polyline, the format routing services
+// such as Google, OSRM and Valhalla use for route geometry.
+func decodePolyline(encoded string) ([]RoutePoint, error) {
+	var points []RoutePoint
+	var lat, lon int
+	for i := 0; i < len(encoded); {
+		var deltas [2]int
+		for j := range deltas {
+			result, shift := 0, 0
+			for {
+				if i >= len(encoded) {
+					return nil, fmt.Errorf("invalid polyline: truncated")
+				}
+				b := int(encoded[i]) - 63
+				i++
+				if b < 0 || b > 63 {
+					return nil, fmt.Errorf("invalid polyline: unexpected character %q", encoded[i-1])
+				}
+				result |= (b & 0x1f) << shift
+				shift += 5
+				if b < 0x20 {
+					break
+				}
+			}
+			if result&1 != 0 {
+				deltas[j] = ^(result >> 1)
+			} else {
+				deltas[j] = result >> 1
+			}
+		}
+		lat += deltas[0]
+		lon += deltas[1]
+		points = append(points, RoutePoint{Lat: float64(lat) / 1e5, Lon: float64(lon) / 1e5})
+	}
+	return points, nil
+}
+
+// parseRoutePoint parses "lat,lon" or "lat,lon,time", where time is RFC
+// 3339 or Unix seconds.
+func parseRoutePoint(s string) (RoutePoint, error) {
+	fields := strings.Split(s, ",")
+	if len(fields) != 2 && len(fields) != 3 {
+		return RoutePoint{}, fmt.Errorf("invalid route point %q, use lat,lon or lat,lon,time", s)
+	}
+	lat, err1 := strconv.ParseFloat(strings.TrimSpace(fields[0]), 64)
+	lon, err2 := strconv.ParseFloat(strings.TrimSpace(fields[1]), 64)
+	if err1 != nil || err2 != nil {
+		return RoutePoint{}, fmt.Errorf("invalid coordinates in route point %q", s)
+	}
+	if err := weather.ValidateCoordinates(lat, lon); err != nil {
+		return RoutePoint{}, fmt.Errorf("route point %q: %w", s, err)
+	}
+	p := RoutePoint{Lat: lat, Lon: lon}
+	if len(fields) == 3 {
+		at := strings.TrimSpace(fields[2])
+		if t, err := time.Parse(time.RFC3339, at); err == nil {
+			p.Dt = t.Unix()
+		} else if p.Dt, err = strconv.ParseInt(at, 10, 64); err != nil {
+			return RoutePoint{}, fmt.Errorf("invalid time in route point %q, use RFC 3339 or Unix seconds", s)
+		}
+	}
+	return p, nil
+}
+
+// readRouteFile reads route points, one "lat,lon[,time]" per line. Blank
+// lines and lines starting with # are skipped.
+func readRouteFile(path string) ([]RoutePoint, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open route: %w", err)
+	}
+	defer f.Close()
+	var points []RoutePoint
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p, err := parseRoutePoint(line)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read route: %w", err)
+	}
+	return points, nil
+}
+
+// runRoadRisk implements `weather roadrisk`.
+func runRoadRisk(args []string) error {
+	fs := flag.NewFlagSet("roadrisk", flag.ExitOnError)
+	var pointArgs stringList
+	fs.Var(&pointArgs, "point", "Route point as lat,lon or lat,lon,time (repeatable, in driving order)")
+	routeFile := fs.String("route", "", "File with one lat,lon[,time] route point per line")
+	polyline := fs.String("polyline", "", "Route as an encoded polyline, e.g. from a routing service")
+	depart := fs.String("depart", "", "Departure time for points without one, RFC 3339 (default now)")
+	every := fs.Duration("every", 15*time.Minute, "Time between consecutive points without their own time")
+	fs.Parse(args)
+
+	usage := fmt.Errorf("usage: weather roadrisk (--point LAT,LON[,TIME]... | --route FILE | --polyline ENCODED) [--depart TIME] [--every DURATION]")
+	if fs.NArg() > 0 || countSet(len(pointArgs) > 0, *routeFile != "", *polyline != "") != 1 {
+		return usage
+	}
+	var track []RoutePoint
+	var err error
+	switch {
+	case len(pointArgs) > 0:
+		for _, s := range pointArgs {
+			p, err := parseRoutePoint(s)
+			if err != nil {
+				return err
+			}
+			track = append(track, p)
+		}
+	case *routeFile != "":
+		track, err = readRouteFile(*routeFile)
+	default:
+		track, err = decodePolyline(*polyline)
+	}
+	if err != nil {
+		return err
+	}
+	if len(track) == 0 {
+		return fmt.Errorf("the route has no points")
+	}
+
+	start := time.Now()
+	if *depart != "" {
+		if start, err = time.Parse(time.RFC3339, *depart); err != nil {
+			return fmt.Errorf("invalid --depart %q, use RFC 3339", *depart)
+		}
+	}
+	for i := range track {
+		if track[i].Dt == 0 {
+			track[i].Dt = start.Add(time.Duration(i) * *every).Unix()
+		}
+	}
+
+	risks, err := GetRoadRisk(track, apiKeyFromEnv())
+	if err != nil {
+		return fmt.Errorf("fetching road risk: %w", err)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	temp := displayUnits.temp.symbol
+	fmt.Fprintf(tw, "#\tTIME\tLOCATION\tROAD\tROAD %s\tAIR %s\tPRECIP mm/h\tWIND %s\tALERTS\n", temp, temp, displayUnits.speed.symbol)
+	for i, r := range risks {
+		state := "unknown"
+		if r.Road.State >= 0 && r.Road.State < len(roadStates) {
+			state = roadStates[r.Road.State]
+		}
+		var alerts []string
+		for _, a := range r.Alerts {
+			alerts = append(alerts, a.Event)
+		}
+		if len(alerts) == 0 {
+			alerts = []string{"-"}
+		}
+		fmt.Fprint(tw, lsprintf("%d\t%s\t%.4f, %.4f\t%s\t%.*f\t%.*f\t%.1f\t%.*f\t%s\n", i+1,
+			time.Unix(r.Dt, 0).Local().Format("Mon 15:04"), r.Coord[0], r.Coord[1], state,
+			decimals("temp", 1), showTemp(r.Road.Temp-kelvinOffset), decimals("temp", 1), showTemp(r.Weather.Temp-kelvinOffset),
+			r.Weather.PrecipitationIntensity, decimals("wind", 1), showSpeed(r.Weather.WindSpeed), strings.Join(alerts, "; ")))
+	}
+	return tw.Flush()
+}
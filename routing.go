@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// NotifyRoute sends a daemon task's notifications through one channel,
+// optionally only from a minimum severity and with its own wording, e.g.
+// a frost rule that goes to SMS only when severe but always to Pushover.
+type NotifyRoute struct {
+	Channel string `json:"channel"`
+	// MinSeverity is the least severe alert sent through the channel:
+	// "warning" (the default), "severe" or "extreme". Messages without a
+	// severity, such as digests, are always sent.
+	MinSeverity string `json:"min_severity,omitempty"`
+	// Template is a text/template replacing the message body, executed
+	// with the Message, e.g. "{{.Title}} ({{.Severity}}): {{index .Parts 0}}".
+	Template string `json:"template,omitempty"`
+}
+
+// alertSeverities ranks the alert severities, least serious first.
+var alertSeverities = []string{severityWarning, severitySevere, severityExtreme}
+
+// routedNotifier applies a route's severity filter and template before
+// passing messages on.
+type routedNotifier struct {
+	minSeverity string
+	template    *template.Template
+	next        Notifier
+}
+
+// skips reports whether msg is below the route's minimum severity.
+func (r *routedNotifier) skips(msg Message) bool {
+	return msg.Severity != severityNone && slices.Index(alertSeverities, msg.Severity) < slices.Index(alertSeverities, r.minSeverity)
+}
+
+// render applies the route's template to msg.
+func (r *routedNotifier) render(msg Message) (Message, error) {
+	if r.template == nil {
+		return msg, nil
+	}
+	var body strings.Builder
+	if err := r.template.Execute(&body, msg); err != nil {
+		return msg, fmt.Errorf("rendering template: %w", err)
+	}
+	msg.Parts = []string{strings.TrimSpace(body.String())}
+	return msg, nil
+}
+
+func (r *routedNotifier) Notify(msg Message) error {
+	if r.skips(msg) {
+		return nil
+	}
+	msg, err := r.render(msg)
+	if err != nil {
+		return err
+	}
+	return r.next.Notify(msg)
+}
+
+// taskNotifiers configures a daemon task's channels followed by its routes,
+// returning the channel name of each for logs and errors.
+func taskNotifiers(task DaemonTask) ([]string, []Notifier, error) {
+	targets, err := newNotifiers(task.Channels)
+	if err != nil {
+		return nil, nil, err
+	}
+	channels := slices.Clone(task.Channels)
+	for _, route := range task.Routes {
+		r := &routedNotifier{minSeverity: severityWarning}
+		switch route.MinSeverity {
+		case "", severityWarning:
+		case severitySevere, severityExtreme:
+			r.minSeverity = route.MinSeverity
+		default:
+			return nil, nil, fmt.Errorf("route to %s: invalid min_severity %q, use warning, severe or extreme", route.Channel, route.MinSeverity)
+		}
+		if route.Template != "" {
+			if r.template, err = template.New(route.Channel).Option("missingkey=error").Parse(route.Template); err != nil {
+				return nil, nil, fmt.Errorf("route to %s: invalid template: %w", route.Channel, err)
+			}
+		}
+		next, err := newNotifiers([]string{route.Channel})
+		if err != nil {
+			return nil, nil, err
+		}
+		r.next = next[0]
+		channels = append(channels, route.Channel)
+		targets = append(targets, r)
+	}
+	if len(targets) == 0 {
+		return nil, nil, fmt.Errorf("no channels given")
+	}
+	return channels, targets, nil
+}
+
+// delivery reports what sending msg through n will do: skip it as below
+// the route's severity, or hold it back for quiet hours.
+func delivery(n Notifier, msg Message) (skip, held bool) {
+	if r, ok := n.(*routedNotifier); ok {
+		if r.skips(msg) {
+			return true, false
+		}
+		n = r.next
+	}
+	q, ok := n.(*quietNotifier)
+	return false, ok && q.holds(msg)
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/ast"
+	"github.com/expr-lang/expr/vm"
+)
+
+// ruleEnv is the variable set available to alert rule conditions. Field
+// names in conditions are the expr tags.
+type ruleEnv struct {
+	Location   string   `expr:"location"`
+	Condition  string   `expr:"condition"` // e.g. "Rain", "Snow"
+	Temp       float64  `expr:"temp"`
+	FeelsLike  float64  `expr:"feels_like"`
+	TempMin    float64  `expr:"temp_min"`
+	TempMax    float64  `expr:"temp_max"`
+	Humidity   int      `expr:"humidity"`
+	Pressure   int      `expr:"pressure"`
+	Clouds     int      `expr:"clouds"`
+	Visibility int      `expr:"visibility"` // metres, at most 10000
+	Wind       ruleWind `expr:"wind"`
+	// VisibilityClass is "dense fog", "fog", "mist", "haze", "moderate"
+	// or "clear"; see visibilityClass.
+	VisibilityClass string `expr:"visibility_class"`
+	// DewPoint, Humidex, WetBulb, WBGT and WindChill are derived from the
+	// temperature, humidity, ground-level pressure and wind; see
+	// heatstress.go.
+	DewPoint  float64 `expr:"dew_point"`
+	Humidex   float64 `expr:"humidex"`
+	WetBulb   float64 `expr:"wet_bulb"`
+	WBGT      float64 `expr:"wbgt"`
+	WindChill float64 `expr:"wind_chill"`
+	// Pop is the probability of precipitation in the next forecast slot.
+	Pop float64 `expr:"pop"`
+	// AQI is the air quality index, 1 (good) to 5 (very poor).
+	AQI   int       `expr:"aqi"`
+	Alert ruleAlert `expr:"alert"`
+	// Yesterday summarizes the weather at the location on the previous
+	// local calendar day; see summarizeDayAt.
+	Yesterday ruleDay `expr:"yesterday"`
+
+	now      time.Time
+	forecast []ForecastPoint
+}
+
+type ruleWind struct {
+	Speed float64 `expr:"speed"`
+	Gust  float64 `expr:"gust"`
+	Deg   int     `expr:"deg"`
+}
+
+type ruleAlert struct {
+	// Severity is the built-in grading: "", "severe" or "extreme".
+	Severity string `expr:"severity"`
+}
+
+type ruleDay struct {
+	TempMin float64 `expr:"temp_min"`
+	TempMax float64 `expr:"temp_max"`
+	TempAvg float64 `expr:"temp_avg"`
+	WindMax float64 `expr:"wind_max"`
+	Rain    float64 `expr:"rain"` // mm
+	// Observations is how many recorded readings the summary is based on,
+	// or 0 when it comes from the day_summary endpoint.
+	Observations int `expr:"observations"`
+}
+
+// summarizeDay aggregates the observations of location recorded on the
+// local calendar day containing day.
+func summarizeDay(observations []Observation, location string, day time.Time) ruleDay {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	end := start.AddDate(0, 0, 1)
+	var summary ruleDay
+	var total float64
+	for _, obs := range observations {
+		if obs.Location != location || obs.At.Before(start) || !obs.At.Before(end) {
+			continue
+		}
+		if summary.Observations == 0 {
+			summary.TempMin, summary.TempMax = obs.Temp, obs.Temp
+		}
+		summary.TempMin = min(summary.TempMin, obs.Temp)
+		summary.TempMax = max(summary.TempMax, obs.Temp)
+		summary.WindMax = max(summary.WindMax, obs.WindSpeed)
+		summary.Rain += obs.Rain
+		total += obs.Temp
+		summary.Observations++
+	}
+	if summary.Observations > 0 {
+		summary.TempAvg = total / float64(summary.Observations)
+	}
+	return summary
+}
+
+// forecastFields are the per-slot values the window functions aggregate.
+var forecastFields = map[string]func(p ForecastPoint) float64{
+	"temp":       func(p ForecastPoint) float64 { return p.Temp },
+	"feels_like": func(p ForecastPoint) float64 { return p.FeelsLike },
+	"temp_min":   func(p ForecastPoint) float64 { return p.TempMin },
+	"temp_max":   func(p ForecastPoint) float64 { return p.TempMax },
+	"humidity":   func(p ForecastPoint) float64 { return p.Humidity },
+	"pressure":   func(p ForecastPoint) float64 { return p.Pressure },
+	"clouds":     func(p ForecastPoint) float64 { return p.Clouds },
+	"visibility": func(p ForecastPoint) float64 { return p.Visibility },
+	"pop":        func(p ForecastPoint) float64 { return p.Pop },
+	"wind.speed": func(p ForecastPoint) float64 { return p.WindSpeed },
+	"wind.gust":  func(p ForecastPoint) float64 { return p.WindGust },
+}
+
+// windowFuncs aggregate a forecast field over the slots starting between
+// two offsets from now, e.g. max(pop, 0h, 12h).
+var windowFuncs = map[string]func(values []float64) float64{
+	"max": func(values []float64) float64 {
+		m := values[0]
+		for _, v := range values[1:] {
+			m = max(m, v)
+		}
+		return m
+	},
+	"min": func(values []float64) float64 {
+		m := values[0]
+		for _, v := range values[1:] {
+			m = min(m, v)
+		}
+		return m
+	},
+	"sum": func(values []float64) float64 {
+		var s float64
+		for _, v := range values {
+			s += v
+		}
+		return s
+	},
+	"avg": func(values []float64) float64 {
+		var s float64
+		for _, v := range values {
+			s += v
+		}
+		return s / float64(len(values))
+	},
+}
+
+// durationLiteral matches bare durations such as 12h or 90m, which rules
+// may use as window offsets. It also matches string literals, so that
+// compileRule can leave text like "Area 12m" alone.
+var durationLiteral = regexp.MustCompile(`"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'|` + "`[^`]*`" + `|\b\d+(\.\d+)?[hm]\b`)
+
+// Rule is a compiled alert condition.
+type Rule struct {
+	Source  string
+	program *vm.Program
+	// needsForecast and needsAQI record which extra API calls evaluating
+	// the condition requires; needsHistory that it reads recorded
+	// observations.
+	needsForecast bool
+	needsAQI      bool
+	needsHistory  bool
+}
+
+// compileRule parses and type-checks condition, which must be a boolean
+// expression over ruleEnv.
+func compileRule(condition string) (*Rule, error) {
+	source := durationLiteral.ReplaceAllStringFunc(condition, func(d string) string {
+		if strings.ContainsRune("\"'`", rune(d[0])) {
+			return d
+		}
+		return fmt.Sprintf("duration(%q)", d)
+	})
+	rule := &Rule{Source: condition}
+	patcher := &rulePatcher{rule: rule}
+	opts := []expr.Option{expr.Env(ruleEnv{}), expr.AsBool(), expr.Patch(patcher)}
+	for name, aggregate := range windowFuncs {
+		opts = append(opts, expr.Function(name, windowFunc(name, aggregate)))
+	}
+	program, err := expr.Compile(source, opts...)
+	if err == nil {
+		err = patcher.err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition %q: %w", condition, err)
+	}
+	rule.program = program
+	return rule, nil
+}
+
+// windowFunc adapts an aggregate to expr. The patcher turns the field
+// argument into its name and appends $env, so a call arrives as
+// (field, from, to, env).
+func windowFunc(name string, aggregate func([]float64) float64) func(params ...any) (any, error) {
+	return func(params ...any) (any, error) {
+		value := forecastFields[params[0].(string)]
+		from, err := windowOffset(params[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		to, err := windowOffset(params[2])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		env := params[3].(ruleEnv)
+
+		var values []float64
+		for _, point := range env.forecast {
+			offset := point.At.Sub(env.now)
+			// Include the slot already under way at the start of the window.
+			if offset > from-3*time.Hour && offset <= to {
+				values = append(values, value(point))
+			}
+		}
+		if len(values) == 0 {
+			return nil, fmt.Errorf("%s: no forecast data between %s and %s", name, from, to)
+		}
+		return aggregate(values), nil
+	}
+}
+
+// windowOffset accepts a duration or a number of hours.
+func windowOffset(v any) (time.Duration, error) {
+	switch v := v.(type) {
+	case time.Duration:
+		return v, nil
+	case int:
+		return time.Duration(v) * time.Hour, nil
+	case float64:
+		return time.Duration(v * float64(time.Hour)), nil
+	}
+	return 0, fmt.Errorf("invalid window offset %v, use e.g. 12h", v)
+}
+
+// rulePatcher rewrites window function calls and notes which data the
+// condition uses.
+type rulePatcher struct {
+	rule *Rule
+	err  error
+}
+
+func (p *rulePatcher) Visit(node *ast.Node) {
+	switch n := (*node).(type) {
+	case *ast.IdentifierNode:
+		switch n.Value {
+		case "pop":
+			p.rule.needsForecast = true
+		case "aqi":
+			p.rule.needsAQI = true
+		case "yesterday":
+			p.rule.needsHistory = true
+		}
+	case *ast.CallNode:
+		callee, ok := n.Callee.(*ast.IdentifierNode)
+		if !ok || windowFuncs[callee.Value] == nil {
+			return
+		}
+		if len(n.Arguments) != 3 {
+			p.err = fmt.Errorf("%s takes a field and two offsets, e.g. %s(pop, 0h, 12h)", callee.Value, callee.Value)
+			return
+		}
+		field := fieldName(n.Arguments[0])
+		if _, ok := forecastFields[field]; !ok {
+			p.err = fmt.Errorf("%s: %s is not a forecast field", callee.Value, field)
+			return
+		}
+		n.Arguments[0] = &ast.StringNode{Value: field}
+		n.Arguments = append(n.Arguments, &ast.IdentifierNode{Value: "$env"})
+		p.rule.needsForecast = true
+	}
+}
+
+// fieldName returns the dotted name of an identifier or member access such
+// as wind.gust, or "" for anything else.
+func fieldName(node ast.Node) string {
+	switch n := node.(type) {
+	case *ast.IdentifierNode:
+		return n.Value
+	case *ast.MemberNode:
+		prop, ok := n.Property.(*ast.StringNode)
+		if base := fieldName(n.Node); ok && base != "" {
+			return base + "." + prop.Value
+		}
+	}
+	return ""
+}
+
+// newRuleEnv builds the variables for a rule at loc from the fetched data;
+// forecast and air may be nil when the rule doesn't need them.
+func newRuleEnv(loc Location, current *CurrentWeatherResponse, forecast *ForecastResponse, air *AirPollutionResponse, now time.Time) ruleEnv {
+	env := ruleEnv{
+		Location:   current.Name,
+		Temp:       current.Main.Temp,
+		FeelsLike:  current.Main.FeelsLike,
+		TempMin:    current.Main.TempMin,
+		TempMax:    current.Main.TempMax,
+		Humidity:   current.Main.Humidity,
+		Pressure:   current.Main.Pressure,
+		Clouds:     current.Clouds.All,
+		Visibility: current.Visibility,
+		Wind:       ruleWind{Speed: current.Wind.Speed, Gust: current.Wind.Gust, Deg: current.Wind.Deg},
+		Alert:      ruleAlert{Severity: alertSeverity(loc, current)},
+		now:        now,
+	}
+	env.VisibilityClass = visibilityClass(current.Visibility, current.Main.Humidity)
+	humidity := float64(current.Main.Humidity)
+	env.DewPoint = dewPoint(current.Main.Temp, humidity)
+	env.Humidex = humidex(current.Main.Temp, humidity)
+	env.WetBulb = wetBulb(current.Main.Temp, humidity, current.Main.StationPressure())
+	env.WBGT = wbgt(current.Main.Temp, humidity)
+	env.WindChill = windChill(current.Main.Temp, current.Wind.Speed)
+	if len(current.Weather) > 0 {
+		env.Condition = current.Weather[0].Main
+	}
+	if forecast != nil {
+		env.forecast = forecastPointsFrom(forecast)
+		if len(env.forecast) > 0 {
+			env.Pop = env.forecast[0].Pop
+		}
+	}
+	if air != nil {
+		env.AQI = air.List[0].Main.AQI
+	}
+	return env
+}
+
+// Eval fetches whatever the rule needs for loc and evaluates it.
+func (r *Rule) Eval(loc Location, current *CurrentWeatherResponse, apiKey string) (bool, error) {
+	var forecast *ForecastResponse
+	var air *AirPollutionResponse
+	var err error
+	if r.needsForecast {
+		if forecast, err = GetForecastAt(loc, apiKey); err != nil {
+			return false, fmt.Errorf("fetching forecast for %s: %w", loc, err)
+		}
+	}
+	if r.needsAQI {
+		if air, err = GetAirPollution(current.Coord.Lat, current.Coord.Lon, apiKey); err != nil {
+			return false, fmt.Errorf("fetching air quality for %s: %w", loc, err)
+		}
+	}
+	now := time.Now()
+	env := newRuleEnv(loc, current, forecast, air, now)
+	if r.needsHistory {
+		env.Yesterday, err = summarizeDayAt(loc.String(), current.Coord.Lat, current.Coord.Lon, now.AddDate(0, 0, -1), apiKey)
+		if err != nil {
+			return false, err
+		}
+	}
+	result, err := expr.Run(r.program, env)
+	if err != nil {
+		return false, fmt.Errorf("evaluating %q for %s: %w", r.Source, loc, err)
+	}
+	return result.(bool), nil
+}
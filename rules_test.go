@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/expr-lang/expr"
+)
+
+func TestCompileRule(t *testing.T) {
+	now := time.Date(2026, time.March, 2, 6, 0, 0, 0, time.UTC)
+	env := ruleEnv{Location: "Area 12m", Condition: "Rain", Temp: 24, now: now}
+	for i, pop := range []float64{0.1, 0.6, 0.9, 0.2, 0.3} {
+		env.forecast = append(env.forecast, ForecastPoint{At: now.Add(time.Duration(3*i) * time.Hour), Pop: pop, Temp: float64(20 + i)})
+	}
+	tests := []struct {
+		condition string
+		want      bool
+	}{
+		{"max(pop, 0h, 6h) > 0.8", true},
+		{"max(pop, 9h, 12h) > 0.8", false},
+		{"min(temp, 90m, 4.5h) == 20", true},
+		{"min(temp, 3h, 6h) >= 21", true},
+		{"max(pop, 0, 3) > 0.5", true},
+		{`location == "Area 12m"`, true},
+		{`location == 'Area 12m'`, true},
+		{"location == `Area 12m`", true},
+		{`location contains "12h" || max(pop, 0h, 3h) > 0.5`, true},
+		{`condition == "Rain" && max(pop, 12h, 12h) > 0.5`, false},
+		{`location == "Area \"12m\""`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			rule, err := compileRule(tt.condition)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := expr.Run(rule.program, env)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("%s = %v, want %v", tt.condition, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileRuleErrors(t *testing.T) {
+	for _, condition := range []string{
+		"max(pop, 0h) > 0.5",
+		"max(condition, 0h, 6h) > 0.5",
+		`temp > "12h`,
+	} {
+		if _, err := compileRule(condition); err == nil {
+			t.Errorf("compileRule(%q) succeeded, want an error", condition)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Without --schema, --output json and yaml print OpenWeatherMap's own
+// response shape, which changes whenever the API or the tool's models do.
+// A schema version is the tool's own shape instead: once released it only
+// gains fields, and anything else gets a new version, so scripts pinned to
+// one keep working. Record every change in the README's schema changelog.
+
+// schemaV1 identifies version 1 documents.
+const schemaV1 = "weather-tool/v1"
+
+// outputSchema converts the API responses to one schema version.
+type outputSchema struct {
+	current  func(*CurrentWeatherResponse) any
+	forecast func(*ForecastResponse) any
+}
+
+var outputSchemas = map[string]outputSchema{
+	"v1": {
+		current:  func(data *CurrentWeatherResponse) any { return currentV1From(data) },
+		forecast: func(data *ForecastResponse) any { return forecastV1From(data) },
+	},
+}
+
+// outputSchemaNames lists the accepted --schema values for help text.
+func outputSchemaNames() string {
+	names := make([]string, 0, len(outputSchemas))
+	for name := range outputSchemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// schemaOutput returns the json or yaml output format printing documents
+// of schema version.
+func schemaOutput(format, version string) (outputFormat, error) {
+	schema, ok := outputSchemas[version]
+	if !ok {
+		return outputFormat{}, fmt.Errorf("unknown schema %q, use one of: %s", version, outputSchemaNames())
+	}
+	printDoc := printJSON[any]
+	switch format {
+	case "json":
+	case "yaml":
+		printDoc = printYAML[any]
+	default:
+		return outputFormat{}, fmt.Errorf("--schema needs --output json or yaml, not %s", format)
+	}
+	return outputFormat{
+		current:  func(data *CurrentWeatherResponse) { printDoc(schema.current(data)) },
+		forecast: func(data *ForecastResponse) { printDoc(schema.forecast(data)) },
+	}, nil
+}
+
+// locationV1 is the place a v1 document describes.
+type locationV1 struct {
+	Name    string  `json:"name"`
+	Country string  `json:"country"`
+	CityID  int     `json:"city_id,omitempty"`
+	Lat     float64 `json:"lat"`
+	Lon     float64 `json:"lon"`
+}
+
+// unitsV1 names the units of a v1 document's readings, following --units.
+type unitsV1 struct {
+	Temperature   string `json:"temperature"`
+	WindSpeed     string `json:"wind_speed"`
+	Pressure      string `json:"pressure"`
+	Precipitation string `json:"precipitation"`
+	Visibility    string `json:"visibility"`
+}
+
+// windV1 is a v1 wind reading; Direction is where it blows from, in
+// degrees.
+type windV1 struct {
+	Speed     float64 `json:"speed"`
+	Gust      float64 `json:"gust,omitempty"`
+	Direction int     `json:"direction"`
+}
+
+// currentV1 is version 1 of the current weather document.
+type currentV1 struct {
+	Schema        string     `json:"schema"`
+	Kind          string     `json:"kind"`
+	Location      locationV1 `json:"location"`
+	Units         unitsV1    `json:"units"`
+	ObservedAt    time.Time  `json:"observed_at"`
+	Condition     string     `json:"condition"`
+	Description   string     `json:"description"`
+	Temperature   float64    `json:"temperature"`
+	FeelsLike     float64    `json:"feels_like"`
+	TempMin       float64    `json:"temp_min"`
+	TempMax       float64    `json:"temp_max"`
+	Humidity      int        `json:"humidity"`
+	Pressure      int        `json:"pressure"`
+	Wind          windV1     `json:"wind"`
+	Clouds        int        `json:"clouds"`
+	Visibility    int        `json:"visibility"`
+	Precipitation float64    `json:"precipitation_1h"`
+	Sunrise       time.Time  `json:"sunrise"`
+	Sunset        time.Time  `json:"sunset"`
+}
+
+// forecastV1 is version 1 of the forecast document.
+type forecastV1 struct {
+	Schema   string            `json:"schema"`
+	Kind     string            `json:"kind"`
+	Location locationV1        `json:"location"`
+	Units    unitsV1           `json:"units"`
+	Entries  []forecastEntryV1 `json:"entries"`
+}
+
+// forecastEntryV1 is one 3-hour step of a v1 forecast. Precipitation is
+// rain and snow over the 3 hours.
+type forecastEntryV1 struct {
+	Time          time.Time `json:"time"`
+	Condition     string    `json:"condition"`
+	Description   string    `json:"description"`
+	Temperature   float64   `json:"temperature"`
+	FeelsLike     float64   `json:"feels_like"`
+	Humidity      int       `json:"humidity"`
+	Pressure      int       `json:"pressure"`
+	Wind          windV1    `json:"wind"`
+	Clouds        int       `json:"clouds"`
+	Pop           float64   `json:"pop"`
+	Precipitation float64   `json:"precipitation_3h"`
+}
+
+func unitsV1Now() unitsV1 {
+	return unitsV1{
+		Temperature:   displayUnits.temp.symbol,
+		WindSpeed:     displayUnits.speed.symbol,
+		Pressure:      "hPa",
+		Precipitation: "mm",
+		Visibility:    "m",
+	}
+}
+
+func windV1From(w Wind) windV1 {
+	return windV1{Speed: showSpeed(w.Speed), Gust: showSpeed(w.Gust), Direction: w.Deg}
+}
+
+func currentV1From(data *CurrentWeatherResponse) currentV1 {
+	doc := currentV1{
+		Schema: schemaV1,
+		Kind:   "current",
+		Location: locationV1{
+			Name: data.Name, Country: data.Sys.Country, CityID: data.ID,
+			Lat: data.Coord.Lat, Lon: data.Coord.Lon,
+		},
+		Units:         unitsV1Now(),
+		ObservedAt:    time.Unix(data.Dt, 0).UTC(),
+		Temperature:   showTemp(data.Main.Temp),
+		FeelsLike:     showTemp(data.Main.FeelsLike),
+		TempMin:       showTemp(data.Main.TempMin),
+		TempMax:       showTemp(data.Main.TempMax),
+		Humidity:      data.Main.Humidity,
+		Pressure:      data.Main.Pressure,
+		Wind:          windV1From(data.Wind),
+		Clouds:        data.Clouds.All,
+		Visibility:    data.Visibility,
+		Precipitation: data.Rain.OneHour,
+		Sunrise:       time.Unix(data.Sys.Sunrise, 0).UTC(),
+		Sunset:        time.Unix(data.Sys.Sunset, 0).UTC(),
+	}
+	if len(data.Weather) > 0 {
+		doc.Condition, doc.Description = data.Weather[0].Main, data.Weather[0].Description
+	}
+	return doc
+}
+
+func forecastV1From(data *ForecastResponse) forecastV1 {
+	doc := forecastV1{
+		Schema: schemaV1,
+		Kind:   "forecast",
+		Location: locationV1{
+			Name: data.City.Name, Country: data.City.Country, CityID: data.City.ID,
+			Lat: data.City.Coord.Lat, Lon: data.City.Coord.Lon,
+		},
+		Units:   unitsV1Now(),
+		Entries: make([]forecastEntryV1, len(data.List)),
+	}
+	for i, e := range data.List {
+		entry := forecastEntryV1{
+			Time:          time.Unix(e.Dt, 0).UTC(),
+			Temperature:   showTemp(e.Main.Temp),
+			FeelsLike:     showTemp(e.Main.FeelsLike),
+			Humidity:      e.Main.Humidity,
+			Pressure:      e.Main.Pressure,
+			Wind:          windV1From(e.Wind),
+			Clouds:        e.Clouds.All,
+			Pop:           e.Pop,
+			Precipitation: e.Rain.ThreeHours + e.Snow.ThreeHours,
+		}
+		if len(e.Weather) > 0 {
+			entry.Condition, entry.Description = e.Weather[0].Main, e.Weather[0].Description
+		}
+		doc.Entries[i] = entry
+	}
+	return doc
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	starjson "go.starlark.net/lib/json"
+	starmath "go.starlark.net/lib/math"
+	startime "go.starlark.net/lib/time"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+// viewScript is a Starlark file that renders weather data as text. It
+// defines current(data) and/or forecast(data), which receive the same
+// JSON shape as the OpenWeatherMap API decoded into dicts and lists and
+// return the text to print.
+type viewScript struct {
+	path    string
+	globals starlark.StringDict
+}
+
+// loadScript runs the script file once to collect its functions. The json,
+// math and time modules from the Starlark standard library are available.
+func loadScript(path string) (*viewScript, error) {
+	predeclared := starlark.StringDict{
+		"json": starjson.Module,
+		"math": starmath.Module,
+		"time": startime.Module,
+	}
+	thread := newScriptThread(path)
+	globals, err := starlark.ExecFileOptions(&syntax.FileOptions{}, thread, path, nil, predeclared)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load script: %w", scriptError(err))
+	}
+	return &viewScript{path: path, globals: globals}, nil
+}
+
+func newScriptThread(path string) *starlark.Thread {
+	return &starlark.Thread{
+		Name:  path,
+		Print: func(_ *starlark.Thread, msg string) { fmt.Fprintln(os.Stderr, msg) },
+	}
+}
+
+// render calls the script's fn function with data and returns its result
+// as text ending in a newline.
+func (s *viewScript) render(fn string, data any) (string, error) {
+	callable, ok := s.globals[fn].(starlark.Callable)
+	if !ok {
+		return "", fmt.Errorf("script %s does not define %s(data)", s.path, fn)
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode data for script: %w", err)
+	}
+	thread := newScriptThread(s.path)
+	value, err := starlark.Call(thread, starjson.Module.Members["decode"], starlark.Tuple{starlark.String(encoded)}, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode data for script: %w", err)
+	}
+	result, err := starlark.Call(thread, callable, starlark.Tuple{value}, nil)
+	if err != nil {
+		return "", fmt.Errorf("script %s: %w", s.path, scriptError(err))
+	}
+
+	text, ok := starlark.AsString(result)
+	if !ok {
+		text = result.String()
+	}
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	return text, nil
+}
+
+// scriptError includes the Starlark backtrace for runtime errors, which
+// points at the offending line of the script.
+func scriptError(err error) error {
+	if evalErr, ok := err.(*starlark.EvalError); ok {
+		return fmt.Errorf("%s", evalErr.Backtrace())
+	}
+	return err
+}
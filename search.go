@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// runSearch implements `weather search <query>`, listing places matching
+// the query so the user can pick the exact one to query or save.
+func runSearch(args []string) error {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	limit := fs.Int("limit", 5, "Maximum number of results (the geocoding API returns at most 5)")
+	state := fs.String("state", "", "US state code to narrow the search, e.g. OR")
+	country := fs.String("country", "", "Only list places in this country, e.g. KE")
+	fs.Parse(args)
+
+	query := qualifyCity(strings.Join(fs.Args(), " "), *state, *country)
+	if query == "" {
+		return fmt.Errorf("usage: weather search [--limit N] <place>")
+	}
+
+	results, err := Geocode(query, *limit, apiKeyFromEnv())
+	if err != nil {
+		return fmt.Errorf("searching for %s: %w", query, err)
+	}
+	results = filterByCountry(results, *country)
+	if len(results) == 0 {
+		fmt.Printf("No places found matching %q.\n", query)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tName\tState\tCountry\tLat\tLon")
+	for i, r := range results {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%.4f\t%.4f\n", i+1, r.Name, r.State, r.Country, r.Lat, r.Lon)
+	}
+	w.Flush()
+
+	fmt.Println()
+	fmt.Println(`Save one with: weather fav add <name> "Name,State,Country"`)
+	return nil
+}
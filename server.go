@@ -0,0 +1,318 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+//go:embed data/openapi.json
+var openAPIDocument []byte
+
+//go:embed data/docs.html
+var apiDocsPage []byte
+
+// ServerConfig is the "server" section of the config file.
+type ServerConfig struct {
+	// Tokens are the API keys accepted by the server. With none, the API
+	// is open to anyone who can reach it.
+	Tokens []ServerToken `json:"tokens,omitempty"`
+	// CORSOrigins are the browser origins allowed to call the API, e.g.
+	// "https://dash.example.com", or "*" for any.
+	CORSOrigins []string `json:"cors_origins,omitempty"`
+	// RateLimit is the number of requests per minute allowed for each
+	// client (API key, or IP address without keys) unless its key sets
+	// its own; zero means unlimited.
+	RateLimit int `json:"rate_limit,omitempty"`
+	// GlobalRateLimit caps requests per minute across all clients.
+	GlobalRateLimit int `json:"global_rate_limit,omitempty"`
+	// KeyPassthrough lets callers send their own OpenWeatherMap API key in
+	// the X-OpenWeather-Key header, used upstream instead of the server's.
+	KeyPassthrough bool `json:"key_passthrough,omitempty"`
+	// CacheTTL is how long upstream responses are served from memory,
+	// e.g. "10m"; "0s" disables the cache.
+	CacheTTL string `json:"cache_ttl,omitempty"`
+	// CacheStale is how much longer an expired response may still be
+	// served while it is refreshed in the background.
+	CacheStale string `json:"cache_stale,omitempty"`
+}
+
+// cacheDurations parses CacheTTL and CacheStale, applying the defaults.
+func (c ServerConfig) cacheDurations() (ttl, stale time.Duration, err error) {
+	ttl, stale = 10*time.Minute, time.Hour
+	if c.CacheTTL != "" {
+		if ttl, err = time.ParseDuration(c.CacheTTL); err != nil {
+			return 0, 0, fmt.Errorf("invalid server cache_ttl %q: %w", c.CacheTTL, err)
+		}
+	}
+	if c.CacheStale != "" {
+		if stale, err = time.ParseDuration(c.CacheStale); err != nil {
+			return 0, 0, fmt.Errorf("invalid server cache_stale %q: %w", c.CacheStale, err)
+		}
+	}
+	return ttl, stale, nil
+}
+
+// runServe implements `weather serve`, a small REST API over the weather
+// client for dashboards and scripts. SIGHUP rereads the config file.
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:8080", "Address to listen on")
+	fs.Parse(args)
+
+	handler := &reloadableServer{}
+	if err := handler.build(); err != nil {
+		return err
+	}
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	log.Printf("listening on http://%s (API docs at /docs)", *addr)
+	if len(config.Server.Tokens) == 0 {
+		log.Printf("no API keys configured; the API is open to anyone who can reach it")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+wait:
+	for {
+		select {
+		case err := <-errc:
+			return fmt.Errorf("server failed: %w", err)
+		case <-hup:
+			if err := handler.reload(); err != nil {
+				log.Printf("reload failed, keeping the current config: %v", err)
+				continue
+			}
+			log.Printf("config reloaded")
+		case <-ctx.Done():
+			break wait
+		}
+	}
+	log.Printf("shutting down, waiting for requests in flight")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
+
+// reloadableServer serves the API built from the current config and
+// rebuilds it on reload. Requests hold mu for reading, so a reload waits
+// for those in flight before it replaces the config they read.
+type reloadableServer struct {
+	mu      sync.RWMutex
+	handler http.Handler
+}
+
+func (s *reloadableServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.handler.ServeHTTP(w, r)
+}
+
+// build sets up the API from the config, which must hold the server's
+// API key unless callers can bring their own.
+func (s *reloadableServer) build() error {
+	apiKey, err := lookupAPIKey()
+	if err != nil && !config.Server.KeyPassthrough {
+		return err
+	}
+	handler, err := newServer(apiKey, config.Server)
+	if err != nil {
+		return err
+	}
+	s.handler = handler
+	return nil
+}
+
+// reload rereads the config file and rebuilds the API from it, with an
+// empty cache and fresh stats. On error the current config and API stay.
+func (s *reloadableServer) reload() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous, handler := config, s.handler
+	config = Config{}
+	err := loadConfig()
+	if err == nil {
+		err = s.build()
+	}
+	if err != nil {
+		config, s.handler = previous, handler
+	}
+	return err
+}
+
+// newServer returns the API's routes behind CORS and API key checks. The
+// API description stays public so clients can be generated without a key.
+func newServer(apiKey string, cfg ServerConfig) (http.Handler, error) {
+	ttl, stale, err := cfg.cacheDurations()
+	if err != nil {
+		return nil, err
+	}
+	s := &apiServer{apiKey: apiKey, passthrough: cfg.KeyPassthrough, cache: newResponseCache(ttl, stale), stats: newServerStats()}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/current", s.serveWeather("current"))
+	mux.HandleFunc("GET /v1/forecast", s.serveWeather("forecast"))
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openAPIDocument)
+	})
+	mux.HandleFunc("GET /docs", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(apiDocsPage)
+	})
+	s.handleAdmin(mux)
+	handleGrafana(mux)
+	limited := newRateLimits(cfg).middleware(mux)
+	return allowCORS(cfg.CORSOrigins, requireToken(cfg.Tokens, []string{"/openapi.json", "/docs"}, limited)), nil
+}
+
+// tenantKeyHeader carries a caller's own OpenWeatherMap API key.
+const tenantKeyHeader = "X-OpenWeather-Key"
+
+// apiServer holds the state shared by the API handlers.
+type apiServer struct {
+	apiKey      string
+	passthrough bool
+	cache       *responseCache
+	stats       *serverStats
+}
+
+// upstreamKey returns the API key to fetch with for r, and the tenant it
+// belongs to for cache isolation: empty for the server's own key, else a
+// hash of the caller's key.
+func (s *apiServer) upstreamKey(r *http.Request) (key, tenant string) {
+	if s.passthrough {
+		if key := r.Header.Get(tenantKeyHeader); key != "" {
+			sum := sha256.Sum256([]byte(key))
+			return key, hex.EncodeToString(sum[:8])
+		}
+	}
+	return s.apiKey, ""
+}
+
+// serveWeather returns a handler that resolves the request's location and
+// writes its kind ("current" or "forecast") data as JSON, from the cache
+// when possible.
+func (s *apiServer) serveWeather(kind string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		loc, err := locationFromQuery(r.URL.Query())
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err)
+			return
+		}
+		apiKey, tenant := s.upstreamKey(r)
+		if apiKey == "" {
+			writeJSONError(w, http.StatusUnauthorized, fmt.Errorf("send your OpenWeatherMap API key in the %s header", tenantKeyHeader))
+			return
+		}
+		s.stats.requested(loc)
+		data, result, err := s.cache.get(cacheKey{kind: kind, loc: loc, tenant: tenant}, func() (data any, err error) {
+			start := time.Now()
+			defer func() { s.stats.fetched(selectedProvider(), time.Since(start), err) }()
+			if kind == "forecast" {
+				return GetForecastAt(loc, apiKey)
+			}
+			return GetCurrentWeatherAt(loc, apiKey)
+		})
+		if err != nil {
+			// Upstream errors can include the request URL and with it the
+			// API key, so clients only get a summary.
+			var apiErr *APIError
+			var rateErr *RateLimitError
+			switch {
+			case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound:
+				writeJSONError(w, http.StatusNotFound, fmt.Errorf("location %s not found", loc))
+				return
+			case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized && tenant != "":
+				writeJSONError(w, http.StatusUnauthorized, fmt.Errorf("OpenWeatherMap rejected the API key in %s", tenantKeyHeader))
+				return
+			case errors.As(err, &rateErr):
+				if rateErr.RetryAfter > 0 {
+					w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(rateErr.RetryAfter.Seconds()))))
+				}
+				writeJSONError(w, http.StatusServiceUnavailable, fmt.Errorf("OpenWeatherMap's rate limit was reached, try again later"))
+				return
+			}
+			log.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+			writeJSONError(w, http.StatusBadGateway, fmt.Errorf("fetching weather for %s failed", loc))
+			return
+		}
+		w.Header().Set("X-Cache", result)
+		writeJSON(w, http.StatusOK, data)
+	}
+}
+
+// locationFromQuery reads exactly one of city, zip, id or lat+lon from the
+// query string. Cities can't be @favorites, whose coordinates are the
+// operator's own and would come back in the response.
+func locationFromQuery(q url.Values) (Location, error) {
+	given := countSet(q.Has("city"), q.Has("zip"), q.Has("id"), q.Has("lat") || q.Has("lon"))
+	if given != 1 {
+		return Location{}, fmt.Errorf("give exactly one of city, zip, id or lat and lon")
+	}
+	switch {
+	case q.Has("city"):
+		city := q.Get("city")
+		if strings.HasPrefix(city, "@") {
+			return Location{}, fmt.Errorf("invalid city %q, favorites can't be used through the API", city)
+		}
+		return Location{Name: city}, nil
+	case q.Has("zip"):
+		return Location{Zip: q.Get("zip")}, nil
+	case q.Has("id"):
+		id, err := strconv.Atoi(q.Get("id"))
+		if err != nil || id <= 0 {
+			return Location{}, fmt.Errorf("invalid id %q", q.Get("id"))
+		}
+		return Location{ID: id}, nil
+	}
+	lat, err := strconv.ParseFloat(q.Get("lat"), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return Location{}, fmt.Errorf("invalid lat %q, must be between -90 and 90", q.Get("lat"))
+	}
+	lon, err := strconv.ParseFloat(q.Get("lon"), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return Location{}, fmt.Errorf("invalid lon %q, must be between -180 and 180", q.Get("lon"))
+	}
+	return Location{Lat: lat, Lon: lon, HasCoords: true}, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// apiError is the body of every error response.
+type apiError struct {
+	Error string `json:"error"`
+}
+
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, apiError{Error: err.Error()})
+}
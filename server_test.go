@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocationFromQuery(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    Location
+		wantErr bool
+	}{
+		{"city=Nairobi,KE", Location{Name: "Nairobi,KE"}, false},
+		{"zip=10001,US", Location{Zip: "10001,US"}, false},
+		{"id=184745", Location{ID: 184745}, false},
+		{"lat=-1.29&lon=36.82", Location{Lat: -1.29, Lon: 36.82, HasCoords: true}, false},
+		{"city=@home", Location{}, true},
+		{"city=Nairobi&id=184745", Location{}, true},
+		{"", Location{}, true},
+		{"id=-1", Location{}, true},
+		{"lat=91&lon=0", Location{}, true},
+		{"lat=0", Location{}, true},
+	}
+	for _, tt := range tests {
+		q, err := url.ParseQuery(tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := locationFromQuery(q)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("locationFromQuery(%q) error = %v, want error %v", tt.query, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("locationFromQuery(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestReloadableServer(t *testing.T) {
+	t.Setenv("OPENWEATHER_API_KEY", "")
+	saved := configFile
+	configFile = filepath.Join(t.TempDir(), "config.json")
+	defer func() { configFile = saved }()
+
+	withConfig(t, Config{APIKey: "old"}, func() {
+		s := &reloadableServer{}
+		if err := s.build(); err != nil {
+			t.Fatal(err)
+		}
+		tests := []struct {
+			name    string
+			file    string
+			wantErr bool
+			wantKey string
+		}{
+			{"new key", `{"api_key": "new"}`, false, "new"},
+			{"no key", `{"default_city": "Nairobi"}`, true, "new"},
+			{"bad json", `{"api_key":`, true, "new"},
+			{"key pass-through", `{"server": {"key_passthrough": true}}`, false, ""},
+		}
+		for _, tt := range tests {
+			if err := os.WriteFile(configFile, []byte(tt.file), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			err := s.reload()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("%s: reload error = %v, want error %v", tt.name, err, tt.wantErr)
+			}
+			if config.APIKey != tt.wantKey {
+				t.Errorf("%s: api key %q after reload, want %q", tt.name, config.APIKey, tt.wantKey)
+			}
+		}
+	})
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/template"
+)
+
+var systemdUnit = template.Must(template.New("unit").Parse(`[Unit]
+Description=weather-tool daemon{{if .Profile}} ({{.Profile}}){{end}}
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+ExecStart={{.ExecStart}}
+WorkingDirectory={{.WorkingDir}}
+Restart=on-failure
+RestartSec=30
+
+[Install]
+WantedBy=default.target
+`))
+
+var launchdPlist = template.Must(template.New("plist").Funcs(template.FuncMap{"xml": xmlEscape}).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>com.github.mugambi645.{{xml .Label}}</string>
+	<key>ProgramArguments</key>
+	<array>
+{{- range .Args}}
+		<string>{{xml .}}</string>
+{{- end}}
+	</array>
+	<key>WorkingDirectory</key>
+	<string>{{xml .WorkingDir}}</string>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<true/>
+	<key>StandardErrorPath</key>
+	<string>{{xml .LogFile}}</string>
+</dict>
+</plist>
+`))
+
+// serviceSpec describes how the service manager should start the daemon.
+type serviceSpec struct {
+	Label      string
+	Profile    string
+	Args       []string
+	ExecStart  string
+	WorkingDir string
+	LogFile    string
+}
+
+// runDaemonInstall implements `weather daemon install`, writing a systemd
+// user unit (or a launchd agent on macOS) that starts the daemon at login
+// with the current binary, config file and profile.
+func runDaemonInstall(args []string) error {
+	fs := flag.NewFlagSet("daemon install", flag.ExitOnError)
+	printOnly := fs.Bool("print", false, "Print the service file instead of writing it")
+	fs.Parse(args)
+
+	spec, err := daemonServiceSpec()
+	if err != nil {
+		return err
+	}
+
+	var path string
+	var tmpl *template.Template
+	var next string
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		path, err = systemdUnitPath(spec.Label)
+		tmpl = systemdUnit
+		next = "systemctl --user daemon-reload && systemctl --user enable --now " + filepath.Base(path)
+	case "darwin":
+		path, err = launchdPlistPath(spec.Label)
+		tmpl = launchdPlist
+		next = "launchctl load -w " + path
+	default:
+		return fmt.Errorf("daemon install is not supported on %s; run `weather daemon` from your service manager instead", runtime.GOOS)
+	}
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, spec); err != nil {
+		return fmt.Errorf("failed to render service file: %w", err)
+	}
+	if *printOnly {
+		fmt.Print(buf.String())
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write service file: %w", err)
+	}
+	fmt.Printf("Wrote %s\nStart it with: %s\n", path, next)
+	return nil
+}
+
+// daemonServiceSpec captures the running binary, config file, profile and
+// working directory (for .env) so the service runs the daemon the same way.
+func daemonServiceSpec() (serviceSpec, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return serviceSpec{}, fmt.Errorf("failed to locate the weather-tool binary: %w", err)
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return serviceSpec{}, fmt.Errorf("failed to locate the weather-tool binary: %w", err)
+	}
+	if strings.Contains(exe, "go-build") {
+		return serviceSpec{}, fmt.Errorf("%s is a temporary `go run` binary; build or install weather-tool first", exe)
+	}
+	cfg, err := configPath()
+	if err != nil {
+		return serviceSpec{}, err
+	}
+	if cfg, err = filepath.Abs(cfg); err != nil {
+		return serviceSpec{}, err
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return serviceSpec{}, fmt.Errorf("failed to get working directory: %w", err)
+	}
+	logs, err := logDir()
+	if err != nil {
+		return serviceSpec{}, err
+	}
+
+	spec := serviceSpec{
+		Label:      appName,
+		Profile:    profile,
+		Args:       []string{exe, "--config", cfg},
+		WorkingDir: wd,
+		LogFile:    filepath.Join(logs, "daemon.log"),
+	}
+	if profile != "" {
+		spec.Label += "-" + profile
+		spec.Args = append(spec.Args, "--profile", profile)
+	}
+	spec.Args = append(spec.Args, "daemon")
+
+	quoted := make([]string, len(spec.Args))
+	for i, arg := range spec.Args {
+		quoted[i] = systemdQuote(arg)
+	}
+	spec.ExecStart = strings.Join(quoted, " ")
+	return spec, nil
+}
+
+func systemdUnitPath(label string) (string, error) {
+	base := os.Getenv("XDG_CONFIG_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to find home directory: %w", err)
+		}
+		base = filepath.Join(home, ".config")
+	}
+	return filepath.Join(base, "systemd", "user", label+".service"), nil
+}
+
+func launchdPlistPath(label string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(home, "Library", "LaunchAgents", "com.github.mugambi645."+label+".plist"), nil
+}
+
+// systemdQuote quotes an ExecStart argument if it needs it. Percent signs
+// are doubled so systemd does not treat them as specifiers.
+func systemdQuote(arg string) string {
+	arg = strings.ReplaceAll(arg, "%", "%%")
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\;$") {
+		return arg
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", "$$")
+	return `"` + r.Replace(arg) + `"`
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
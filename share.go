@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mdp/qrterminal/v3"
+)
+
+const owmCityPageURL = "https://openweathermap.org/city/%d"
+
+// runShare implements `weather share <city>`, printing the city's canonical
+// OpenWeatherMap page and a QR code for it so it can be opened on a phone.
+func runShare(args []string) error {
+	fs := flag.NewFlagSet("share", flag.ExitOnError)
+	noQR := fs.Bool("no-qr", false, "Print only the link, without a QR code")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: weather share [--no-qr] <city>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	city := strings.Join(fs.Args(), " ")
+	if city == "" {
+		fs.Usage()
+		return fmt.Errorf("please provide a city name")
+	}
+
+	data, err := GetCurrentWeather(city, apiKeyFromEnv())
+	if err != nil {
+		return fmt.Errorf("fetching current weather for %s: %w", city, err)
+	}
+
+	link := fmt.Sprintf(owmCityPageURL, data.ID)
+	msg := currentWeatherMessage(data)
+	fmt.Println(msg.Title + ": " + msg.Body())
+	fmt.Println(link)
+	if !*noQR {
+		fmt.Println()
+		qrterminal.GenerateHalfBlock(link, qrterminal.L, os.Stdout)
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/wcharczuk/go-chart/v2"
+	"golang.org/x/text/unicode/norm"
+)
+
+//go:embed templates/site
+var siteTemplates embed.FS
+
+// sitePage holds everything needed to render one city's page.
+type sitePage struct {
+	Slug     string
+	Current  *CurrentWeatherResponse
+	Days     []siteDay
+	Forecast []ForecastPoint // for the chart
+}
+
+// siteDay is one calendar day of forecast entries on a city page.
+type siteDay struct {
+	Label   string
+	Entries []ForecastListEntry
+}
+
+// siteData is the template context shared by every generated page.
+type siteData struct {
+	Pages          []sitePage
+	Page           *sitePage
+	GeneratedAt    time.Time
+	RefreshSeconds int
+}
+
+var siteFuncs = template.FuncMap{
+	"clock": func(unix int64) string { return time.Unix(unix, 0).Local().Format("15:04") },
+	// temp and speed show readings in the --units display units, temp
+	// with digits decimals unless --precision says otherwise.
+	"temp": func(celsius float64, digits int) string {
+		return lsprintf("%.*f%s", decimals("temp", digits), showTemp(celsius), displayUnits.temp.symbol)
+	},
+	"speed": func(ms float64) string {
+		return lsprintf("%.*f %s", decimals("wind", 1), showSpeed(ms), displayUnits.speed.symbol)
+	},
+	"percent": func(p float64) string {
+		return fmt.Sprintf("%.0f%%", p*100)
+	},
+	"condition": func(w []Weather) string {
+		if len(w) == 0 {
+			return "N/A"
+		}
+		return fmt.Sprintf("%s (%s)", w[0].Main, w[0].Description)
+	},
+}
+
+// runSite implements `weather site`, rendering a small static site with an
+// index and one page per city, suitable for regenerating from cron.
+func runSite(args []string) error {
+	fs := flag.NewFlagSet("site", flag.ExitOnError)
+	outDir := fs.String("out", "./public", "Directory to write the generated site into")
+	refresh := fs.Duration("refresh", 30*time.Minute, "Auto-refresh interval written into each page")
+	var cities stringList
+	fs.Var(&cities, "city", "City or @favorite to include (repeat for multiple cities)")
+	fs.Parse(args)
+
+	if len(cities) == 0 {
+		return fmt.Errorf("please provide at least one city using the --city flag")
+	}
+	var locations []Location
+	for _, city := range cities {
+		loc, err := resolveLocation(city)
+		if err != nil {
+			return err
+		}
+		locations = append(locations, loc)
+	}
+
+	data, fetchErr := buildSiteData(locations, *refresh, apiKeyFromEnv())
+	if len(data.Pages) == 0 {
+		return fetchErr
+	}
+	if err := writeSite(*outDir, data); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote site for %d cities to %s\n", len(data.Pages), *outDir)
+	return fetchErr
+}
+
+// buildSiteData fetches current weather and forecasts for every location.
+// Locations that can't be fetched are left out of the site and returned
+// as the error, alongside the data for the rest.
+func buildSiteData(locations []Location, refresh time.Duration, apiKey string) (siteData, error) {
+	data := siteData{GeneratedAt: time.Now(), RefreshSeconds: int(refresh.Seconds())}
+	var errs []error
+	slugs := make(map[string]bool)
+	for _, loc := range locations {
+		current, err := GetCurrentWeatherAt(loc, apiKey)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("fetching current weather for %s: %w", loc, err))
+			continue
+		}
+		forecast, err := GetForecastAt(loc, apiKey)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("fetching forecast for %s: %w", loc, err))
+			continue
+		}
+		page := sitePage{Slug: uniqueSlug(slugify(loc.String()), slugs), Current: current, Forecast: forecastPointsFrom(forecast)}
+		dates, byDay := groupForecastByDay(forecast)
+		for _, date := range dates {
+			page.Days = append(page.Days, siteDay{Label: date, Entries: byDay[date]})
+		}
+		data.Pages = append(data.Pages, page)
+	}
+	return data, errors.Join(errs...)
+}
+
+// writeSite renders the index, per-city pages with their forecast charts
+// and stylesheet into outDir.
+func writeSite(outDir string, data siteData) error {
+	tmpl, err := template.New("site").Funcs(siteFuncs).ParseFS(siteTemplates, "templates/site/*.html")
+	if err != nil {
+		return fmt.Errorf("failed to parse site templates: %w", err)
+	}
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	if err := renderSitePage(tmpl, "index.html", filepath.Join(outDir, "index.html"), data); err != nil {
+		return err
+	}
+	for i := range data.Pages {
+		pageData := data
+		pageData.Page = &data.Pages[i]
+		path := filepath.Join(outDir, data.Pages[i].Slug+".html")
+		if err := renderSitePage(tmpl, "city.html", path, pageData); err != nil {
+			return err
+		}
+		if err := writeSiteChart(outDir, data.Pages[i]); err != nil {
+			return err
+		}
+	}
+
+	css, err := siteTemplates.ReadFile("templates/site/style.css")
+	if err != nil {
+		return fmt.Errorf("failed to read stylesheet: %w", err)
+	}
+	return os.WriteFile(filepath.Join(outDir, "style.css"), css, 0o644)
+}
+
+func renderSitePage(tmpl *template.Template, name, path string, data siteData) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+	if err := tmpl.ExecuteTemplate(f, name, data); err != nil {
+		return fmt.Errorf("failed to render %s: %w", path, err)
+	}
+	return nil
+}
+
+// writeSiteChart renders a city page's forecast chart as <slug>-forecast.svg.
+func writeSiteChart(outDir string, page sitePage) error {
+	path := filepath.Join(outDir, page.Slug+"-forecast.svg")
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+	return renderForecastChart(f, "", page.Forecast, chart.SVG)
+}
+
+// siteReservedSlugs are the site's own pages, which a city page mustn't
+// overwrite.
+var siteReservedSlugs = map[string]bool{"index": true}
+
+// slugify turns a city name into a safe file name, e.g. "New York" ->
+// "new-york" and "Zürich" -> "zurich". Names with no Latin letters or
+// digits, such as "東京", get "city-" and a hash of the name instead.
+func slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	// Decomposing accented letters leaves the base letter, followed by
+	// combining marks that are dropped like other punctuation.
+	for _, r := range norm.NFD.String(strings.ToLower(name)) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+			dash = false
+		case !dash && b.Len() > 0 && !unicode.Is(unicode.Mn, r):
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	if slug := strings.TrimSuffix(b.String(), "-"); slug != "" {
+		return slug
+	}
+	sum := sha256.Sum256([]byte(name))
+	return "city-" + hex.EncodeToString(sum[:4])
+}
+
+// uniqueSlug returns slug, or slug with a number appended if taken already
+// has it or it's reserved, and adds it to taken.
+func uniqueSlug(slug string, taken map[string]bool) string {
+	unique := slug
+	for n := 2; taken[unique] || siteReservedSlugs[unique]; n++ {
+		unique = fmt.Sprintf("%s-%d", slug, n)
+	}
+	taken[unique] = true
+	return unique
+}
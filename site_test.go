@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"New York", "new-york"},
+		{"Portland,OR,US", "portland-or-us"},
+		{"Zürich", "zurich"},
+		{"São Paulo", "sao-paulo"},
+		{"  St. John's  ", "st-john-s"},
+		{"Nairobi,KE", "nairobi-ke"},
+	}
+	for _, tt := range tests {
+		if got := slugify(tt.name); got != tt.want {
+			t.Errorf("slugify(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	tokyo, moscow := slugify("東京"), slugify("Москва")
+	if !strings.HasPrefix(tokyo, "city-") || !strings.HasPrefix(moscow, "city-") || tokyo == moscow {
+		t.Errorf("slugify of non-Latin names = %q and %q, want distinct city- slugs", tokyo, moscow)
+	}
+	if again := slugify("東京"); again != tokyo {
+		t.Errorf("slugify(東京) = %q, then %q; want the same slug every time", tokyo, again)
+	}
+}
+
+func TestUniqueSlug(t *testing.T) {
+	taken := make(map[string]bool)
+	var got []string
+	for _, name := range []string{"New York", "new york", "New-York!", "Index", "Nairobi"} {
+		got = append(got, uniqueSlug(slugify(name), taken))
+	}
+	want := []string{"new-york", "new-york-2", "new-york-3", "index-2", "nairobi"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("slugs = %q, want %q", got, want)
+			break
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const solarURL = "https://api.openweathermap.org/energy/1.0/solar/data"
+
+// performanceRatio is the share of the panel's rated output that reaches
+// the meter after inverter, temperature and wiring losses.
+const performanceRatio = 0.75
+
+// Irradiance is global horizontal (GHI), direct normal (DNI) and diffuse
+// horizontal (DHI) irradiance in W/m², or Wh/m² for daily totals.
+type Irradiance struct {
+	GHI float64 `json:"ghi"`
+	DNI float64 `json:"dni"`
+	DHI float64 `json:"dhi"`
+}
+
+// SolarResponse is the solar irradiance API response for one day: hourly
+// and daily irradiance under a clear sky and under the forecast clouds.
+type SolarResponse struct {
+	Lat        float64 `json:"lat"`
+	Lon        float64 `json:"lon"`
+	Date       string  `json:"date"`
+	Tz         string  `json:"tz"`
+	Sunrise    string  `json:"sunrise"`
+	Sunset     string  `json:"sunset"`
+	Irradiance struct {
+		Daily []struct {
+			ClearSky  Irradiance `json:"clear_sky"`
+			CloudySky Irradiance `json:"cloudy_sky"`
+		} `json:"daily"`
+		Hourly []struct {
+			Hour      int        `json:"hour"`
+			ClearSky  Irradiance `json:"clear_sky"`
+			CloudySky Irradiance `json:"cloudy_sky"`
+		} `json:"hourly"`
+	} `json:"irradiance"`
+}
+
+// GetSolarRadiation fetches hourly solar irradiance at coordinates for a
+// day (YYYY-MM-DD).
+func GetSolarRadiation(lat, lon float64, date string, apiKey string) (*SolarResponse, error) {
+	params := url.Values{
+		"lat":      {strconv.FormatFloat(lat, 'f', -1, 64)},
+		"lon":      {strconv.FormatFloat(lon, 'f', -1, 64)},
+		"date":     {date},
+		"interval": {"1h"},
+		"appid":    {apiKey},
+	}
+	var data SolarResponse
+	if err := fetchWeatherData(solarURL+"?"+params.Encode(), &data); err != nil {
+		return nil, planError(err, "solar irradiance data")
+	}
+	return &data, nil
+}
+
+// estimatePV estimates a day's generation in kWh of a system rated kwp
+// from the day's horizontal irradiance in Wh/m². Rated output is measured
+// at 1000 W/m², so each kWh/m² yields about kwp kWh before losses.
+func estimatePV(ghi, kwp float64) float64 {
+	return ghi / 1000 * kwp * performanceRatio
+}
+
+// runSolar implements `weather solar`.
+func runSolar(args []string) error {
+	fs := flag.NewFlagSet("solar", flag.ExitOnError)
+	locFlags := addLocationFlags(fs)
+	date := fs.String("date", "", "Day to show, YYYY-MM-DD (default today)")
+	kwp := fs.Float64("kwp", 0, "Estimate the day's generation of a PV system of this rated size in kWp")
+	asJSON := fs.Bool("json", false, "Print the raw irradiance data as JSON")
+	fs.Parse(args)
+
+	loc, hasFlagLoc, err := locFlags.location()
+	switch {
+	case err != nil:
+	case hasFlagLoc:
+	case fs.NArg() > 0:
+		loc, err = locFlags.resolveCity(strings.Join(fs.Args(), " "))
+	default:
+		return fmt.Errorf("usage: weather solar [--date YYYY-MM-DD] [--kwp N] [--json] <city|@favorite>")
+	}
+	if err != nil {
+		return err
+	}
+	usePreferences(loc)
+	day := time.Now().Format(time.DateOnly)
+	if *date != "" {
+		if _, err := time.Parse(time.DateOnly, *date); err != nil {
+			return fmt.Errorf("invalid --date %q, use YYYY-MM-DD", *date)
+		}
+		day = *date
+	}
+	if *kwp < 0 {
+		return fmt.Errorf("--kwp must not be negative")
+	}
+
+	apiKey := apiKeyFromEnv()
+	lat, lon, err := loc.coordinates(apiKey)
+	if err != nil {
+		return err
+	}
+	data, err := GetSolarRadiation(lat, lon, day, apiKey)
+	if err != nil {
+		return fmt.Errorf("fetching solar irradiance for %s: %w", loc, err)
+	}
+	if *asJSON {
+		out, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode solar data: %w", err)
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	fmt.Printf("Solar irradiance for %s on %s (sunrise %s, sunset %s, UTC%s)\n\n", loc, data.Date, data.Sunrise, data.Sunset, data.Tz)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "HOUR\tGHI\tDNI\tDHI\tCLEAR-SKY GHI\t")
+	for _, h := range data.Irradiance.Hourly {
+		if h.ClearSky.GHI == 0 {
+			continue // night
+		}
+		fmt.Fprintf(tw, "%02d:00\t%.0f\t%.0f\t%.0f\t%.0f\t\n", h.Hour, h.CloudySky.GHI, h.CloudySky.DNI, h.CloudySky.DHI, h.ClearSky.GHI)
+	}
+	tw.Flush()
+	fmt.Println("W/m², under the forecast clouds unless marked clear-sky")
+
+	if len(data.Irradiance.Daily) > 0 {
+		daily := data.Irradiance.Daily[0]
+		fmt.Printf("\nDaily total: %.2f kWh/m² GHI (%.2f under a clear sky)\n", daily.CloudySky.GHI/1000, daily.ClearSky.GHI/1000)
+		if *kwp > 0 {
+			fmt.Printf("Estimated generation of a %g kWp system: %.1f kWh\n", *kwp, estimatePV(daily.CloudySky.GHI, *kwp))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// rainPopThreshold is the probability of precipitation from which a
+// forecast entry counts as "rain expected".
+const rainPopThreshold = 0.5
+
+// runSpeak implements `weather speak`, reading a short natural-language
+// summary aloud through the system's text-to-speech engine.
+func runSpeak(args []string) error {
+	fs := flag.NewFlagSet("speak", flag.ExitOnError)
+	city := fs.String("city", "", "City to summarize")
+	printOnly := fs.Bool("print", false, "Print the summary instead of speaking it")
+	fs.Parse(args)
+
+	if *city == "" {
+		return fmt.Errorf("please provide a city name using the --city flag")
+	}
+	apiKey := apiKeyFromEnv()
+
+	current, err := GetCurrentWeather(*city, apiKey)
+	if err != nil {
+		return fmt.Errorf("fetching current weather for %s: %w", *city, err)
+	}
+	forecast, err := GetForecast(*city, apiKey)
+	if err != nil {
+		return fmt.Errorf("fetching forecast for %s: %w", *city, err)
+	}
+
+	summary := spokenSummary(current, forecast, time.Now())
+	if *printOnly {
+		fmt.Println(summary)
+		return nil
+	}
+	return speak(summary)
+}
+
+// spokenSummary renders current conditions and upcoming rain as a couple of
+// plain sentences, e.g. "Currently 24 degrees and partly cloudy in Nairobi.
+// Rain expected after 4 pm."
+func spokenSummary(current *CurrentWeatherResponse, forecast *ForecastResponse, now time.Time) string {
+	condition := "clear"
+	if len(current.Weather) > 0 {
+		condition = current.Weather[0].Description
+	}
+	var b strings.Builder
+	b.WriteString(lsprintf("Currently %.0f %s and %s in %s.", showTemp(current.Main.Temp), displayUnits.tempWord, condition, current.Name))
+	if diff := current.Main.FeelsLike - current.Main.Temp; diff >= 3 || diff <= -3 {
+		b.WriteString(lsprintf(" It feels like %.0f.", showTemp(current.Main.FeelsLike)))
+	}
+
+	rain := false
+	for _, entry := range forecast.List {
+		at := time.Unix(entry.Dt, 0).Local()
+		if at.Sub(now) > 24*time.Hour {
+			break
+		}
+		if entry.Pop >= rainPopThreshold {
+			fmt.Fprintf(&b, " Rain expected %s.", spokenTime(at, now))
+			rain = true
+			break
+		}
+	}
+	if !rain {
+		b.WriteString(" No rain expected in the next 24 hours.")
+	}
+	return b.String()
+}
+
+// spokenTime phrases a forecast time relative to now: "after 4 pm" or
+// "tomorrow after 9 am".
+func spokenTime(at, now time.Time) string {
+	hour := strings.ToLower(at.Format("3 PM"))
+	if at.YearDay() != now.YearDay() {
+		return "tomorrow after " + hour
+	}
+	return "after " + hour
+}
+
+// speak sends text to the platform's text-to-speech engine.
+func speak(text string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("say", "-f", "-")
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-Command",
+			"Add-Type -AssemblyName System.Speech; (New-Object System.Speech.Synthesis.SpeechSynthesizer).Speak([Console]::In.ReadToEnd())")
+	default:
+		engine, err := findExecutable("espeak-ng", "espeak")
+		if err != nil {
+			return fmt.Errorf("no text-to-speech engine found; install espeak-ng or espeak")
+		}
+		cmd = exec.Command(engine, "--stdin")
+	}
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("text-to-speech failed: %w", err)
+	}
+	return nil
+}
+
+// findExecutable returns the path of the first of names found on PATH.
+func findExecutable(names ...string) (string, error) {
+	for _, name := range names {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("none of %s found in PATH", strings.Join(names, ", "))
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	stationsURL     = "https://api.openweathermap.org/data/3.0/stations"
+	measurementsURL = "https://api.openweathermap.org/data/3.0/measurements"
+)
+
+// Station is a personal weather station registered with OpenWeatherMap.
+type Station struct {
+	ID         string  `json:"id"`
+	ExternalID string  `json:"external_id"`
+	Name       string  `json:"name"`
+	Latitude   float64 `json:"latitude"`
+	Longitude  float64 `json:"longitude"`
+	Altitude   float64 `json:"altitude"`
+	Rank       int     `json:"rank"`
+	CreatedAt  string  `json:"created_at"`
+	UpdatedAt  string  `json:"updated_at"`
+}
+
+// measurementStat is one aggregated value of a station measurement.
+type measurementStat struct {
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+	Average float64 `json:"average"`
+	Weight  int     `json:"weight"`
+}
+
+// StationMeasurement is a station's readings aggregated over a minute,
+// hour or day, as returned by the measurements API.
+type StationMeasurement struct {
+	Type      string          `json:"type"`
+	Date      int64           `json:"date"`
+	StationID string          `json:"station_id"`
+	Temp      measurementStat `json:"temp"`
+	Humidity  measurementStat `json:"humidity"`
+	Pressure  measurementStat `json:"pressure"`
+	Wind      struct {
+		Deg   measurementStat `json:"deg"`
+		Speed measurementStat `json:"speed"`
+	} `json:"wind"`
+	Precipitation struct {
+		Rain float64 `json:"rain"`
+	} `json:"precipitation"`
+}
+
+// measurementTypes maps --type values to the API's aggregation codes.
+var measurementTypes = map[string]string{"minute": "m", "hour": "h", "day": "d"}
+
+// GetStations lists the stations registered with apiKey.
+func GetStations(apiKey string) ([]Station, error) {
+	var stations []Station
+	if err := fetchWeatherData(stationsURL+"?"+url.Values{"appid": {apiKey}}.Encode(), &stations); err != nil {
+		return nil, err
+	}
+	return stations, nil
+}
+
+// GetStationMeasurements fetches a station's measurements between from and
+// to, aggregated per aggregation ("m", "h" or "d").
+func GetStationMeasurements(stationID, aggregation string, from, to time.Time, limit int, apiKey string) ([]StationMeasurement, error) {
+	params := url.Values{
+		"station_id": {stationID},
+		"type":       {aggregation},
+		"limit":      {strconv.Itoa(limit)},
+		"from":       {strconv.FormatInt(from.Unix(), 10)},
+		"to":         {strconv.FormatInt(to.Unix(), 10)},
+		"appid":      {apiKey},
+	}
+	var measurements []StationMeasurement
+	if err := fetchWeatherData(measurementsURL+"?"+params.Encode(), &measurements); err != nil {
+		return nil, err
+	}
+	return measurements, nil
+}
+
+// runStation implements `weather station list|get|push`.
+func runStation(args []string) error {
+	usage := fmt.Errorf("usage: weather station list | get [--from TIME] [--to TIME] [--type minute|hour|day] [--limit N] <station-id> | push [flags] <station-id>")
+	if len(args) == 0 {
+		return usage
+	}
+	switch args[0] {
+	case "list":
+		if len(args) != 1 {
+			return usage
+		}
+		stations, err := GetStations(apiKeyFromEnv())
+		if err != nil {
+			return fmt.Errorf("listing stations: %w", err)
+		}
+		if len(stations) == 0 {
+			fmt.Println("No stations registered with this API key.")
+			return nil
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tEXTERNAL ID\tNAME\tLOCATION")
+		for _, s := range stations {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%.4f, %.4f (%.0f m)\n", s.ID, s.ExternalID, s.Name, s.Latitude, s.Longitude, s.Altitude)
+		}
+		return tw.Flush()
+
+	case "get":
+		return runStationGet(args[1:])
+	case "push":
+		return runStationPush(args[1:])
+	}
+	return usage
+}
+
+func runStationGet(args []string) error {
+	fs := flag.NewFlagSet("station get", flag.ExitOnError)
+	from := fs.String("from", "", "Start of the period, YYYY-MM-DD or RFC 3339 (default 24 hours ago)")
+	to := fs.String("to", "", "End of the period, YYYY-MM-DD or RFC 3339 (default now)")
+	aggregation := fs.String("type", "hour", "Aggregate readings per minute, hour or day")
+	limit := fs.Int("limit", 100, "Maximum number of measurements")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: weather station get [--from TIME] [--to TIME] [--type minute|hour|day] [--limit N] <station-id>")
+	}
+	code, ok := measurementTypes[*aggregation]
+	if !ok {
+		return fmt.Errorf("invalid --type %q, use minute, hour or day", *aggregation)
+	}
+	end, err := parseStationTime(*to, time.Now())
+	if err != nil {
+		return err
+	}
+	start, err := parseStationTime(*from, end.Add(-24*time.Hour))
+	if err != nil {
+		return err
+	}
+
+	measurements, err := GetStationMeasurements(fs.Arg(0), code, start, end, *limit, apiKeyFromEnv())
+	if err != nil {
+		return fmt.Errorf("fetching measurements for station %s: %w", fs.Arg(0), err)
+	}
+	if len(measurements) == 0 {
+		fmt.Printf("No measurements for station %s between %s and %s.\n", fs.Arg(0), start.Format(time.DateTime), end.Format(time.DateTime))
+		return nil
+	}
+	displayStationMeasurements(measurements)
+	return nil
+}
+
+// parseStationTime parses a date or RFC 3339 time, returning fallback for
+// an empty value.
+func parseStationTime(value string, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, use YYYY-MM-DD or RFC 3339", value)
+	}
+	return t, nil
+}
+
+func displayStationMeasurements(measurements []StationMeasurement) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "TIME\tTEMP %s (MIN–MAX)\tHUMIDITY\tWIND %s\tPRESSURE hPa\tRAIN mm\n", displayUnits.temp.symbol, displayUnits.speed.symbol)
+	for _, m := range measurements {
+		fmt.Fprint(tw, lsprintf("%s\t%.1f (%.1f–%.1f)\t%.0f%%\t%.1f\t%.0f\t%.1f\n",
+			time.Unix(m.Date, 0).Local().Format("2006-01-02 15:04"),
+			showTemp(m.Temp.Average), showTemp(m.Temp.Min), showTemp(m.Temp.Max), m.Humidity.Average,
+			showSpeed(m.Wind.Speed.Average), m.Pressure.Average, m.Precipitation.Rain))
+	}
+	tw.Flush()
+}
+
+// StationReading is one measurement submitted to the Stations API. Unset
+// values are left out.
+type StationReading struct {
+	StationID   string   `json:"station_id"`
+	Dt          int64    `json:"dt"`
+	Temperature *float64 `json:"temperature,omitempty"`
+	Humidity    *float64 `json:"humidity,omitempty"`
+	Pressure    *float64 `json:"pressure,omitempty"`
+	WindSpeed   *float64 `json:"wind_speed,omitempty"`
+	WindGust    *float64 `json:"wind_gust,omitempty"`
+	WindDeg     *float64 `json:"wind_deg,omitempty"`
+	Rain1h      *float64 `json:"rain_1h,omitempty"`
+}
+
+// PushStationMeasurements submits readings to the Stations API.
+func PushStationMeasurements(readings []StationReading, apiKey string) error {
+	return sendJSON(http.MethodPost, measurementsURL+"?"+url.Values{"appid": {apiKey}}.Encode(), readings, nil)
+}
+
+// parseStationReadings decodes a reading or an array of readings in the
+// Stations API's field names, filling in stationID and the current time
+// where they are missing.
+func parseStationReadings(data []byte, stationID string) ([]StationReading, error) {
+	var readings []StationReading
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		readings = make([]StationReading, 1)
+		err := json.Unmarshal(data, &readings[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid reading: %w", err)
+		}
+	} else if err := json.Unmarshal(data, &readings); err != nil {
+		return nil, fmt.Errorf("invalid readings, expected a JSON object or array: %w", err)
+	}
+	for i := range readings {
+		if readings[i].StationID == "" {
+			readings[i].StationID = stationID
+		}
+		if readings[i].Dt == 0 {
+			readings[i].Dt = time.Now().Unix()
+		}
+		if readings[i].StationID == "" {
+			return nil, fmt.Errorf("reading %d has no station_id", i+1)
+		}
+	}
+	return readings, nil
+}
+
+// readStationSource runs an exec source command and parses the readings it
+// prints on stdout.
+func readStationSource(command []string, stationID string) ([]StationReading, error) {
+	stdout, err := runCommand(command, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("reading source: %w", err)
+	}
+	return parseStationReadings(stdout, stationID)
+}
+
+func runStationPush(args []string) error {
+	fs := flag.NewFlagSet("station push", flag.ExitOnError)
+	reading := StationReading{Dt: time.Now().Unix()}
+	given := false
+	for name, field := range map[string]**float64{
+		"temp":       &reading.Temperature,
+		"humidity":   &reading.Humidity,
+		"pressure":   &reading.Pressure,
+		"wind-speed": &reading.WindSpeed,
+		"wind-gust":  &reading.WindGust,
+		"wind-deg":   &reading.WindDeg,
+		"rain-1h":    &reading.Rain1h,
+	} {
+		fs.Func(name, "Measured "+strings.ReplaceAll(name, "-", " ")+" to submit", func(v string) error {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return err
+			}
+			*field, given = &f, true
+			return nil
+		})
+	}
+	stdin := fs.Bool("stdin", false, "Read a JSON reading, or an array of them, from stdin")
+	exec := fs.String("exec", "", "Command printing a JSON reading, or an array of them, e.g. 'read-sensor --json'")
+	fs.Parse(args)
+	if fs.NArg() != 1 || countSet(given, *stdin, *exec != "") != 1 {
+		return fmt.Errorf("usage: weather station push (--temp C --humidity %% ... | --stdin | --exec COMMAND) <station-id>")
+	}
+	reading.StationID = fs.Arg(0)
+
+	readings := []StationReading{reading}
+	var err error
+	switch {
+	case *stdin:
+		var data []byte
+		if data, err = io.ReadAll(os.Stdin); err == nil {
+			readings, err = parseStationReadings(data, fs.Arg(0))
+		}
+	case *exec != "":
+		readings, err = readStationSource(strings.Fields(*exec), fs.Arg(0))
+	}
+	if err != nil {
+		return err
+	}
+	if err := PushStationMeasurements(readings, apiKeyFromEnv()); err != nil {
+		return fmt.Errorf("submitting measurements: %w", err)
+	}
+	fmt.Printf("Submitted %d measurements to station %s\n", len(readings), fs.Arg(0))
+	return nil
+}
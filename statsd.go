@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// StatsDConfig is the "statsd" section of the config file.
+type StatsDConfig struct {
+	// Address is the StatsD agent's UDP address, host[:port]. Without one
+	// no metrics are sent.
+	Address string `json:"address,omitempty"`
+	// Prefix starts every metric name; it defaults to "weather.".
+	Prefix string `json:"prefix,omitempty"`
+	// DogStatsD sends the location as a "location" tag rather than in the
+	// metric name, along with Tags.
+	DogStatsD bool `json:"dogstatsd,omitempty"`
+	// Tags are added to every metric in DogStatsD mode, e.g.
+	// {"env": "prod"}.
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+const statsdDefaultPort = "8125"
+
+// statsd emits gauges for every current-weather fetch while the daemon
+// runs; nil otherwise.
+var statsd *statsdEmitter
+
+type statsdEmitter struct {
+	conn net.Conn
+	cfg  StatsDConfig
+	tags string
+}
+
+// newStatsDEmitter connects to the agent in cfg, returning nil if none is
+// configured.
+func newStatsDEmitter(cfg StatsDConfig) (*statsdEmitter, error) {
+	if cfg.Address == "" {
+		return nil, nil
+	}
+	address := cfg.Address
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, statsdDefaultPort)
+	}
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid statsd address %q: %w", cfg.Address, err)
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = "weather."
+	}
+	e := &statsdEmitter{conn: conn, cfg: cfg}
+	var tags []string
+	for name, value := range cfg.Tags {
+		tags = append(tags, name+":"+value)
+	}
+	sort.Strings(tags)
+	e.tags = strings.Join(tags, ",")
+	return e, nil
+}
+
+// statsdUnsafe matches the characters replaced in location names used as
+// metric name segments.
+var statsdUnsafe = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// gauges sends the current weather for loc, e.g.
+// "weather.nairobi.temp:22.5|g". Failures are only logged, since metrics
+// shouldn't fail the fetch that produced them.
+func (e *statsdEmitter) gauges(loc Location, data *CurrentWeatherResponse) {
+	if e == nil {
+		return
+	}
+	obs := observationFrom(loc.String(), data)
+	location := strings.Trim(statsdUnsafe.ReplaceAllString(strings.ToLower(obs.Location), "_"), "_")
+
+	var lines []string
+	for _, field := range sortedKeys(observationFields) {
+		value := strconv.FormatFloat(observationFields[field](obs), 'f', -1, 64)
+		if !e.cfg.DogStatsD {
+			lines = append(lines, fmt.Sprintf("%s%s.%s:%s|g", e.cfg.Prefix, location, field, value))
+			continue
+		}
+		line := fmt.Sprintf("%s%s:%s|g|#location:%s", e.cfg.Prefix, field, value, location)
+		if e.tags != "" {
+			line += "," + e.tags
+		}
+		lines = append(lines, line)
+	}
+	if _, err := e.conn.Write([]byte(strings.Join(lines, "\n"))); err != nil {
+		log.Printf("statsd: %v", err)
+	}
+}
+
+func (e *statsdEmitter) Close() error {
+	if e == nil {
+		return nil
+	}
+	return e.conn.Close()
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+func observationsPath() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "observations.jsonl"), nil
+}
+
+// appendObservation adds obs to the observation log, one JSON object per
+// line so the file can be appended to without rewriting it.
+func appendObservation(obs Observation) error {
+	path, err := observationsPath()
+	if err != nil {
+		return err
+	}
+	return appendJSONLines(path, []Observation{obs})
+}
+
+// readObservations returns every recorded observation, oldest first.
+func readObservations() ([]Observation, error) {
+	path, err := observationsPath()
+	if err != nil {
+		return nil, err
+	}
+	return readJSONLines[Observation](path)
+}
+
+// appendJSONLines appends values to a JSON Lines file, creating it if
+// needed.
+func appendJSONLines[T any](path string, values []T) error {
+	var buf bytes.Buffer
+	for _, v := range values {
+		line, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s entry: %w", filepath.Base(path), err)
+		}
+		buf.Write(append(line, '\n'))
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", filepath.Base(path), err)
+	}
+	defer f.Close()
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// readJSONLines reads every entry of a JSON Lines file, oldest first. A
+// missing file has no entries.
+func readJSONLines[T any](path string) ([]T, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+	defer f.Close()
+
+	var entries []T
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		var entry T
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse %s line %d: %w", path, line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// workloads are the --work intensities, as in the US Army's heat stress
+// guidance: light (walking, weeding), moderate (digging, marching with a
+// load) and heavy (hauling, sprint drills).
+var workloads = []string{"light", "moderate", "heavy"}
+
+// heatCategories are the WBGT flag conditions of US Army TB MED 507, with
+// the work/rest cycle per hour for each workload and the water to drink.
+var heatCategories = []struct {
+	minWBGT  float64
+	flag     string
+	workRest [3]string
+	water    string
+	sports   string
+}{
+	{32.2, "black", [3]string{"50/10 min", "20/40 min", "10/50 min"}, "1 L/h", "cancel or move indoors"},
+	{31.1, "red", [3]string{"no limit", "30/30 min", "20/40 min"}, "0.75 L/h", "limit intense drills, remove equipment"},
+	{29.4, "yellow", [3]string{"no limit", "40/20 min", "30/30 min"}, "0.75 L/h", "more breaks, watch unfit or new players"},
+	{27.8, "green", [3]string{"no limit", "50/10 min", "30/30 min"}, "0.75 L/h", "normal, with water breaks"},
+	{25.6, "white", [3]string{"no limit", "no limit", "40/20 min"}, "0.5 L/h", "normal, with water breaks"},
+}
+
+// frostbiteRisks are Environment Canada's wind chill risk levels, with how
+// soon exposed skin can freeze.
+var frostbiteRisks = []struct {
+	maxChill float64
+	risk     string
+	exposure string
+	sports   string
+}{
+	{-55, "extreme", "under 2 min", "cancel"},
+	{-48, "very high", "2-5 min", "cancel or move indoors"},
+	{-40, "high", "5-10 min", "move indoors"},
+	{-28, "moderate", "10-30 min", "cover all skin, shorten practice"},
+	{-10, "low", "", "dress in layers"},
+}
+
+// coldWorkSchedule is the ACGIH work/warm-up schedule for a 4-hour shift,
+// from the longest work period to stopping work. See coldWorkPeriod.
+var coldWorkSchedule = []string{
+	"normal breaks", "normal breaks", "75 min work, 2 breaks", "55 min work, 3 breaks",
+	"40 min work, 4 breaks", "30 min work, 5 breaks", "stop non-emergency work",
+}
+
+// coldWorkPeriod returns the ACGIH work/warm-up schedule for moderate to
+// heavy work at temp °C and wind m/s, or "" above -26 °C. Each band of about
+// 3 °C colder, or 8 km/h windier, moves one step down the schedule.
+func coldWorkPeriod(temp, wind float64) string {
+	if temp > -25.5 {
+		return ""
+	}
+	row := 6
+	for i, limit := range []float64{-28.5, -31.5, -34.5, -37.5, -39.5, -42.5} {
+		if temp > limit {
+			row = i
+			break
+		}
+	}
+	column := min(int(math.Round(wind*3.6/8)), 4)
+	return coldWorkSchedule[min(row+column, len(coldWorkSchedule)-1)]
+}
+
+// stressAdvice is the heat or cold stress advisory for one time.
+type stressAdvice struct {
+	at       time.Time
+	temp     float64
+	index    string // "WBGT 30.1" or "wind chill -31"
+	level    string
+	exposure string // work/rest cycle, or time to frostbite
+	water    string
+	sports   string
+}
+
+// adviseStress returns the advisory for temperature in °C, relative
+// humidity in % and wind in m/s for work, an index into workloads.
+func adviseStress(at time.Time, temp, humidity, wind float64, work int) stressAdvice {
+	a := stressAdvice{at: at, temp: temp, level: "none", exposure: "-", water: "-", sports: "normal"}
+	if temp >= 15 {
+		w := wbgt(temp, humidity)
+		a.index = lsprintf("WBGT %.1f", showTemp(w))
+		for _, c := range heatCategories {
+			if w >= c.minWBGT {
+				a.level, a.exposure, a.water, a.sports = c.flag+" flag", c.workRest[work]+" work/rest", c.water, c.sports
+				break
+			}
+		}
+		return a
+	}
+	chill := windChill(temp, wind)
+	a.index = lsprintf("wind chill %.0f", showTemp(chill))
+	for _, r := range frostbiteRisks {
+		if chill <= r.maxChill {
+			a.level, a.sports = r.risk+" frostbite risk", r.sports
+			if r.exposure != "" {
+				a.exposure = "skin freezes in " + r.exposure
+			}
+			break
+		}
+	}
+	if schedule := coldWorkPeriod(temp, wind); schedule != "" {
+		a.exposure = strings.TrimPrefix(a.exposure+"; ", "-; ") + schedule
+	}
+	return a
+}
+
+// runStress implements `weather stress`, the heat and cold stress advisory
+// for scheduling outdoor work or sports over the coming hours.
+func runStress(args []string) error {
+	fs := flag.NewFlagSet("stress", flag.ExitOnError)
+	locFlags := addLocationFlags(fs)
+	work := fs.String("work", "moderate", "Workload for work/rest cycles: "+strings.Join(workloads, ", "))
+	hours := fs.Int("hours", 24, "How many hours ahead to advise on, up to 120")
+	fs.Parse(args)
+
+	loc, hasFlagLoc, err := locFlags.location()
+	switch {
+	case err != nil:
+	case hasFlagLoc:
+	case fs.NArg() > 0:
+		loc, err = locFlags.resolveCity(strings.Join(fs.Args(), " "))
+	case config.DefaultCity != "":
+		loc, err = resolveLocation(config.DefaultCity)
+	default:
+		return fmt.Errorf("usage: weather stress [--work light|moderate|heavy] [--hours 24] <city|@favorite>")
+	}
+	if err != nil {
+		return err
+	}
+	usePreferences(loc)
+	workload := -1
+	for i, w := range workloads {
+		if w == *work {
+			workload = i
+		}
+	}
+	if workload < 0 {
+		return fmt.Errorf("invalid --work %q, use one of: %s", *work, strings.Join(workloads, ", "))
+	}
+
+	apiKey := apiKeyFromEnv()
+	current, err := GetCurrentWeatherAt(loc, apiKey)
+	if err != nil {
+		return fmt.Errorf("fetching current weather for %s: %w", loc, err)
+	}
+	forecast, err := GetForecastAt(loc, apiKey)
+	if err != nil {
+		return fmt.Errorf("fetching forecast for %s: %w", loc, err)
+	}
+
+	now := time.Now()
+	advice := []stressAdvice{adviseStress(now, current.Main.Temp, float64(current.Main.Humidity), current.Wind.Speed, workload)}
+	for _, p := range forecastPointsFrom(forecast) {
+		if p.At.After(now) && p.At.Before(now.Add(time.Duration(*hours)*time.Hour)) {
+			advice = append(advice, adviseStress(p.At, p.Temp, p.Humidity, p.WindSpeed, workload))
+		}
+	}
+
+	lprintf("Heat and cold stress for %s, %s (%s work):\n", current.Name, current.Sys.Country, *work)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "TIME\tTEMP %s\tINDEX\tLEVEL\tWORK\tWATER\tSPORTS\n", displayUnits.temp.symbol)
+	for i, a := range advice {
+		at := formatDate(a.at.Local(), "Mon 15:04")
+		if i == 0 {
+			at = "now"
+		}
+		fmt.Fprintln(tw, lsprintf("%s\t%.0f\t%s\t%s\t%s\t%s\t%s", at, showTemp(a.temp), a.index, a.level, a.exposure, a.water, a.sports))
+	}
+	tw.Flush()
+	fmt.Println("WBGT is estimated for moderate sun and light wind; full sun adds about 2-3 °C. Rest in shade, and stop at any sign of heat illness or numbness.")
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// printJSON prints data as indented JSON for --output json, in the same
+// shape the API returns so it can be piped into jq.
+func printJSON[T any](data T) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(data); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to encode JSON: %v\n", err)
+	}
+}
+
+// printYAML prints data as YAML for --output yaml, with the same keys and
+// order as the JSON output.
+func printYAML[T any](data T) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to encode YAML: %v\n", err)
+		return
+	}
+	// JSON is YAML, so decoding it into a node keeps the key order; only the
+	// flow style it's parsed with needs resetting to get block output.
+	var doc yaml.Node
+	if err := yaml.Unmarshal(encoded, &doc); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to encode YAML: %v\n", err)
+		return
+	}
+	blockStyle(&doc)
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to encode YAML: %v\n", err)
+		return
+	}
+	encoder.Close()
+	os.Stdout.Write(out.Bytes())
+}
+
+// blockStyle switches n and its children from flow to block style, leaving
+// quoted strings quoted.
+func blockStyle(n *yaml.Node) {
+	n.Style &^= yaml.FlowStyle
+	if n.Kind == yaml.ScalarNode && n.Tag == "!!str" {
+		n.Style = 0
+	}
+	for _, child := range n.Content {
+		blockStyle(child)
+	}
+}
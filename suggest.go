@@ -0,0 +1,112 @@
+package main
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+//go:embed data/cities.txt
+var bundledCities string
+
+// maxSuggestions is how many "did you mean" candidates are offered.
+const maxSuggestions = 3
+
+// withSuggestions augments a city-not-found error with likely intended
+// spellings. Other errors are returned unchanged.
+func withSuggestions(err error, loc Location, apiKey string) error {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || loc.Name == "" || loc.HasCoords {
+		return err
+	}
+	suggestions := suggestCities(loc.Name, apiKey)
+	if len(suggestions) == 0 {
+		return err
+	}
+	return fmt.Errorf("%w\nDid you mean %s?", err, quoteJoin(suggestions))
+}
+
+// suggestCities returns the places most similar to a city name that was not
+// found, drawing on geocoding search results and a bundled list of major
+// cities.
+func suggestCities(query, apiKey string) []string {
+	name, _, _ := strings.Cut(query, ",")
+	name = strings.TrimSpace(name)
+
+	candidates := make(map[string]bool)
+	if results, err := Geocode(name, 5, apiKey); err == nil {
+		for _, r := range results {
+			candidates[r.Name] = true
+		}
+	}
+	for _, line := range strings.Split(bundledCities, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			candidates[line] = true
+		}
+	}
+
+	// Allow roughly one typo per three characters.
+	maxDistance := max(len([]rune(name))/3, 1)
+	type match struct {
+		name     string
+		distance int
+	}
+	var matches []match
+	for candidate := range candidates {
+		if strings.EqualFold(candidate, name) {
+			continue
+		}
+		if d := levenshtein(strings.ToLower(name), strings.ToLower(candidate)); d <= maxDistance {
+			matches = append(matches, match{candidate, d})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	var suggestions []string
+	for i := 0; i < len(matches) && i < maxSuggestions; i++ {
+		suggestions = append(suggestions, matches[i].name)
+	}
+	return suggestions
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// quoteJoin formats names as "'a', 'b' or 'c'".
+func quoteJoin(names []string) string {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = "'" + n + "'"
+	}
+	if len(quoted) == 1 {
+		return quoted[0]
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + " or " + quoted[len(quoted)-1]
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"fyne.io/systray"
+)
+
+const iconURL = "https://openweathermap.org/img/wn/%s@2x.png"
+
+// trayDetailRows is the number of read-only detail rows in the tray menu.
+const trayDetailRows = 8
+
+// runTray implements `weather tray`, showing the current temperature and
+// condition icon in the system tray / menu bar. Clicking the icon opens a
+// menu with the full details and the next few forecast entries.
+func runTray(args []string) error {
+	fs := flag.NewFlagSet("tray", flag.ExitOnError)
+	city := fs.String("city", "", "City to show")
+	interval := fs.Duration("interval", 10*time.Minute, "How often to refresh")
+	fs.Parse(args)
+
+	if *city == "" {
+		return fmt.Errorf("please provide a city name using the --city flag")
+	}
+	apiKey := apiKeyFromEnv()
+
+	systray.Run(func() { trayReady(*city, apiKey, *interval) }, func() {})
+	return nil
+}
+
+func trayReady(city, apiKey string, interval time.Duration) {
+	systray.SetTitle("…")
+	systray.SetTooltip("Weather for " + city)
+
+	header := systray.AddMenuItem(city, "")
+	header.Disable()
+	systray.AddSeparator()
+	details := make([]*systray.MenuItem, trayDetailRows)
+	for i := range details {
+		details[i] = systray.AddMenuItem("", "")
+		details[i].Disable()
+		details[i].Hide()
+	}
+	systray.AddSeparator()
+	refresh := systray.AddMenuItem("Refresh now", "Fetch the latest weather")
+	open := systray.AddMenuItem("Open in browser", "Show this city on OpenWeatherMap")
+	quit := systray.AddMenuItem("Quit", "Quit the weather tray")
+
+	cityID := 0
+	update := func() {
+		current, err := GetCurrentWeather(city, apiKey)
+		if err != nil {
+			systray.SetTitle("?")
+			systray.SetTooltip(fmt.Sprintf("Error fetching weather for %s: %v", city, err))
+			return
+		}
+		cityID = current.ID
+		msg := currentWeatherMessage(current)
+		systray.SetTitle(lsprintf("%.*f%s", decimals("temp", 0), showTemp(current.Main.Temp), displayUnits.temp.symbol))
+		systray.SetTooltip(msg.Title + ": " + msg.Body())
+		if len(current.Weather) > 0 {
+			if icon, err := fetchTrayIcon(current.Weather[0].Icon); err == nil {
+				systray.SetIcon(icon)
+			}
+		}
+
+		header.SetTitle(msg.Title)
+		rows := append([]string{}, msg.Parts...)
+		rows = append(rows, fmt.Sprintf("sunrise %s, sunset %s",
+			time.Unix(current.Sys.Sunrise, 0).Local().Format("15:04"),
+			time.Unix(current.Sys.Sunset, 0).Local().Format("15:04")))
+		if forecast, err := GetForecast(city, apiKey); err == nil {
+			for _, entry := range forecast.List {
+				if len(rows) == trayDetailRows {
+					break
+				}
+				rows = append(rows, lsprintf("%s  %.*f%s  %.0f%% rain",
+					time.Unix(entry.Dt, 0).Local().Format("Mon 15:04"), decimals("temp", 0), showTemp(entry.Main.Temp), displayUnits.temp.symbol, entry.Pop*100))
+			}
+		}
+		for i, item := range details {
+			if i < len(rows) {
+				item.SetTitle(rows[i])
+				item.Show()
+			} else {
+				item.Hide()
+			}
+		}
+	}
+
+	go func() {
+		update()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				update()
+			case <-refresh.ClickedCh:
+				update()
+			case <-open.ClickedCh:
+				if cityID != 0 {
+					openURL(fmt.Sprintf("https://openweathermap.org/city/%d", cityID))
+				}
+			case <-quit.ClickedCh:
+				systray.Quit()
+				return
+			}
+		}
+	}()
+}
+
+// fetchTrayIcon downloads an OpenWeatherMap condition icon in the format
+// the platform's tray expects.
+func fetchTrayIcon(code string) ([]byte, error) {
+	resp, err := http.Get(fmt.Sprintf(iconURL, code))
+	if err != nil {
+		return nil, fmt.Errorf("failed to make HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("icon request failed with status %d", resp.StatusCode)
+	}
+	png, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read icon: %w", err)
+	}
+	if runtime.GOOS == "windows" {
+		return pngToICO(png), nil
+	}
+	return png, nil
+}
+
+// pngToICO wraps PNG data in a single-image ICO container, which Windows
+// accepts since Vista and is what the Windows tray requires.
+func pngToICO(png []byte) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, struct {
+		Reserved, Type, Count uint16
+	}{0, 1, 1})
+	binary.Write(&buf, binary.LittleEndian, struct {
+		Width, Height, Colors, Reserved uint8
+		Planes, BitCount                uint16
+		Size, Offset                    uint32
+	}{0, 0, 0, 0, 1, 32, uint32(len(png)), 22})
+	buf.Write(png)
+	return buf.Bytes()
+}
+
+// openURL opens url in the user's default browser.
+func openURL(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
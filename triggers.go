@@ -0,0 +1,250 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const triggersURL = "https://api.openweathermap.org/data/3.0/triggers"
+
+// kelvinOffset converts between the Triggers API's Kelvin temperatures and
+// the Celsius used everywhere else.
+const kelvinOffset = 273.15
+
+// TriggerCondition is one server-side trigger condition, e.g. temperature
+// ($gt) above 308.15 K.
+type TriggerCondition struct {
+	ID         string  `json:"_id,omitempty"`
+	Name       string  `json:"name"`
+	Expression string  `json:"expression"`
+	Amount     float64 `json:"amount"`
+}
+
+// triggerTime is a point relative to when the trigger was created.
+type triggerTime struct {
+	Expression string `json:"expression"`
+	Amount     int64  `json:"amount"` // milliseconds
+}
+
+// triggerArea is a GeoJSON geometry the trigger watches.
+type triggerArea struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"` // lon, lat
+}
+
+// Trigger is an OpenWeatherMap trigger: conditions watched by the API
+// over an area and time period, raising alerts when they are met.
+type Trigger struct {
+	ID         string `json:"_id,omitempty"`
+	TimePeriod struct {
+		Start triggerTime `json:"start"`
+		End   triggerTime `json:"end"`
+	} `json:"time_period"`
+	Conditions []TriggerCondition `json:"conditions"`
+	Area       []triggerArea      `json:"area"`
+}
+
+// TriggerAlert is one alert raised by a trigger.
+type TriggerAlert struct {
+	ID         string `json:"_id"`
+	Date       int64  `json:"date"`        // forecast time the alert is about, ms
+	LastUpdate int64  `json:"last_update"` // ms
+	Conditions []struct {
+		CurrentValue struct {
+			Min float64 `json:"min"`
+			Max float64 `json:"max"`
+		} `json:"current_value"`
+		Condition TriggerCondition `json:"condition"`
+	} `json:"conditions"`
+	Coordinates Coord `json:"coordinates"`
+}
+
+// triggerOperators maps condition operators to the API's expressions.
+var triggerOperators = map[string]string{">": "$gt", ">=": "$gte", "<": "$lt", "<=": "$lte", "=": "$eq", "!=": "$ne"}
+
+// triggerFields are the parameters triggers can watch.
+var triggerFields = []string{"temp", "pressure", "humidity", "wind_speed", "wind_direction", "clouds"}
+
+var triggerConditionPattern = regexp.MustCompile(`^\s*([a-z_]+)\s*(>=|<=|!=|>|<|=)\s*(-?[0-9.]+)\s*$`)
+
+// parseTriggerCondition parses e.g. "temp > 35" (°C) into a condition.
+func parseTriggerCondition(s string) (TriggerCondition, error) {
+	m := triggerConditionPattern.FindStringSubmatch(s)
+	if m == nil {
+		return TriggerCondition{}, fmt.Errorf("invalid condition %q, use e.g. \"temp > 35\"", s)
+	}
+	known := false
+	for _, f := range triggerFields {
+		known = known || f == m[1]
+	}
+	if !known {
+		return TriggerCondition{}, fmt.Errorf("unknown parameter %q in condition %q, use one of: %s", m[1], s, strings.Join(triggerFields, ", "))
+	}
+	amount, err := strconv.ParseFloat(m[3], 64)
+	if err != nil {
+		return TriggerCondition{}, fmt.Errorf("invalid value in condition %q", s)
+	}
+	if m[1] == "temp" {
+		amount += kelvinOffset
+	}
+	return TriggerCondition{Name: m[1], Expression: triggerOperators[m[2]], Amount: amount}, nil
+}
+
+// String formats c the way parseTriggerCondition reads it.
+func (c TriggerCondition) String() string {
+	op := c.Expression
+	for symbol, expression := range triggerOperators {
+		if expression == c.Expression {
+			op = symbol
+		}
+	}
+	amount := c.Amount
+	if c.Name == "temp" {
+		amount -= kelvinOffset
+	}
+	return fmt.Sprintf("%s %s %s", c.Name, op, strconv.FormatFloat(amount, 'f', -1, 64))
+}
+
+func triggersRequestURL(apiKey string, path ...string) string {
+	return strings.Join(append([]string{triggersURL}, path...), "/") + "?" + url.Values{"appid": {apiKey}}.Encode()
+}
+
+// runTriggers implements `weather triggers create|list|delete|history`.
+func runTriggers(args []string) error {
+	usage := fmt.Errorf("usage: weather triggers create --condition COND [--condition COND]... [--start DURATION] [--end DURATION] <city|@favorite> | list | delete <id> | history <id>")
+	if len(args) == 0 {
+		return usage
+	}
+	switch args[0] {
+	case "create":
+		return runTriggersCreate(args[1:])
+
+	case "list":
+		if len(args) != 1 {
+			return usage
+		}
+		var triggers []Trigger
+		if err := sendJSON(http.MethodGet, triggersRequestURL(apiKeyFromEnv()), nil, &triggers); err != nil {
+			return fmt.Errorf("listing triggers: %w", err)
+		}
+		if len(triggers) == 0 {
+			fmt.Println("No triggers. Create one with: weather triggers create --condition \"temp > 35\" Nairobi")
+			return nil
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tCONDITIONS\tAREA\tPERIOD")
+		for _, t := range triggers {
+			var conditions, area []string
+			for _, c := range t.Conditions {
+				conditions = append(conditions, c.String())
+			}
+			for _, a := range t.Area {
+				if len(a.Coordinates) == 2 {
+					area = append(area, fmt.Sprintf("%.4f, %.4f", a.Coordinates[1], a.Coordinates[0]))
+				}
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s to %s\n", t.ID, strings.Join(conditions, " && "), strings.Join(area, "; "),
+				time.Duration(t.TimePeriod.Start.Amount)*time.Millisecond, time.Duration(t.TimePeriod.End.Amount)*time.Millisecond)
+		}
+		return tw.Flush()
+
+	case "delete":
+		if len(args) != 2 {
+			return usage
+		}
+		if err := sendJSON(http.MethodDelete, triggersRequestURL(apiKeyFromEnv(), url.PathEscape(args[1])), nil, nil); err != nil {
+			return fmt.Errorf("deleting trigger %s: %w", args[1], err)
+		}
+		fmt.Printf("Deleted trigger %s\n", args[1])
+		return nil
+
+	case "history":
+		if len(args) != 2 {
+			return usage
+		}
+		var alerts []TriggerAlert
+		if err := sendJSON(http.MethodGet, triggersRequestURL(apiKeyFromEnv(), url.PathEscape(args[1]), "history"), nil, &alerts); err != nil {
+			return fmt.Errorf("fetching alerts for trigger %s: %w", args[1], err)
+		}
+		if len(alerts) == 0 {
+			fmt.Printf("Trigger %s hasn't raised any alerts.\n", args[1])
+			return nil
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "FOR\tCONDITION\tVALUE\tLOCATION\tUPDATED")
+		for _, a := range alerts {
+			for _, c := range a.Conditions {
+				value := c.CurrentValue.Max
+				if c.Condition.Name == "temp" {
+					value -= kelvinOffset
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%.1f\t%.4f, %.4f\t%s\n",
+					time.UnixMilli(a.Date).Local().Format("2006-01-02 15:04"), c.Condition, value,
+					a.Coordinates.Lat, a.Coordinates.Lon, time.UnixMilli(a.LastUpdate).Local().Format("2006-01-02 15:04"))
+			}
+		}
+		return tw.Flush()
+	}
+	return usage
+}
+
+func runTriggersCreate(args []string) error {
+	fs := flag.NewFlagSet("triggers create", flag.ExitOnError)
+	locFlags := addLocationFlags(fs)
+	var conditions stringList
+	fs.Var(&conditions, "condition", "Condition such as \"temp > 35\" (°C) or \"wind_speed >= 15\" (repeatable; all must hold): "+strings.Join(triggerFields, ", "))
+	start := fs.Duration("start", 0, "Start of the watched period, from now")
+	end := fs.Duration("end", 48*time.Hour, "End of the watched period, from now (the forecast reaches about 5 days)")
+	fs.Parse(args)
+
+	loc, hasFlagLoc, err := locFlags.location()
+	switch {
+	case err != nil:
+	case hasFlagLoc:
+	case fs.NArg() > 0:
+		loc, err = locFlags.resolveCity(strings.Join(fs.Args(), " "))
+	default:
+		return fmt.Errorf("usage: weather triggers create --condition COND [--condition COND]... [--start DURATION] [--end DURATION] <city|@favorite>")
+	}
+	if err != nil {
+		return err
+	}
+	if len(conditions) == 0 {
+		return fmt.Errorf("please give at least one --condition, e.g. --condition \"temp > 35\"")
+	}
+	if *start < 0 || *end <= *start {
+		return fmt.Errorf("--end must be after --start")
+	}
+
+	var trigger Trigger
+	for _, c := range conditions {
+		condition, err := parseTriggerCondition(c)
+		if err != nil {
+			return err
+		}
+		trigger.Conditions = append(trigger.Conditions, condition)
+	}
+	apiKey := apiKeyFromEnv()
+	lat, lon, err := loc.coordinates(apiKey)
+	if err != nil {
+		return err
+	}
+	trigger.Area = []triggerArea{{Type: "Point", Coordinates: []float64{lon, lat}}}
+	trigger.TimePeriod.Start = triggerTime{Expression: "after", Amount: start.Milliseconds()}
+	trigger.TimePeriod.End = triggerTime{Expression: "after", Amount: end.Milliseconds()}
+
+	var created Trigger
+	if err := sendJSON(http.MethodPost, triggersRequestURL(apiKey), trigger, &created); err != nil {
+		return fmt.Errorf("creating trigger: %w", err)
+	}
+	fmt.Printf("Created trigger %s for %s. Check its alerts with: weather triggers history %s\n", created.ID, loc, created.ID)
+	return nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const twilioMessagesURL = "https://api.twilio.com/2010-04-01/Accounts/%s/Messages.json"
+
+// smsSegmentLength is the size of a single GSM-7 SMS segment, in septets.
+// Messages are kept within one segment (and to GSM-7 characters) to avoid
+// the recipient receiving a split or UCS-2 encoded message.
+const smsSegmentLength = 160
+
+// gsm7Basic and gsm7Extension are the GSM 03.38 default alphabet and its
+// extension table, whose characters take two septets each.
+const (
+	gsm7Basic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+		"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+	gsm7Extension = "^{}\\[~]|€"
+)
+
+// gsm7Replacements spell common characters outside GSM-7 with ones inside.
+var gsm7Replacements = map[rune]string{
+	'°': "", '–': "-", '—': "-", '…': "...", '‘': "'", '’': "'", '“': "\"", '”': "\"", '\t': " ",
+}
+
+// TwilioNotifier sends SMS messages through the Twilio REST API.
+type TwilioNotifier struct {
+	AccountSID string
+	AuthToken  string
+	From       string
+	To         []string
+}
+
+// newTwilioNotifierFromEnv builds a TwilioNotifier from the TWILIO_ACCOUNT_SID,
+// TWILIO_AUTH_TOKEN, TWILIO_FROM and TWILIO_TO (comma-separated) variables.
+func newTwilioNotifierFromEnv() (Notifier, error) {
+	n := &TwilioNotifier{
+		AccountSID: os.Getenv("TWILIO_ACCOUNT_SID"),
+		AuthToken:  os.Getenv("TWILIO_AUTH_TOKEN"),
+		From:       os.Getenv("TWILIO_FROM"),
+	}
+	for _, to := range strings.Split(os.Getenv("TWILIO_TO"), ",") {
+		if to = strings.TrimSpace(to); to != "" {
+			n.To = append(n.To, to)
+		}
+	}
+	if n.AccountSID == "" || n.AuthToken == "" || n.From == "" || len(n.To) == 0 {
+		return nil, fmt.Errorf("TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN, TWILIO_FROM and TWILIO_TO must all be set")
+	}
+	return n, nil
+}
+
+// Notify sends msg, summarized to fit a single SMS, to every recipient.
+func (n *TwilioNotifier) Notify(msg Message) error {
+	text := summarizeForSMS(msg, smsSegmentLength)
+	for _, to := range n.To {
+		form := url.Values{"From": {n.From}, "To": {to}, "Body": {text}}
+		req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(twilioMessagesURL, n.AccountSID), strings.NewReader(form.Encode()))
+		if err != nil {
+			return fmt.Errorf("failed to build Twilio request: %w", err)
+		}
+		req.SetBasicAuth(n.AccountSID, n.AuthToken)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to make HTTP request: %w", err)
+		}
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("Twilio request for %s failed with status %d: %s", to, resp.StatusCode, string(bodyBytes))
+		}
+	}
+	return nil
+}
+
+// summarizeForSMS renders msg as GSM-7 text (see toGSM7) of at most limit
+// septets, keeping as many of the leading (most important) parts as fit and
+// truncating the first part only if even that does not fit.
+func summarizeForSMS(msg Message, limit int) string {
+	text := toGSM7(msg.Title)
+	for i, part := range msg.Parts {
+		sep := ": "
+		if i > 0 {
+			sep = ", "
+		}
+		next := text + sep + toGSM7(part)
+		if gsm7Length(next) > limit {
+			if i == 0 {
+				text = next
+			}
+			break
+		}
+		text = next
+	}
+	if gsm7Length(text) > limit {
+		runes := []rune(text)
+		for len(runes) > 0 && gsm7Length(string(runes))+3 > limit {
+			runes = runes[:len(runes)-1]
+		}
+		text = string(runes) + "..."
+	}
+	return text
+}
+
+// toGSM7 rewrites s in the GSM-7 alphabet, so an SMS isn't sent as UCS-2
+// with 70-character segments: common punctuation is replaced, accents the
+// alphabet lacks are dropped (Zürich stays, Kraków becomes Krakow), other
+// letters and digits become ? and other symbols, such as emoji, are left
+// out.
+func toGSM7(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if replacement, ok := gsm7Replacements[r]; ok {
+			b.WriteString(replacement)
+			continue
+		}
+		if strings.ContainsRune(gsm7Basic, r) || strings.ContainsRune(gsm7Extension, r) {
+			b.WriteRune(r)
+			continue
+		}
+		base := []rune(norm.NFD.String(string(r)))[0]
+		switch {
+		case strings.ContainsRune(gsm7Basic, base):
+			b.WriteRune(base)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteByte('?')
+		case unicode.IsSpace(r):
+			b.WriteByte(' ')
+		}
+	}
+	return b.String()
+}
+
+// gsm7Length returns the septets s takes in GSM-7, which it must be
+// written in.
+func gsm7Length(s string) int {
+	n := 0
+	for _, r := range s {
+		n++
+		if strings.ContainsRune(gsm7Extension, r) {
+			n++
+		}
+	}
+	return n
+}
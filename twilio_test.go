@@ -0,0 +1,70 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestToGSM7(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Nairobi: 24°C, scattered clouds", "Nairobi: 24C, scattered clouds"},
+		{"Zürich 12–18°C…", "Zürich 12-18C..."},
+		{"Kraków, São Paulo, Reykjavík", "Krakow, Sao Paulo, Reykjavik"},
+		{"Москва", "??????"},
+		{"Rain ☔ later", "Rain  later"},
+		{"“quoted” {braces} €5", "\"quoted\" {braces} €5"},
+	}
+	for _, tt := range tests {
+		if got := toGSM7(tt.in); got != tt.want {
+			t.Errorf("toGSM7(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestGSM7Length(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"", 0},
+		{"Zürich", 6},
+		{"{€}", 6},
+	}
+	for _, tt := range tests {
+		if got := gsm7Length(tt.in); got != tt.want {
+			t.Errorf("gsm7Length(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSummarizeForSMS(t *testing.T) {
+	msg := Message{Title: "Zürich", Parts: []string{"12°C, light rain", "wind 4 m/s", "humidity 80%"}}
+	tests := []struct {
+		name  string
+		limit int
+		want  string
+	}{
+		{"everything fits", 160, "Zürich: 12C, light rain, wind 4 m/s, humidity 80%"},
+		{"drops trailing parts", 35, "Zürich: 12C, light rain, wind 4 m/s"},
+		{"truncates the first part by character", 14, "Zürich: 12C..."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := summarizeForSMS(msg, tt.limit)
+			if got != tt.want {
+				t.Errorf("summarizeForSMS = %q, want %q", got, tt.want)
+			}
+			if !utf8.ValidString(got) || gsm7Length(got) > tt.limit {
+				t.Errorf("summarizeForSMS = %q, not valid UTF-8 within %d septets", got, tt.limit)
+			}
+		})
+	}
+
+	long := Message{Title: strings.Repeat("é", 200)}
+	if got := summarizeForSMS(long, smsSegmentLength); gsm7Length(got) != smsSegmentLength || !utf8.ValidString(got) {
+		t.Errorf("summarizeForSMS of a long title = %d septets, valid UTF-8 %v", gsm7Length(got), utf8.ValidString(got))
+	}
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// unit is a unit of measurement, converted through its quantity's base
+// unit: °C, m/s, hPa, mm or km.
+type unit struct {
+	symbol   string
+	quantity string
+	toBase   func(float64) float64
+	fromBase func(float64) float64
+}
+
+// scaled returns a unit that is factor base units.
+func scaled(symbol, quantity string, factor float64) unit {
+	return unit{symbol, quantity, func(v float64) float64 { return v * factor }, func(v float64) float64 { return v / factor }}
+}
+
+// units lists the known units, grouped by quantity; the first of each
+// group is the base unit.
+var units = []unit{
+	scaled("°C", "temperature", 1),
+	{"°F", "temperature", func(v float64) float64 { return (v - 32) * 5 / 9 }, func(v float64) float64 { return v*9/5 + 32 }},
+	{"K", "temperature", func(v float64) float64 { return v - kelvinOffset }, func(v float64) float64 { return v + kelvinOffset }},
+
+	scaled("m/s", "speed", 1),
+	scaled("km/h", "speed", 1/3.6),
+	scaled("mph", "speed", 0.44704),
+	scaled("knots", "speed", 1852.0/3600),
+	{"Beaufort", "speed", beaufortToSpeed, speedToBeaufort},
+
+	scaled("hPa", "pressure", 1),
+	scaled("kPa", "pressure", 10),
+	scaled("inHg", "pressure", 33.8639),
+	scaled("mmHg", "pressure", 1.33322),
+	scaled("psi", "pressure", 68.9476),
+
+	scaled("mm", "precipitation", 1),
+	scaled("cm", "precipitation", 10),
+	scaled("in", "precipitation", 25.4),
+
+	scaled("km", "distance", 1),
+	scaled("m", "distance", 0.001),
+	scaled("mi", "distance", 1.609344),
+}
+
+// unitsName and langName are set by the global --units and --lang flags
+// or the WEATHER_TOOL_UNITS and WEATHER_TOOL_LANG environment variables,
+// and fall back to "units" and "lang" in the config file. precisionSpec
+// is the global --precision flag or WEATHER_TOOL_PRECISION, e.g.
+// "temp=0,wind=1", on top of "precision" in the config file.
+var unitsName, langName, precisionSpec string
+
+// precisionFields are the readings whose decimals --precision sets: all
+// temperatures and all wind speeds.
+var precisionFields = []string{"temp", "wind"}
+
+// displayPrecision is the number of decimals for each field of
+// precisionFields that --precision or the config file sets. Fields it
+// doesn't set keep each output's own precision.
+var displayPrecision = map[string]int{}
+
+// unitsFromFlag and langFromFlag record whether --units and --lang (or
+// their environment variables) were given, so that a favorite's
+// preferences don't override them.
+var unitsFromFlag, langFromFlag bool
+
+// unitSystem is a --units choice, named as in the OpenWeatherMap API, with
+// the units temperatures and wind speeds are displayed in. Readings are
+// still fetched in metric, which alert rules, records and the server
+// depend on, and converted for display.
+type unitSystem struct {
+	// distance is the unit visibility is shown in, with shorter
+	// distances in metres or feet.
+	temp, speed, distance unit
+	tempWords, speedWords string
+	// tempWord is the short spoken temperature unit, as in "14 to 26
+	// degrees".
+	tempWord string
+}
+
+var unitSystems = map[string]unitSystem{
+	"metric":   {mustLookupUnit("C"), mustLookupUnit("m/s"), mustLookupUnit("km"), "degrees Celsius", "meters per second", "degrees"},
+	"imperial": {mustLookupUnit("F"), mustLookupUnit("mph"), mustLookupUnit("mi"), "degrees Fahrenheit", "miles per hour", "degrees"},
+	"standard": {mustLookupUnit("K"), mustLookupUnit("m/s"), mustLookupUnit("km"), "kelvin", "meters per second", "kelvin"},
+}
+
+// displayUnits is the unit system selected by applyUnitsAndLang.
+var displayUnits = unitSystems["metric"]
+
+// applyUnitsAndLang resolves --units, --lang and --precision against the
+// config file.
+func applyUnitsAndLang() error {
+	unitsFromFlag, langFromFlag = unitsName != "", langName != ""
+	if unitsName == "" {
+		unitsName = config.Units
+	}
+	if unitsName != "" {
+		system, ok := unitSystems[strings.ToLower(unitsName)]
+		if !ok {
+			return fmt.Errorf("unknown units %q, use metric, imperial or standard", unitsName)
+		}
+		displayUnits = system
+	}
+	if langName == "" {
+		langName = config.Lang
+	}
+	for name, prefs := range config.Locations {
+		if _, ok := unitSystems[strings.ToLower(prefs.Units)]; prefs.Units != "" && !ok {
+			return fmt.Errorf("unknown units %q for @%s in the config file, use metric, imperial or standard", prefs.Units, name)
+		}
+	}
+	for field, digits := range config.Precision {
+		if err := setPrecision(field, digits); err != nil {
+			return fmt.Errorf("config file precision: %w", err)
+		}
+	}
+	if precisionSpec != "" {
+		for _, part := range strings.Split(precisionSpec, ",") {
+			field, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+			digits, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid --precision %q, use e.g. temp=0,wind=1", precisionSpec)
+			}
+			if err := setPrecision(field, digits); err != nil {
+				return fmt.Errorf("--precision: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func setPrecision(field string, digits int) error {
+	if !slices.Contains(precisionFields, field) {
+		return fmt.Errorf("unknown field %q, use %s", field, strings.Join(precisionFields, " or "))
+	}
+	if digits < 0 || digits > 6 {
+		return fmt.Errorf("%s precision %d is out of range, use 0 to 6 decimals", field, digits)
+	}
+	displayPrecision[field] = digits
+	return nil
+}
+
+// decimals returns the decimals to show field with, or def if
+// --precision doesn't set it. Use it with the %.*f verb.
+func decimals(field string, def int) int {
+	if d, ok := displayPrecision[field]; ok {
+		return d
+	}
+	return def
+}
+
+// roundField rounds v to the --precision decimals of field, if set, so
+// machine-read outputs carry the same values as the text ones.
+func roundField(field string, v float64) float64 {
+	d, ok := displayPrecision[field]
+	if !ok {
+		return v
+	}
+	scale := math.Pow10(d)
+	if v = math.Round(v*scale) / scale; v == 0 {
+		return 0 // not -0
+	}
+	return v
+}
+
+// showTemp converts a temperature in °C to the display units, rounded to
+// the --precision for "temp".
+func showTemp(celsius float64) float64 {
+	return roundField("temp", displayUnits.temp.fromBase(celsius))
+}
+
+// showTempChange converts a temperature difference in °C, such as between
+// two days or a forecast error, to the display units.
+func showTempChange(celsius float64) float64 {
+	return roundField("temp", displayUnits.temp.fromBase(celsius)-displayUnits.temp.fromBase(0))
+}
+
+// showSpeed converts a speed in m/s to the display units, rounded to the
+// --precision for "wind".
+func showSpeed(ms float64) float64 {
+	return roundField("wind", displayUnits.speed.fromBase(ms))
+}
+
+// convertCurrentUnits converts the temperatures and wind speeds of data to
+// the display units, for outputs that print the API's fields as they are.
+func convertCurrentUnits(data *CurrentWeatherResponse) {
+	convertMainUnits(&data.Main)
+	data.Wind.Speed, data.Wind.Gust = showSpeed(data.Wind.Speed), showSpeed(data.Wind.Gust)
+}
+
+// convertForecastUnits is convertCurrentUnits for each forecast entry.
+func convertForecastUnits(data *ForecastResponse) {
+	for i := range data.List {
+		e := &data.List[i]
+		convertMainUnits(&e.Main)
+		e.Wind.Speed, e.Wind.Gust = showSpeed(e.Wind.Speed), showSpeed(e.Wind.Gust)
+	}
+}
+
+func convertMainUnits(m *Main) {
+	m.Temp, m.FeelsLike = showTemp(m.Temp), showTemp(m.FeelsLike)
+	m.TempMin, m.TempMax = showTemp(m.TempMin), showTemp(m.TempMax)
+}
+
+// inDisplayUnits converts data with convert before displaying it.
+func inDisplayUnits[T any](convert func(T), display func(T)) func(T) {
+	return func(data T) {
+		convert(data)
+		display(data)
+	}
+}
+
+// unitAliases maps lower-case spellings to unit symbols.
+var unitAliases = map[string]string{
+	"c": "°C", "celsius": "°C", "f": "°F", "fahrenheit": "°F", "k": "K", "kelvin": "K",
+	"m/s": "m/s", "mps": "m/s", "kmh": "km/h", "km/h": "km/h", "kph": "km/h", "mph": "mph",
+	"kt": "knots", "kts": "knots", "kn": "knots", "knot": "knots", "knots": "knots",
+	"bft": "Beaufort", "beaufort": "Beaufort",
+	"hpa": "hPa", "mbar": "hPa", "mb": "hPa", "kpa": "kPa", "inhg": "inHg", "mmhg": "mmHg", "torr": "mmHg", "psi": "psi",
+	"mm": "mm", "cm": "cm", "in": "in", "inch": "in", "inches": "in", `"`: "in",
+	"km": "km", "m": "m", "mi": "mi", "mile": "mi", "miles": "mi",
+}
+
+// lookupUnit finds a unit by symbol or alias, ignoring case and degree
+// signs.
+func lookupUnit(name string) (unit, error) {
+	key := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "°"))
+	symbol, ok := unitAliases[key]
+	if ok {
+		for _, u := range units {
+			if u.symbol == symbol {
+				return u, nil
+			}
+		}
+	}
+	return unit{}, fmt.Errorf("unknown unit %q", name)
+}
+
+func mustLookupUnit(name string) unit {
+	u, err := lookupUnit(name)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+// convertUnits converts value between units of the same quantity.
+func convertUnits(value float64, from, to unit) (float64, error) {
+	if from.quantity != to.quantity {
+		return 0, fmt.Errorf("can't convert %s (%s) to %s (%s)", from.symbol, from.quantity, to.symbol, to.quantity)
+	}
+	return to.fromBase(from.toBase(value)), nil
+}
+
+// beaufortToSpeed returns the mean wind speed in m/s of a Beaufort force,
+// from the empirical v = 0.836 B^(3/2).
+func beaufortToSpeed(force float64) float64 {
+	return 0.836 * math.Pow(max(force, 0), 1.5)
+}
+
+// speedToBeaufort returns the Beaufort force of a wind speed in m/s,
+// rounded to a whole force and capped at 12 (hurricane).
+func speedToBeaufort(speed float64) float64 {
+	return min(math.Round(math.Pow(max(speed, 0)/0.836, 2.0/3)), 12)
+}
+
+var numberPrefix = regexp.MustCompile(`^[-+]?\.?[0-9]`)
+
+var quantityPattern = regexp.MustCompile(`^\s*([-+]?[0-9]*\.?[0-9]+)\s*(.+?)\s*$`)
+
+// runConvert implements `weather convert`, e.g. `weather convert 72F` or
+// `weather convert '15 m/s' --to knots`.
+func runConvert(args []string) error {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	to := fs.String("to", "", "Unit to convert to (default every unit of the same quantity)")
+	quantity, flags := splitConvertArgs(args)
+	fs.Parse(flags)
+
+	input := strings.Join(quantity, " ")
+	m := quantityPattern.FindStringSubmatch(input)
+	if m == nil {
+		return fmt.Errorf("usage: weather convert [--to UNIT] <value><unit>, e.g. 72F or '15 m/s'")
+	}
+	value, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return fmt.Errorf("invalid value %q", m[1])
+	}
+	from, err := lookupUnit(m[2])
+	if err != nil {
+		return err
+	}
+
+	targets := []unit{}
+	if *to != "" {
+		target, err := lookupUnit(*to)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, target)
+	} else {
+		for _, u := range units {
+			if u.quantity == from.quantity && u.symbol != from.symbol {
+				targets = append(targets, u)
+			}
+		}
+	}
+	for _, target := range targets {
+		converted, err := convertUnits(value, from, target)
+		if err != nil {
+			return err
+		}
+		fmt.Println(formatQuantity(converted, target))
+	}
+	return nil
+}
+
+// splitConvertArgs separates the quantity from the flags of `weather
+// convert`. The quantity may come before --to, and may be negative, so the
+// arguments are split by hand rather than stopping at the first positional
+// one. The only flag takes a value.
+func splitConvertArgs(args []string) (quantity, flags []string) {
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--":
+			quantity, i = append(quantity, args[i+1:]...), len(args)
+		case !strings.HasPrefix(arg, "-") || numberPrefix.MatchString(arg):
+			quantity = append(quantity, arg)
+		case strings.Contains(arg, "=") || i == len(args)-1:
+			flags = append(flags, arg)
+		default:
+			flags = append(flags, arg, args[i+1])
+			i++
+		}
+	}
+	return quantity, flags
+}
+
+// formatQuantity formats a value with its unit symbol.
+func formatQuantity(value float64, u unit) string {
+	switch u.symbol {
+	case "Beaufort":
+		return fmt.Sprintf("Beaufort %.0f", value)
+	case "°C", "°F":
+		return fmt.Sprintf("%.1f%s", value, u.symbol)
+	}
+	return strconv.FormatFloat(math.Round(value*100)/100, 'f', -1, 64) + " " + u.symbol
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// withUnits runs f with the named --units system and restores metric.
+func withUnits(t *testing.T, name string, f func()) {
+	t.Helper()
+	displayUnits = unitSystems[name]
+	defer func() { displayUnits = unitSystems["metric"] }()
+	f()
+}
+
+func TestShowTempAndChange(t *testing.T) {
+	tests := []struct {
+		units        string
+		celsius      float64
+		temp, change float64
+	}{
+		{"metric", 20, 20, 20},
+		{"imperial", 20, 68, 36},
+		{"imperial", -5, 23, -9},
+		{"standard", 0, 273.15, 0},
+	}
+	for _, tt := range tests {
+		withUnits(t, tt.units, func() {
+			if got := showTemp(tt.celsius); math.Abs(got-tt.temp) > 1e-9 {
+				t.Errorf("%s: showTemp(%v) = %v, want %v", tt.units, tt.celsius, got, tt.temp)
+			}
+			if got := showTempChange(tt.celsius); math.Abs(got-tt.change) > 1e-9 {
+				t.Errorf("%s: showTempChange(%v) = %v, want %v", tt.units, tt.celsius, got, tt.change)
+			}
+		})
+	}
+}
+
+func TestApplyPrecision(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]int
+		spec    string
+		want    map[string]int
+		wantErr string
+	}{
+		{"none", nil, "", map[string]int{}, ""},
+		{"flag", nil, "temp=0,wind=1", map[string]int{"temp": 0, "wind": 1}, ""},
+		{"spaces", nil, " temp=2 , wind=0", map[string]int{"temp": 2, "wind": 0}, ""},
+		{"config", map[string]int{"temp": 0}, "", map[string]int{"temp": 0}, ""},
+		{"flag wins by field", map[string]int{"temp": 0, "wind": 2}, "temp=1", map[string]int{"temp": 1, "wind": 2}, ""},
+		{"no value", nil, "temp", nil, `invalid --precision "temp"`},
+		{"not a number", nil, "temp=x", nil, `invalid --precision "temp=x"`},
+		{"unknown field", nil, "pressure=0", nil, `--precision: unknown field "pressure"`},
+		{"out of range", nil, "wind=7", nil, "--precision: wind precision 7 is out of range"},
+		{"bad config", map[string]int{"temp": -1}, "", nil, "config file precision: temp precision -1 is out of range"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			savedConfig, savedSpec := config, precisionSpec
+			defer func() {
+				config, precisionSpec, displayPrecision = savedConfig, savedSpec, map[string]int{}
+				unitsName, langName, displayUnits = "", "", unitSystems["metric"]
+			}()
+			config, precisionSpec, displayPrecision = Config{Precision: tt.config}, tt.spec, map[string]int{}
+
+			err := applyUnitsAndLang()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("applyUnitsAndLang error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(displayPrecision, tt.want) {
+				t.Errorf("precision = %v, want %v", displayPrecision, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitConvertArgs(t *testing.T) {
+	tests := []struct {
+		args            []string
+		quantity, flags []string
+	}{
+		{[]string{"72F"}, []string{"72F"}, nil},
+		{[]string{"15", "m/s", "--to", "km/h"}, []string{"15", "m/s"}, []string{"--to", "km/h"}},
+		{[]string{"--to", "F", "20C"}, []string{"20C"}, []string{"--to", "F"}},
+		{[]string{"-to=K", "-5C"}, []string{"-5C"}, []string{"-to=K"}},
+		{[]string{"-.5", "C"}, []string{"-.5", "C"}, nil},
+		{[]string{"+3C", "--to"}, []string{"+3C"}, []string{"--to"}},
+		{[]string{"--to", "F", "--", "-40", "C"}, []string{"-40", "C"}, []string{"--to", "F"}},
+	}
+	for _, tt := range tests {
+		quantity, flags := splitConvertArgs(tt.args)
+		if !slices.Equal(quantity, tt.quantity) || !slices.Equal(flags, tt.flags) {
+			t.Errorf("splitConvertArgs(%q) = %q, %q; want %q, %q", tt.args, quantity, flags, tt.quantity, tt.flags)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import "fmt"
+
+// maxVisibility is the most the API reports, in metres; anything beyond
+// is reported as this.
+const maxVisibility = 10000
+
+// visibilityClass classifies visibility in metres the way forecasters
+// and pilots describe it: dense fog below 200 m, fog below 1 km, then mist
+// in humid air or haze in dry air up to 5 km, moderate up to 10 km, and
+// clear beyond.
+func visibilityClass(metres, humidity int) string {
+	switch {
+	case metres < 200:
+		return "dense fog"
+	case metres < 1000:
+		return "fog"
+	case metres < 5000 && humidity >= 80:
+		return "mist"
+	case metres < 5000:
+		return "haze"
+	case metres < maxVisibility:
+		return "moderate"
+	}
+	return "clear"
+}
+
+// formatVisibility formats visibility in metres in the distance unit of
+// system, as e.g. "850 m" or "6.5 km", with "10+ km" for the API's maximum,
+// or in imperial units as e.g. "2789 ft" or "4.0 mi", with "6+ mi".
+func formatVisibility(metres int, system unitSystem) string {
+	if system.distance.symbol == "mi" {
+		miles := system.distance.fromBase(float64(metres) / 1000)
+		switch {
+		case metres >= maxVisibility:
+			return "6+ mi"
+		case miles < 1:
+			return fmt.Sprintf("%.0f ft", miles*5280)
+		}
+		return fmt.Sprintf("%.1f mi", miles)
+	}
+	switch {
+	case metres >= maxVisibility:
+		return "10+ km"
+	case metres < 1000:
+		return fmt.Sprintf("%d m", metres)
+	}
+	return fmt.Sprintf("%.1f km", float64(metres)/1000)
+}
@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestFormatVisibility(t *testing.T) {
+	tests := []struct {
+		units  string
+		metres int
+		want   string
+	}{
+		{"metric", 850, "850 m"},
+		{"metric", 6500, "6.5 km"},
+		{"metric", 10000, "10+ km"},
+		{"standard", 6500, "6.5 km"},
+		{"imperial", 150, "492 ft"},
+		{"imperial", 850, "2789 ft"},
+		{"imperial", 6500, "4.0 mi"},
+		{"imperial", 10000, "6+ mi"},
+	}
+	for _, tt := range tests {
+		if got := formatVisibility(tt.metres, unitSystems[tt.units]); got != tt.want {
+			t.Errorf("%s: formatVisibility(%d) = %q, want %q", tt.units, tt.metres, got, tt.want)
+		}
+	}
+}
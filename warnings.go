@@ -0,0 +1,515 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// WeatherAlert is an official weather warning from a national weather
+// service, as included in the One Call API response.
+type WeatherAlert struct {
+	SenderName  string   `json:"sender_name"`
+	Event       string   `json:"event"`
+	Start       int64    `json:"start"`
+	End         int64    `json:"end"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+}
+
+// id identifies the warning across polls. The API gives warnings no ID of
+// their own, so it is derived from the issuer, event and start time.
+func (a WeatherAlert) id() string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%d", a.SenderName, a.Event, a.Start)))
+	return hex.EncodeToString(sum[:6])
+}
+
+// revision changes whenever the issuer updates the warning's end time or
+// text.
+func (a WeatherAlert) revision() string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d\x00%s", a.End, a.Description)))
+	return hex.EncodeToString(sum[:6])
+}
+
+// warningSeverities are the CAP severity levels, least serious first.
+var warningSeverities = []string{"minor", "moderate", "severe", "extreme"}
+
+// severityWords grade warnings whose text doesn't state a CAP severity,
+// using the colour codes and terms weather services put in event names.
+// Explicit grades and colours win over the generic terms, so a "Yellow
+// Warning" is moderate.
+var severityWords = []map[string]string{
+	{
+		"extreme": "extreme", "red": "extreme",
+		"severe": "severe", "orange": "severe", "amber": "severe",
+		"moderate": "moderate", "yellow": "moderate",
+		"minor": "minor", "green": "minor",
+	},
+	{
+		"emergency": "extreme", "warning": "severe", "watch": "moderate",
+		"advisory": "minor", "statement": "minor",
+	},
+}
+
+// warningCategories maps the categories warnings can be filtered by to the
+// words in event names and tags that put a warning in them.
+var warningCategories = map[string][]string{
+	"flood":        {"flood", "flooding", "flash"},
+	"wind":         {"wind", "gale", "gust", "gusts", "hurricane", "typhoon", "cyclone", "tornado"},
+	"heat":         {"heat", "hot", "high"},
+	"cold":         {"cold", "frost", "freeze", "freezing", "low", "chill"},
+	"rain":         {"rain", "rainfall", "precipitation"},
+	"snow":         {"snow", "ice", "icing", "blizzard", "avalanche", "avalanches"},
+	"thunderstorm": {"thunderstorm", "thunderstorms", "thunder", "lightning"},
+	"fog":          {"fog"},
+	"fire":         {"fire", "wildfire"},
+	"coastal":      {"coastal", "marine", "surf", "tide", "tsunami"},
+	"air":          {"air", "dust", "smoke", "pollution"},
+}
+
+// words splits text into lower-case words.
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) })
+}
+
+// capField returns the value of a "Name: value" line in a warning's
+// description, as some services include CAP fields there.
+func capField(description, name string) string {
+	for _, line := range strings.Split(description, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(key), name) {
+			return strings.ToLower(strings.TrimSpace(value))
+		}
+	}
+	return ""
+}
+
+// severity returns the warning's CAP severity, from its description if it
+// states one and otherwise from its event name, or "" when unknown.
+func (a WeatherAlert) severity() string {
+	if s := capField(a.Description, "severity"); slices.Contains(warningSeverities, s) {
+		return s
+	}
+	for _, grades := range severityWords {
+		level := ""
+		for _, w := range words(a.Event) {
+			if s, ok := grades[w]; ok && slices.Index(warningSeverities, s) > slices.Index(warningSeverities, level) {
+				level = s
+			}
+		}
+		if level != "" {
+			return level
+		}
+	}
+	return ""
+}
+
+// certainty and urgency return the warning's CAP certainty ("likely") and
+// urgency ("expected"), where its description states them.
+func (a WeatherAlert) certainty() string { return capField(a.Description, "certainty") }
+func (a WeatherAlert) urgency() string   { return capField(a.Description, "urgency") }
+
+// categories returns the categories the warning's event name and tags put
+// it in, in alphabetical order.
+func (a WeatherAlert) categories() []string {
+	text := words(a.Event + " " + strings.Join(a.Tags, " "))
+	var categories []string
+	for _, category := range sortedKeys(warningCategories) {
+		for _, w := range warningCategories[category] {
+			if slices.Contains(text, w) {
+				categories = append(categories, category)
+				break
+			}
+		}
+	}
+	return categories
+}
+
+// warningFilter selects warnings by minimum severity and category.
+// Warnings of unknown severity pass any minimum, so nothing serious is
+// dropped for lack of a grade.
+type warningFilter struct {
+	minSeverity string
+	categories  []string
+}
+
+// validate checks the filter's severity and category names.
+func (f warningFilter) validate() error {
+	if f.minSeverity != "" && !slices.Contains(warningSeverities, f.minSeverity) {
+		return fmt.Errorf("invalid minimum severity %q, use one of: %s", f.minSeverity, strings.Join(warningSeverities, ", "))
+	}
+	for _, c := range f.categories {
+		if _, ok := warningCategories[c]; !ok {
+			return fmt.Errorf("unknown warning category %q, use one of: %s", c, strings.Join(sortedKeys(warningCategories), ", "))
+		}
+	}
+	return nil
+}
+
+func (f warningFilter) matches(a WeatherAlert) bool {
+	if s := a.severity(); f.minSeverity != "" && s != "" && slices.Index(warningSeverities, s) < slices.Index(warningSeverities, f.minSeverity) {
+		return false
+	}
+	if len(f.categories) == 0 {
+		return true
+	}
+	for _, c := range a.categories() {
+		if slices.Contains(f.categories, c) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetWeatherAlerts fetches the official warnings in force at coordinates.
+func GetWeatherAlerts(lat, lon float64, apiKey string) ([]WeatherAlert, error) {
+	data, err := GetOneCall(lat, lon, []string{"current", "minutely", "hourly", "daily"}, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	return data.Alerts, nil
+}
+
+// seenWarning is what the warnings task last saw of a warning.
+type seenWarning struct {
+	Alert    WeatherAlert `json:"alert"`
+	Revision string       `json:"revision"`
+}
+
+// seenWarnings maps location names to the warnings seen there, by ID.
+type seenWarnings map[string]map[string]seenWarning
+
+// seenWarningsMu serializes warnings tasks running in the same daemon.
+var seenWarningsMu sync.Mutex
+
+func seenWarningsPath() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "warnings.json"), nil
+}
+
+func loadSeenWarnings() (seenWarnings, error) {
+	path, err := seenWarningsPath()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(seenWarnings)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return seen, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seen warnings: %w", err)
+	}
+	if err := json.Unmarshal(data, &seen); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return seen, nil
+}
+
+func saveSeenWarnings(seen seenWarnings) error {
+	path, err := seenWarningsPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(seen, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode seen warnings: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write seen warnings: %w", err)
+	}
+	return nil
+}
+
+// Changes to a warning between polls.
+const (
+	warningNew     = "new"
+	warningUpdated = "updated"
+	warningExpired = "expired"
+)
+
+// warningChange is a warning that is new, updated or expired since the
+// previous poll of its location.
+type warningChange struct {
+	Kind     string
+	Location string
+	Alert    WeatherAlert
+}
+
+// diffWarnings compares the warnings now in force at location with those
+// seen before, records them in seen and returns what changed. Warnings
+// that have disappeared or whose end has passed count as expired.
+func diffWarnings(seen seenWarnings, location string, current []WeatherAlert, now time.Time) []warningChange {
+	before := seen[location]
+	after := make(map[string]seenWarning)
+	var changes []warningChange
+	for _, a := range current {
+		if a.End != 0 && time.Unix(a.End, 0).Before(now) {
+			continue
+		}
+		id, revision := a.id(), a.revision()
+		after[id] = seenWarning{Alert: a, Revision: revision}
+		prev, ok := before[id]
+		switch {
+		case !ok:
+			changes = append(changes, warningChange{warningNew, location, a})
+		case prev.Revision != revision:
+			changes = append(changes, warningChange{warningUpdated, location, a})
+		}
+	}
+	ids := make([]string, 0, len(before))
+	for id := range before {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if _, ok := after[id]; !ok {
+			changes = append(changes, warningChange{warningExpired, location, before[id].Alert})
+		}
+	}
+	if len(after) == 0 {
+		delete(seen, location)
+	} else {
+		seen[location] = after
+	}
+	return changes
+}
+
+// warningMessage describes a warning change. New and updated warnings are
+// severe, so they break through quiet hours.
+func warningMessage(c warningChange) Message {
+	a := c.Alert
+	title := map[string]string{warningNew: "Weather warning", warningUpdated: "Updated warning", warningExpired: "Warning ended"}[c.Kind]
+	msg := Message{Title: fmt.Sprintf("%s for %s: %s", title, c.Location, a.Event), Severe: c.Kind != warningExpired}
+	if c.Kind == warningExpired {
+		return msg
+	}
+	// Routes grade warnings like alerts; CAP's minor and moderate are
+	// both a warning.
+	switch msg.Severity = severityWarning; a.severity() {
+	case "severe":
+		msg.Severity = severitySevere
+	case "extreme":
+		msg.Severity = severityExtreme
+	}
+	period := "from " + time.Unix(a.Start, 0).Local().Format("Mon 15:04")
+	if a.End != 0 {
+		period += " until " + time.Unix(a.End, 0).Local().Format("Mon 15:04")
+	}
+	msg.Parts = append(msg.Parts, period)
+	if a.SenderName != "" {
+		msg.Parts = append(msg.Parts, "issued by "+a.SenderName)
+	}
+	if summary, _, _ := strings.Cut(strings.TrimSpace(a.Description), "\n"); summary != "" {
+		msg.Parts = append(msg.Parts, summary)
+	}
+	return msg
+}
+
+// warningsTask polls the official warnings for its locations, or every
+// favorite if it has none, and notifies only about warnings that are new,
+// updated or expired since the previous poll. Warnings outside the task's
+// minimum severity and categories are ignored.
+func warningsTask(task DaemonTask, locations []Location, apiKey string) (func() error, error) {
+	channels, targets, err := taskNotifiers(task)
+	if err != nil {
+		return nil, err
+	}
+	filter := warningFilter{task.MinSeverity, task.Categories}
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+	return func() error {
+		watched := locations
+		if len(watched) == 0 {
+			// Favorites are read on every run so new ones are picked up.
+			favorites, err := loadFavorites()
+			if err != nil {
+				return err
+			}
+			for _, name := range sortedKeys(favorites) {
+				watched = append(watched, favorites[name].Location(name))
+			}
+		}
+
+		seenWarningsMu.Lock()
+		defer seenWarningsMu.Unlock()
+		seen, err := loadSeenWarnings()
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		var fetchErr error
+		var changes []warningChange
+		for _, loc := range watched {
+			lat, lon, err := loc.coordinates(apiKey)
+			var alerts []WeatherAlert
+			if err == nil {
+				alerts, err = GetWeatherAlerts(lat, lon, apiKey)
+			}
+			if err != nil {
+				// Keep what was seen, so a failed poll doesn't expire warnings.
+				fetchErr = errors.Join(fetchErr, fmt.Errorf("fetching warnings for %s: %w", loc, err))
+				continue
+			}
+			alerts = slices.DeleteFunc(alerts, func(a WeatherAlert) bool { return !filter.matches(a) })
+			changes = append(changes, diffWarnings(seen, loc.String(), alerts, now)...)
+		}
+
+		var sendErr error
+		var entries []AlertLogEntry
+		for _, c := range changes {
+			entry := AlertLogEntry{At: now, Rule: task.Name, Event: eventFired, Location: c.Location, Severity: severitySevere}
+			if c.Kind == warningExpired {
+				entry.Event, entry.Severity = eventCleared, severityNone
+			}
+			entries = append(entries, entry)
+			msg := warningMessage(c)
+			for i, n := range targets {
+				entry := AlertLogEntry{At: now, Rule: task.Name, Event: eventSent, Location: c.Location, Channel: channels[i]}
+				skip, held := delivery(n, msg)
+				if skip {
+					continue
+				}
+				if held {
+					entry.Event = eventHeld
+				}
+				if err := n.Notify(msg); err != nil {
+					entry.Event, entry.Error = eventFailed, err.Error()
+					sendErr = errors.Join(sendErr, fmt.Errorf("sending via %s: %w", channels[i], err))
+				}
+				entries = append(entries, entry)
+			}
+		}
+		var emitErr error
+		for _, entry := range entries {
+			emitErr = errors.Join(emitErr, emitCloudEvent(cloudEventAlert+"."+entry.Event, entry.Location, entry.At, entry))
+		}
+		return errors.Join(fetchErr, sendErr, saveSeenWarnings(seen), logAlertEvents(entries...), emitErr)
+	}, nil
+}
+
+// runWarnings implements `weather warnings`, listing the official
+// warnings in force for a location.
+func runWarnings(args []string) error {
+	fs := flag.NewFlagSet("warnings", flag.ExitOnError)
+	locFlags := addLocationFlags(fs)
+	minSeverity := fs.String("min-severity", "", "Only warnings at least this severe: "+strings.Join(warningSeverities, ", "))
+	var categories stringList
+	fs.Var(&categories, "category", "Only warnings in this category (repeatable): "+strings.Join(sortedKeys(warningCategories), ", "))
+	fs.Parse(args)
+
+	loc, hasFlagLoc, err := locFlags.location()
+	switch {
+	case err != nil:
+	case hasFlagLoc:
+	case fs.NArg() > 0:
+		loc, err = locFlags.resolveCity(strings.Join(fs.Args(), " "))
+	default:
+		return fmt.Errorf("usage: weather warnings [--min-severity LEVEL] [--category NAME]... <city|@favorite>")
+	}
+	if err != nil {
+		return err
+	}
+	usePreferences(loc)
+	filter := warningFilter{*minSeverity, categories}
+	if err := filter.validate(); err != nil {
+		return err
+	}
+	return showWarnings(loc, filter, apiKeyFromEnv())
+}
+
+// showWarningsFor prints the warnings in force for each of locs, for the
+// classic interface's --alerts. Like showWeatherFor, a location that fails
+// doesn't stop the others.
+func showWarningsFor(locs []Location, apiKey string) error {
+	var failed []error
+	for i, loc := range locs {
+		if len(locs) > 1 {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("Warnings for %s:\n", loc)
+		}
+		if err := showWarnings(loc, warningFilter{}, apiKey); err != nil {
+			failed = append(failed, err)
+		}
+	}
+	if len(locs) == 1 || len(failed) == 0 {
+		return errors.Join(failed...)
+	}
+	return fmt.Errorf("%d of %d locations failed:\n%w", len(failed), len(locs), errors.Join(failed...))
+}
+
+// showWarnings prints the official warnings in force for loc that pass
+// filter.
+func showWarnings(loc Location, filter warningFilter, apiKey string) error {
+	lat, lon, err := loc.coordinates(apiKey)
+	if err != nil {
+		return err
+	}
+	alerts, err := GetWeatherAlerts(lat, lon, apiKey)
+	if err != nil {
+		return fmt.Errorf("fetching warnings for %s: %w", loc, err)
+	}
+	shown := slices.DeleteFunc(slices.Clone(alerts), func(a WeatherAlert) bool { return !filter.matches(a) })
+	if len(shown) == 0 {
+		fmt.Printf("No warnings in force for %s", loc)
+		if hidden := len(alerts); hidden > 0 {
+			fmt.Printf(" (%d hidden by the filters)", hidden)
+		}
+		fmt.Println(".")
+		return nil
+	}
+	for i, a := range shown {
+		if i > 0 {
+			fmt.Println()
+		}
+		printWarning(alertFrom(a))
+	}
+	return nil
+}
+
+// printWarning prints a warning with its grading, issuer, period and text.
+func printWarning(a Alert) {
+	var grading []string
+	for _, g := range []string{a.Severity, a.Certainty, a.Urgency} {
+		if g != "" {
+			grading = append(grading, g)
+		}
+	}
+	heading := a.Event
+	if len(grading) > 0 {
+		heading += " (" + strings.Join(grading, ", ") + ")"
+	}
+	if len(a.Categories) > 0 {
+		heading += " [" + strings.Join(a.Categories, ", ") + "]"
+	}
+	fmt.Println(heading)
+	if a.Sender != "" {
+		fmt.Printf("  Issued by: %s\n", a.Sender)
+	}
+	period := formatDate(a.Start.Local(), "Mon Jan 2 15:04")
+	if !a.End.IsZero() {
+		period += " – " + formatDate(a.End.Local(), "Mon Jan 2 15:04")
+	}
+	fmt.Printf("  In force: %s\n", period)
+	for _, line := range strings.Split(strings.TrimSpace(a.Description), "\n") {
+		fmt.Printf("  %s\n", line)
+	}
+}
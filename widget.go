@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// i3Block is a status block in the i3bar protocol, also accepted by
+// i3blocks (format=json) and most i3status wrapper scripts.
+type i3Block struct {
+	FullText  string `json:"full_text"`
+	ShortText string `json:"short_text"`
+	Color     string `json:"color,omitempty"`
+}
+
+// temperatureColor picks a bar color for a temperature in °C.
+func temperatureColor(temp float64) string {
+	switch {
+	case temp <= 0:
+		return "#8ab4f8"
+	case temp < 15:
+		return "#81d4fa"
+	case temp < 25:
+		return "#a5d6a7"
+	case temp < 32:
+		return "#ffcc80"
+	default:
+		return "#ef9a9a"
+	}
+}
+
+func printI3Block(block i3Block) {
+	json.NewEncoder(os.Stdout).Encode(block)
+}
+
+// displayCurrentWeatherI3 prints current weather as a single i3bar block.
+func displayCurrentWeatherI3(data *CurrentWeatherResponse) {
+	condition := "N/A"
+	if len(data.Weather) > 0 {
+		condition = data.Weather[0].Main
+	}
+	printI3Block(i3Block{
+		FullText: lsprintf("%s: %.*f%s %s, %d%%, %.*f %s", data.Name, decimals("temp", 0), showTemp(data.Main.Temp), displayUnits.temp.symbol,
+			condition, data.Main.Humidity, decimals("wind", 1), showSpeed(data.Wind.Speed), displayUnits.speed.symbol),
+		ShortText: lsprintf("%.*f%s", decimals("temp", 0), showTemp(data.Main.Temp), displayUnits.temp.symbol),
+		Color:     temperatureColor(data.Main.Temp),
+	})
+}
+
+// displayForecastI3 prints the next forecast entry as a single i3bar block.
+func displayForecastI3(data *ForecastResponse) {
+	if len(data.List) == 0 {
+		printI3Block(i3Block{FullText: "No forecast", ShortText: "N/A"})
+		return
+	}
+	entry := data.List[0]
+	condition := "N/A"
+	if len(entry.Weather) > 0 {
+		condition = entry.Weather[0].Main
+	}
+	at := time.Unix(entry.Dt, 0).Local().Format("15:04")
+	printI3Block(i3Block{
+		FullText:  lsprintf("%s %s: %.*f%s %s, %.0f%% rain", data.City.Name, at, decimals("temp", 0), showTemp(entry.Main.Temp), displayUnits.temp.symbol, condition, entry.Pop*100),
+		ShortText: lsprintf("%s %.*f%s", at, decimals("temp", 0), showTemp(entry.Main.Temp), displayUnits.temp.symbol),
+		Color:     temperatureColor(entry.Main.Temp),
+	})
+}
+
+// displayCurrentWeatherConky prints a single short line for conky's exec.
+func displayCurrentWeatherConky(data *CurrentWeatherResponse) {
+	condition := "N/A"
+	if len(data.Weather) > 0 {
+		condition = data.Weather[0].Main
+	}
+	lprintf("%.*f%s %s %d%% %.*f%s\n", decimals("temp", 0), showTemp(data.Main.Temp), displayUnits.temp.symbol, condition, data.Main.Humidity,
+		decimals("wind", 1), showSpeed(data.Wind.Speed), displayUnits.speed.symbol)
+}
+
+// displayForecastConky prints the next few forecast entries on one line.
+func displayForecastConky(data *ForecastResponse) {
+	for i, entry := range data.List {
+		if i == 4 {
+			break
+		}
+		if i > 0 {
+			fmt.Print("  ")
+		}
+		lprintf("%s %.*f%s", time.Unix(entry.Dt, 0).Local().Format("15h"), decimals("temp", 0), showTemp(entry.Main.Temp), displayUnits.temp.symbol)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ZabbixConfig is the "zabbix" section of the config file.
+type ZabbixConfig struct {
+	// Server is the Zabbix server or proxy trapper address, host[:port].
+	Server string `json:"server,omitempty"`
+	// Items overrides the item key sent for each value in zabbixValues,
+	// e.g. {"temp": "weather.temperature"}.
+	Items map[string]string `json:"items,omitempty"`
+}
+
+// zabbixValues are the values that can be sent, with their default item
+// keys being "weather." followed by the name. "aqi" is added by --aqi.
+var zabbixValues = map[string]func(*CurrentWeatherResponse) string{
+	"temp": func(d *CurrentWeatherResponse) string {
+		return strconv.FormatFloat(d.Main.Temp, 'f', decimals("temp", 1), 64)
+	},
+	"feels_like": func(d *CurrentWeatherResponse) string {
+		return strconv.FormatFloat(d.Main.FeelsLike, 'f', decimals("temp", 1), 64)
+	},
+	"humidity": func(d *CurrentWeatherResponse) string { return strconv.Itoa(d.Main.Humidity) },
+	"pressure": func(d *CurrentWeatherResponse) string { return strconv.Itoa(d.Main.Pressure) },
+	"wind": func(d *CurrentWeatherResponse) string {
+		return strconv.FormatFloat(d.Wind.Speed, 'f', decimals("wind", 1), 64)
+	},
+	"clouds": func(d *CurrentWeatherResponse) string { return strconv.Itoa(d.Clouds.All) },
+}
+
+const zabbixDefaultPort = "10051"
+
+// zabbixItem is one value in a Zabbix sender request.
+type zabbixItem struct {
+	Host  string `json:"host"`
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Clock int64  `json:"clock"`
+}
+
+// runZabbix implements `weather zabbix`, pushing the current weather to
+// Zabbix trapper items with the sender protocol.
+func runZabbix(args []string) error {
+	fs := flag.NewFlagSet("zabbix", flag.ExitOnError)
+	locFlags := addLocationFlags(fs)
+	server := fs.String("server", config.Zabbix.Server, "Zabbix server or proxy, host[:port] (default port "+zabbixDefaultPort+")")
+	host := fs.String("host", "", "Host name the items belong to in Zabbix, e.g. weather.nairobi")
+	aqi := fs.Bool("aqi", false, "Also send the air quality index (1-5) as weather.aqi")
+	printOnly := fs.Bool("print", false, "Print the values in zabbix_sender --input-file format (with -T) instead of sending them")
+	fs.Parse(args)
+
+	loc, hasFlagLoc, err := locFlags.location()
+	switch {
+	case err != nil:
+	case hasFlagLoc:
+	case fs.NArg() > 0:
+		loc, err = locFlags.resolveCity(strings.Join(fs.Args(), " "))
+	default:
+		return fmt.Errorf("usage: weather zabbix --host HOST [--server HOST[:PORT]] [--aqi] [--print] <city|@favorite>")
+	}
+	if err != nil {
+		return err
+	}
+	if *host == "" {
+		return fmt.Errorf("please give the Zabbix host name with --host")
+	}
+	if *server == "" && !*printOnly {
+		return fmt.Errorf("please give the Zabbix server with --server or \"server\" in the \"zabbix\" config section, or use --print")
+	}
+
+	apiKey := apiKeyFromEnv()
+	data, err := GetCurrentWeatherAt(loc, apiKey)
+	if err != nil {
+		return fmt.Errorf("fetching current weather for %s: %w", loc, err)
+	}
+	values := make(map[string]string)
+	for name, value := range zabbixValues {
+		values[name] = value(data)
+	}
+	if *aqi {
+		air, err := GetAirPollution(data.Coord.Lat, data.Coord.Lon, apiKey)
+		if err != nil {
+			return fmt.Errorf("fetching air quality for %s: %w", loc, err)
+		}
+		values["aqi"] = strconv.Itoa(air.List[0].Main.AQI)
+	}
+
+	clock := data.Dt
+	if clock == 0 {
+		clock = time.Now().Unix()
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	items := make([]zabbixItem, len(names))
+	for i, name := range names {
+		key := config.Zabbix.Items[name]
+		if key == "" {
+			key = "weather." + name
+		}
+		items[i] = zabbixItem{Host: *host, Key: key, Value: values[name], Clock: clock}
+	}
+
+	if *printOnly {
+		for _, item := range items {
+			fmt.Printf("%s %s %d %s\n", zabbixQuote(item.Host), zabbixQuote(item.Key), item.Clock, item.Value)
+		}
+		return nil
+	}
+	info, err := sendToZabbix(*server, items)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Sent %d items for %s to %s: %s\n", len(items), *host, *server, info)
+	return nil
+}
+
+// sendToZabbix sends items to a Zabbix trapper and returns the server's
+// summary, e.g. "processed: 6; failed: 0; total: 6; seconds spent: 0.0001".
+func sendToZabbix(server string, items []zabbixItem) (string, error) {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, zabbixDefaultPort)
+	}
+	body, err := json.Marshal(map[string]any{
+		"request": "sender data",
+		"data":    items,
+		"clock":   time.Now().Unix(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode Zabbix request: %w", err)
+	}
+
+	conn, err := net.DialTimeout("tcp", server, 10*time.Second)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to Zabbix: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+	if _, err := conn.Write(zabbixPacket(body)); err != nil {
+		return "", fmt.Errorf("failed to send to Zabbix: %w", err)
+	}
+
+	// The reply uses the same framing: "ZBXD", flags, then the data
+	// length as a little-endian uint64.
+	header := make([]byte, 13)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return "", fmt.Errorf("failed to read Zabbix response: %w", err)
+	}
+	if string(header[:4]) != "ZBXD" {
+		return "", fmt.Errorf("unexpected response from Zabbix, is %s a trapper port?", server)
+	}
+	size := binary.LittleEndian.Uint64(header[5:])
+	if size > 1<<20 {
+		return "", fmt.Errorf("Zabbix response too large (%d bytes)", size)
+	}
+	reply := make([]byte, size)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return "", fmt.Errorf("failed to read Zabbix response: %w", err)
+	}
+	var result struct {
+		Response string `json:"response"`
+		Info     string `json:"info"`
+	}
+	if err := json.Unmarshal(reply, &result); err != nil {
+		return "", fmt.Errorf("failed to parse Zabbix response: %w", err)
+	}
+	if result.Response != "success" {
+		return "", fmt.Errorf("Zabbix rejected the data: %s %s", result.Response, result.Info)
+	}
+	if strings.Contains(result.Info, "failed: ") && !strings.Contains(result.Info, "failed: 0;") {
+		return "", fmt.Errorf("Zabbix didn't accept every item (check the host and trapper item keys): %s", result.Info)
+	}
+	return result.Info, nil
+}
+
+// zabbixPacket frames body with the Zabbix protocol header.
+func zabbixPacket(body []byte) []byte {
+	packet := make([]byte, 13, 13+len(body))
+	copy(packet, "ZBXD\x01")
+	binary.LittleEndian.PutUint64(packet[5:], uint64(len(body)))
+	return append(packet, body...)
+}
+
+// zabbixQuote quotes a zabbix_sender input file field when it contains
+// spaces or quotes.
+func zabbixQuote(s string) string {
+	if strings.ContainsAny(s, " \t\"\\") {
+		return strconv.Quote(s)
+	}
+	return s
+}